@@ -2,35 +2,54 @@ package main
 
 import (
     "context"
+    "flag"
     "fmt"
     "log"
     "os"
     "os/signal"
     "syscall"
+    "time"
+    _ "time/tzdata" // draw schedule time zones work without system zoneinfo
 
     "github.com/gsshankar104/telegram-bot/internal/bot"
     "github.com/gsshankar104/telegram-bot/internal/config"
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+    "github.com/gsshankar104/telegram-bot/internal/payment/upigateway"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
     "github.com/gsshankar104/telegram-bot/internal/storage/drive"
+    "github.com/gsshankar104/telegram-bot/internal/storage/memory"
+    "github.com/gsshankar104/telegram-bot/internal/storage/sqlite"
 )
 
 func main() {
+    configFile := flag.String("config", "config/config.yaml", "path to the config file")
+    reloadInterval := flag.Duration("reload-interval", 10*time.Second, "how often to check the config file for changes")
+    flag.Parse()
+
     // Load config
-    //if err := config.Load("config/config.yaml"); err != nil {
-    //    log.Fatalf("Failed to load config: %v", err)
-    //}
+    if err := config.Load(*configFile); err != nil {
+        log.Fatalf("Failed to load config: %v", err)
+    }
+
+    cfg := config.Get()
 
     // Create storage
-    storage, err := drive.NewDriveStorage(context.Background(), "credentials.json")
+    storage, err := newStorage(context.Background(), cfg)
     if err != nil {
         log.Fatalf("Failed to create storage: %v", err)
     }
 
     // Create bot
-    bot, err := bot.New(storage, config.Get())
+    bot, err := bot.New(storage, cfg)
     if err != nil {
         log.Fatalf("Failed to create bot: %v", err)
     }
 
+    if provider := newPaymentProvider(cfg); provider != nil {
+        log.Printf("Collecting payments through %s", provider.Name())
+        bot.SetPaymentProvider(provider)
+    }
+
     // Setup signal handling
     ctx, cancel := context.WithCancel(context.Background())
     defer cancel()
@@ -43,8 +62,64 @@ func main() {
         cancel()
     }()
 
+    // Reload config on change or SIGHUP
+    watcher := config.NewWatcher(*configFile, *reloadInterval,
+        func(cfg *config.Config) {
+            log.Printf("Config reloaded from %s", *configFile)
+            bot.SetConfig(cfg)
+        },
+        func(err error) {
+            log.Printf("Rejected config reload: %v", err)
+            bot.NotifyAdmins(fmt.Sprintf("⚠️ Config reload rejected, keeping the previous config:\n%v", err))
+        },
+    )
+    go watcher.Run(ctx)
+
+    if ds, ok := storage.(*drive.DriveStorage); ok {
+        go ds.RunCacheRefresh(ctx, cfg.Database.CacheRefresh)
+    }
+
+    hupChan := make(chan os.Signal, 1)
+    signal.Notify(hupChan, syscall.SIGHUP)
+    go func() {
+        for range hupChan {
+            log.Printf("Received SIGHUP, reloading config")
+            watcher.Reload()
+        }
+    }()
+
     // Start bot
     if err := bot.Start(ctx); err != nil && err != context.Canceled {
         log.Printf("Bot stopped with error: %v", err)
     }
 }
+
+// newStorage creates the backend selected by database.driver
+func newStorage(ctx context.Context, cfg *config.Config) (storage.Storage, error) {
+    switch cfg.Database.Driver {
+    case "", "drive":
+        return drive.NewDriveStorage(ctx, cfg.Database.CredentialsFile, cfg.Database.DriveFolderID)
+    case "sqlite":
+        return sqlite.NewSQLiteStorage(ctx, cfg.Database.SQLitePath)
+    case "memory":
+        log.Printf("Using in-memory storage; data will be lost on exit")
+        return memory.NewMemoryStorage(), nil
+    default:
+        return nil, fmt.Errorf("unknown database driver %q", cfg.Database.Driver)
+    }
+}
+
+// newPaymentProvider creates the gateway selected by payment.provider, or
+// nil when admins review payments by hand
+func newPaymentProvider(cfg *config.Config) payment.Provider {
+    switch cfg.Payment.Provider {
+    case "upigateway":
+        return upigateway.New(upigateway.Config{
+            BaseURL:     cfg.Payment.UPIGateway.BaseURL,
+            APIKey:      cfg.Payment.UPIGateway.APIKey,
+            RedirectURL: cfg.Payment.UPIGateway.RedirectURL,
+        })
+    default:
+        return nil
+    }
+}
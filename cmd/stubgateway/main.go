@@ -0,0 +1,25 @@
+// Command stubgateway runs the stub UPI gateway locally so the bot's
+// upigateway provider can be tried end to end without real payments.
+// Open an order's payment link to mark it paid.
+package main
+
+import (
+    "flag"
+    "log"
+    "net/http"
+
+    "github.com/gsshankar104/telegram-bot/internal/payment/upigateway/upigatewaytest"
+)
+
+func main() {
+    listen := flag.String("listen", ":8090", "address to serve the stub gateway on")
+    apiKey := flag.String("key", "test-key", "API key the bot must send")
+    webhook := flag.String("webhook", "http://localhost:8080/payment/callback", "bot payment callback URL; empty disables webhooks")
+    flag.Parse()
+
+    gateway := upigatewaytest.NewGateway(*apiKey)
+    gateway.WebhookURL = *webhook
+
+    log.Printf("Stub UPI gateway listening on %s", *listen)
+    log.Fatal(http.ListenAndServe(*listen, gateway))
+}
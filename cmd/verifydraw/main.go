@@ -0,0 +1,52 @@
+// Command verifydraw recomputes a random draw offline. It checks the seed
+// revealed in the proof channel against the hash published when the draw
+// opened, then picks the winners from the seed and the entry file sent by
+// /verify.
+//
+//	verifydraw -seed <seed> -hash <seed hash> -winners 2 draw-<ID>.csv
+package main
+
+import (
+    "flag"
+    "fmt"
+    "log"
+    "os"
+
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+)
+
+func main() {
+    seed := flag.String("seed", "", "seed revealed with the draw result")
+    hash := flag.String("hash", "", "seed hash published when the draw opened; empty skips the check")
+    winners := flag.Int("winners", 1, "number of winners drawn")
+    flag.Parse()
+
+    if *seed == "" || flag.NArg() != 1 {
+        fmt.Fprintln(os.Stderr, "usage: verifydraw -seed <seed> [-hash <seed hash>] [-winners N] <entry file>")
+        os.Exit(2)
+    }
+
+    if *hash != "" {
+        if got := draw.HashSeed(*seed); got != *hash {
+            fmt.Printf("❌ Seed does NOT match the published hash\n   SHA-256(seed) = %s\n", got)
+            os.Exit(1)
+        }
+        fmt.Println("✅ Seed matches the published hash")
+    }
+
+    file, err := os.Open(flag.Arg(0))
+    if err != nil {
+        log.Fatal(err)
+    }
+    defer file.Close()
+
+    entries, err := draw.ReadEntries(file)
+    if err != nil {
+        log.Fatal(err)
+    }
+
+    fmt.Printf("👥 Entries: %d\n🧾 Entries hash: %s\n🏆 Winners:\n", len(entries), draw.HashEntries(entries))
+    for i, entry := range draw.SelectFair(*seed, entries, *winners) {
+        fmt.Printf("%d. %s - Number %d\n", i+1, entry.EntryID, entry.LuckyNumber)
+    }
+}
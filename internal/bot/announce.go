@@ -0,0 +1,146 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strconv"
+    "strings"
+
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+// announceDraw posts a draw result to the proof channel and stores the
+// message ID on the draw record. It reports whether a post was made.
+func (b *Bot) announceDraw(ctx context.Context, result *draw.Result) bool {
+    chatID := b.cfg().Channels.LotteryProofChatID
+    if chatID == 0 || result.Draw == nil {
+        return false
+    }
+
+    msg, err := b.sendMessage(chatID, b.drawAnnouncementText(ctx, result))
+    if err != nil {
+        log.Printf("Failed to announce draw %s: %v", result.Draw.DrawID, err)
+        return false
+    }
+
+    result.Draw.AnnouncementChatID = chatID
+    result.Draw.AnnouncementMessageID = msg.MessageID
+    if err := b.storage.SaveDraw(ctx, result.Draw); err != nil {
+        log.Printf("Failed to save announcement for draw %s: %v", result.Draw.DrawID, err)
+    }
+    return true
+}
+
+// announceCommitment posts a newly opened draw's seed hash to the proof
+// channel, so everyone can later check the revealed seed against it
+func (b *Bot) announceCommitment(d *models.Draw) {
+    chatID := b.cfg().Channels.LotteryProofChatID
+    if chatID == 0 {
+        return
+    }
+
+    msg := fmt.Sprintf("🔒 New draw open\n\n🎟 Ticket: ₹%.0f\n", d.TicketAmount)
+    if !d.Date.IsZero() {
+        msg += fmt.Sprintf("⏰ Draw: %s\n", d.Date.Format("02 Jan 2006, 03:04 PM MST"))
+    }
+    msg += fmt.Sprintf(
+        "🔑 Seed hash (SHA-256): %s\n\n"+
+            "Draw के बाद seed बताया जाएगा, /verify %s से result जांचें।\n"+
+            "Draw ID: %s",
+        d.SeedHash,
+        d.DrawID,
+        d.DrawID,
+    )
+
+    if _, err := b.sendMessage(chatID, msg); err != nil {
+        log.Printf("Failed to announce commitment for draw %s: %v", d.DrawID, err)
+    }
+}
+
+func (b *Bot) drawAnnouncementText(ctx context.Context, result *draw.Result) string {
+    picks := make(map[int]int)
+    for _, entry := range result.Entries {
+        picks[entry.LuckyNumber]++
+    }
+
+    var numbers []string
+    seen := make(map[int]bool)
+    for _, entry := range result.WinningEntries {
+        if seen[entry.LuckyNumber] {
+            continue
+        }
+        seen[entry.LuckyNumber] = true
+        noun := "entries"
+        if picks[entry.LuckyNumber] == 1 {
+            noun = "entry"
+        }
+        numbers = append(numbers, fmt.Sprintf("%d (%d %s)", entry.LuckyNumber, picks[entry.LuckyNumber], noun))
+    }
+
+    msg := fmt.Sprintf(
+        "🎉 Lucky Draw Result\n\n"+
+            "📅 Date: %s\n"+
+            "🎟 Ticket: ₹%.0f\n"+
+            "🎯 Method: %s\n"+
+            "👥 Total entries: %d\n"+
+            "🔢 Winning numbers: %s\n\n"+
+            "🏆 Winners:\n",
+        result.Date.Format("02 Jan 2006"),
+        result.Amount,
+        draw.MethodNames[result.Method],
+        len(result.Entries),
+        strings.Join(numbers, ", "),
+    )
+    for i, entry := range result.WinningEntries {
+        msg += fmt.Sprintf("%d. %s - Number %d - ₹%.2f\n",
+            i+1,
+            b.maskedName(ctx, entry.UserID),
+            entry.LuckyNumber,
+            result.Winners[i].WinningAmount,
+        )
+    }
+    msg += fmt.Sprintf("\n🔑 Seed: %s\n", result.Draw.Seed)
+    if result.Draw.SeedHash != "" {
+        msg += fmt.Sprintf("🔒 Seed hash: %s\n", result.Draw.SeedHash)
+    } else {
+        msg += "⚠️ इस draw का seed hash पहले से publish नहीं हुआ था\n"
+    }
+    msg += fmt.Sprintf("Draw ID: %s\n\nजांचें: /verify %s", result.Draw.DrawID, result.Draw.DrawID)
+
+    return msg
+}
+
+// maskedName shows enough of a winner's name to recognise it without
+// exposing the full handle
+func (b *Bot) maskedName(ctx context.Context, userID int64) string {
+    user, err := b.storage.GetUser(ctx, userID)
+    if err != nil {
+        user = &models.User{}
+    }
+
+    switch {
+    case user.Username != "":
+        return "@" + mask(user.Username)
+    case user.FirstName != "":
+        return mask(user.FirstName)
+    }
+
+    id := strconv.FormatInt(userID, 10)
+    if len(id) > 4 {
+        id = id[len(id)-4:]
+    }
+    return "User ****" + id
+}
+
+func mask(s string) string {
+    r := []rune(s)
+    switch {
+    case len(r) <= 1:
+        return "***"
+    case len(r) <= 4:
+        return string(r[0]) + "***"
+    }
+    return string(r[:2]) + "***" + string(r[len(r)-1])
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "strconv"
+    "strings"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+const auditPageSize = 10
+
+// recordAdminAction appends an audit entry for a privileged operation.
+// Failures are logged but never block the admin's request.
+func (b *Bot) recordAdminAction(ctx context.Context, adminID int64, actionType string, details interface{}) {
+    data, err := json.Marshal(details)
+    if err != nil {
+        log.Printf("Failed to marshal admin action details: %v", err)
+        data = []byte("{}")
+    }
+
+    now := time.Now()
+    action := &models.AdminAction{
+        ActionID:   fmt.Sprintf("ACT%d", now.UnixNano()),
+        AdminID:    adminID,
+        ActionType: actionType,
+        Details:    string(data),
+        Timestamp:  now,
+    }
+
+    if err := b.storage.SaveAdminAction(ctx, action); err != nil {
+        log.Printf("Failed to save admin action %s: %v", actionType, err)
+    }
+}
+
+func (b *Bot) handleAuditCommand(ctx context.Context, message *tgbotapi.Message) {
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    filter, page, err := parseAuditArgs(strings.Fields(message.CommandArguments()))
+    if err != nil {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("⚠️ %v\n\n%s", err, auditHelp))
+        return
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "audit", map[string]interface{}{
+        "args": message.CommandArguments(),
+    })
+
+    filter.Offset = (page - 1) * auditPageSize
+    filter.Limit = auditPageSize + 1
+    actions, err := b.storage.GetAdminActions(ctx, filter)
+    if err != nil {
+        log.Printf("Failed to get admin actions: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ डेटा प्राप्त करने में त्रुटि हुई")
+        return
+    }
+
+    if len(actions) == 0 {
+        b.sendMessage(message.Chat.ID, "No admin actions found")
+        return
+    }
+
+    hasMore := len(actions) > auditPageSize
+    if hasMore {
+        actions = actions[:auditPageSize]
+    }
+
+    msg := fmt.Sprintf("Audit Log (page %d):\n\n", page)
+    for _, action := range actions {
+        msg += fmt.Sprintf(
+            "%s\n"+
+                "Admin: %d\n"+
+                "Action: %s\n"+
+                "Details: %s\n\n",
+            action.Timestamp.Format("2006-01-02 15:04:05"),
+            action.AdminID,
+            action.ActionType,
+            action.Details,
+        )
+    }
+    if hasMore {
+        next := append(stripPageArgs(message.CommandArguments()), "page", strconv.Itoa(page+1))
+        msg += "Next page: /audit " + strings.Join(next, " ")
+    }
+
+    b.sendMessage(message.Chat.ID, msg)
+}
+
+const auditHelp = `Audit Commands:
+/audit - Latest admin actions
+/audit from YYYY-MM-DD - Actions on or after date
+/audit to YYYY-MM-DD - Actions on or before date
+/audit admin <admin_id> - Actions by one admin
+/audit page <n> - Show page n
+Filters can be combined, e.g. /audit admin 12345 from 2024-01-01 page 2`
+
+func parseAuditArgs(args []string) (storage.AdminActionFilter, int, error) {
+    var filter storage.AdminActionFilter
+    page := 1
+
+    if len(args)%2 != 0 {
+        return filter, 0, fmt.Errorf("Invalid arguments")
+    }
+
+    for i := 0; i < len(args); i += 2 {
+        key, value := args[i], args[i+1]
+        switch key {
+        // Dates are the bot's local calendar days; "to" includes the whole day
+        case "from":
+            date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+            if err != nil {
+                return filter, 0, fmt.Errorf("Invalid date format. Use YYYY-MM-DD")
+            }
+            filter.FromDate = date
+        case "to":
+            date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+            if err != nil {
+                return filter, 0, fmt.Errorf("Invalid date format. Use YYYY-MM-DD")
+            }
+            filter.ToDate = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+        case "admin":
+            id, err := strconv.ParseInt(value, 10, 64)
+            if err != nil {
+                return filter, 0, fmt.Errorf("Invalid admin ID")
+            }
+            filter.AdminID = id
+        case "page":
+            n, err := strconv.Atoi(value)
+            if err != nil || n < 1 {
+                return filter, 0, fmt.Errorf("Invalid page number")
+            }
+            page = n
+        default:
+            return filter, 0, fmt.Errorf("Unknown filter: %s", key)
+        }
+    }
+
+    return filter, page, nil
+}
+
+func stripPageArgs(arguments string) []string {
+    args := strings.Fields(arguments)
+    var kept []string
+    for i := 0; i+1 < len(args); i += 2 {
+        if args[i] != "page" {
+            kept = append(kept, args[i], args[i+1])
+        }
+    }
+    return kept
+}
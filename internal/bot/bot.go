@@ -2,48 +2,96 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
     tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
     "github.com/gsshankar104/telegram-bot/internal/config"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
     "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+    "github.com/gsshankar104/telegram-bot/internal/payment/upi"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler"
     "github.com/gsshankar104/telegram-bot/internal/storage"
 )
 
 type Bot struct {
-    api         *tgbotapi.BotAPI
+    api         Client
     storage     storage.Storage
-    config      *config.Config
+    config      atomic.Pointer[config.Config]
     rateLimiter *sync.Map
+    draws       *draw.Engine
+    payments    payment.Provider // nil means manual review
+    scheduler   *scheduler.Scheduler
+
+    paymentMutex sync.Mutex
+    drawMutex    sync.Mutex // serialises opening and running draws and holding entries for refunds; taken after paymentMutex
 }
 
 func New(storage storage.Storage, cfg *config.Config) (*Bot, error) {
-    botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-    if botToken == "" {
-        return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN environment variable not set")
+    if cfg.Bot.Token == "" {
+        return nil, fmt.Errorf("bot token not configured")
     }
 
-    api, err := tgbotapi.NewBotAPI(botToken)
+    api, err := tgbotapi.NewBotAPI(cfg.Bot.Token)
     if err != nil {
         return nil, fmt.Errorf("failed to create bot: %v", err)
     }
 
-    return &Bot{
-        api:         api,
+    return NewWithClient(api, storage, cfg), nil
+}
+
+// NewWithClient creates a bot that talks to Telegram through client, which
+// lets tests drive the bot with a fake instead of the real API.
+func NewWithClient(client Client, storage storage.Storage, cfg *config.Config) *Bot {
+    b := &Bot{
+        api:         client,
         storage:     storage,
-        config:      cfg,
         rateLimiter: &sync.Map{},
-    }, nil
+        draws:       draw.NewEngine(storage),
+    }
+    b.config.Store(cfg)
+    b.SetClock(nil)
+    return b
+}
+
+// SetConfig atomically replaces the config used by new updates; updates
+// already being handled finish with the config they started with.
+func (b *Bot) SetConfig(cfg *config.Config) {
+    b.config.Store(cfg)
+}
+
+func (b *Bot) cfg() *config.Config {
+    return b.config.Load()
 }
 
 func (b *Bot) Start(ctx context.Context) error {
-    log.Printf("Starting %s", b.config.Bot.Name)
+    log.Printf("Starting %s in %s mode", b.cfg().Bot.Name, b.mode())
+
+    if _, ok := b.payments.(payment.CallbackParser); ok {
+        go func() {
+            if err := b.startPaymentCallbacks(ctx); err != nil && err != context.Canceled {
+                log.Printf("Payment callbacks stopped: %v", err)
+            }
+        }()
+    }
+
+    go b.scheduler.Run(ctx)
+
+    if b.mode() == "webhook" {
+        return b.startWebhook(ctx)
+    }
+
+    // getUpdates is rejected while a webhook is registered
+    if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+        log.Printf("Failed to delete webhook: %v", err)
+    }
 
     updateConfig := tgbotapi.UpdateConfig{
         Timeout: 60,
@@ -60,6 +108,13 @@ func (b *Bot) Start(ctx context.Context) error {
     }
 }
 
+func (b *Bot) mode() string {
+    if b.cfg().Bot.Mode == "" {
+        return "polling"
+    }
+    return b.cfg().Bot.Mode
+}
+
 func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
     defer func() {
         if r := recover(); r != nil {
@@ -76,7 +131,7 @@ func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
         switch {
         case update.Message.IsCommand():
             b.handleCommand(ctx, update.Message)
-        case update.Message.Text != "":
+        case update.Message.Text != "" || hasScreenshot(update.Message):
             state, err := b.storage.GetUserState(ctx, update.Message.From.ID)
             if err != nil {
                 log.Printf("Failed to get user state: %v", err)
@@ -100,12 +155,36 @@ func (b *Bot) handleCommand(ctx context.Context, message *tgbotapi.Message) {
         b.handleSelectWinnerCommand(ctx, message)
     case "export_all_users":
         b.handleExportUsersCommand(ctx, message)
+    case "audit":
+        b.handleAuditCommand(ctx, message)
+    case "pending":
+        b.handlePendingCommand(ctx, message)
+    case "reconcile":
+        b.handleReconcileCommand(ctx, message)
+    case "refund":
+        b.handleRefundCommand(ctx, message)
+    case "payouts":
+        b.handlePayoutsCommand(ctx, message)
+    case "verify":
+        b.handleVerifyCommand(ctx, message)
+    case "stats":
+        b.handleStatsCommand(ctx, message)
     default:
         b.sendMessage(message.Chat.ID, "⚠️ अमान्य कमांड")
     }
 }
 
 func (b *Bot) handleMessageWithState(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
+    if state.CurrentState == "awaiting_statement" {
+        b.handleStatementUpload(ctx, message, state)
+        return
+    }
+
+    if hasScreenshot(message) {
+        b.handleScreenshotSubmission(ctx, message, state)
+        return
+    }
+
     switch state.CurrentState {
     case "awaiting_transaction_id":
         b.handleTransactionIDSubmission(ctx, message, state)
@@ -113,13 +192,17 @@ func (b *Bot) handleMessageWithState(ctx context.Context, message *tgbotapi.Mess
         b.handleLuckyNumberSubmission(ctx, message, state)
     case "selecting_winner_count":
         b.handleWinnerCountSubmission(ctx, message, state)
+    case "awaiting_manual_winners":
+        b.handleManualWinnerSubmission(ctx, message, state)
+    case "awaiting_payout_ref":
+        b.handlePayoutReference(ctx, message, state)
     default:
         b.handleUnexpectedInput(ctx, message, state)
     }
 }
 
 func (b *Bot) handleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
-    parts := strings.Split(callback.Data, ":")
+    parts := strings.SplitN(callback.Data, ":", 2)
     if len(parts) < 2 {
         return
     }
@@ -134,10 +217,18 @@ func (b *Bot) handleCallback(ctx context.Context, callback *tgbotapi.CallbackQue
         b.handleNavigation(ctx, callback, data)
     case "winner_amount":
         b.handleWinnerAmountSelection(ctx, callback, data)
+    case "winner_method":
+        b.handleWinnerMethodSelection(ctx, callback, data)
+    case "payment":
+        b.handlePaymentReview(ctx, callback, data)
+    case "pay_check":
+        b.handlePaymentCheck(ctx, callback, data)
+    case "payout":
+        b.handlePayoutSelection(ctx, callback, data)
     }
 
     callbackConfig := tgbotapi.NewCallback(callback.ID, "")
-    b.api.Send(callbackConfig)
+    b.api.Request(callbackConfig)
 }
 
 func (b *Bot) handleStartCommand(ctx context.Context, message *tgbotapi.Message) {
@@ -159,8 +250,8 @@ func (b *Bot) handleStartCommand(ctx context.Context, message *tgbotapi.Message)
             "यह एक Lottery Bot है, टिकट खरीदने और भाग्य आजमाने के लिए नीचे दिए गए बटन पर क्लिक करें।\n\n"+
             "लॉटरी प्रूफ चैनल: %s",
         message.From.FirstName,
-        b.config.Bot.Name,
-        b.config.Channels.LotteryProof,
+        b.cfg().Bot.Name,
+        b.cfg().Channels.LotteryProof,
     )
 
     buttons := [][]string{
@@ -175,24 +266,45 @@ func (b *Bot) handleStartCommand(ctx context.Context, message *tgbotapi.Message)
 }
 
 func (b *Bot) handleTransactionIDSubmission(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
-    txnID := message.Text
-
-    used, err := b.storage.IsTransactionUsed(ctx, txnID)
-    if err != nil {
-        log.Printf("Failed to check transaction: %v", err)
-        b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+    txnID := strings.TrimSpace(message.Text)
+    if !validTransactionID.MatchString(txnID) {
+        b.sendMessage(message.Chat.ID, "⚠️ अमान्य Transaction ID। कृपया सिर्फ़ अक्षर और अंक वाली सही Transaction ID डालें।")
         return
     }
 
-    if used {
-        b.sendMessage(message.Chat.ID, "⚠️ माफ़ करना! यह Transaction ID पहले ही इस्तेमाल हो चुकी है। कृपया दूसरी Transaction ID डालें।")
+    // States saved before Unique Codes were issued up front have none yet
+    if state.UniqueCode == "" {
+        state.UniqueCode = newUniqueCode()
+    }
+
+    now := time.Now()
+    txn := &models.Transaction{
+        TransactionID: txnID,
+        UserID:        message.From.ID,
+        Amount:        state.SelectedAmount,
+        Date:          now,
+        Time:          now,
+        Status:        "pending",
+        UniqueCode:    state.UniqueCode,
+
+        ScreenshotFileID: state.ScreenshotFileID,
+        ScreenshotType:   state.ScreenshotType,
+    }
+
+    // SaveTransaction rejects duplicates atomically, so two users racing
+    // with the same ID cannot both get through
+    if err := b.storage.SaveTransaction(ctx, txn); err != nil {
+        if errors.Is(err, storage.ErrDuplicateTransaction) {
+            b.sendMessage(message.Chat.ID, "⚠️ माफ़ करना! यह Transaction ID पहले ही इस्तेमाल हो चुकी है। कृपया दूसरी Transaction ID डालें।")
+            return
+        }
+        log.Printf("Failed to save transaction: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
         return
     }
 
-    uniqueCode := fmt.Sprintf("LC%d", time.Now().UnixNano())
     state.CurrentState = "awaiting_lucky_number"
     state.TransactionID = txnID
-    state.UniqueCode = uniqueCode
 
     if err := b.storage.SaveUserState(ctx, state); err != nil {
         log.Printf("Failed to update user state: %v", err)
@@ -200,12 +312,14 @@ func (b *Bot) handleTransactionIDSubmission(ctx context.Context, message *tgbota
         return
     }
 
+    b.sendPaymentReview(ctx, txn, state.UniqueCode)
+
     msg := fmt.Sprintf(
-        "✅ पेमेंट कन्फर्म! आपका Transaction ID है: %s \n\n"+
+        "⏳ आपका Transaction ID %s वेरिफिकेशन के लिए भेज दिया गया है। Admin के कन्फर्म करते ही आपको सूचना मिल जाएगी। \n\n"+
             "आपका Unique Code है: %s \n\n"+
-            "अब 1 से 100 के बीच कोई भी एक Lucky Number चुनें:",
+            "तब तक 1 से 100 के बीच कोई भी एक Lucky Number चुनें:",
         txnID,
-        uniqueCode,
+        state.UniqueCode,
     )
 
     buttons := [][]string{
@@ -225,6 +339,36 @@ func (b *Bot) handleLuckyNumberSubmission(ctx context.Context, message *tgbotapi
         return
     }
 
+    // The entry only counts once an admin has verified the payment. Hold
+    // paymentMutex so an approval cannot slip in between the lookup and
+    // the save and leave the entry pending forever.
+    b.paymentMutex.Lock()
+    entryStatus := "pending"
+    if txn, err := b.storage.GetTransaction(ctx, state.TransactionID); err == nil {
+        switch txn.Status {
+        case "verified":
+            entryStatus = "active"
+        case "rejected":
+            b.paymentMutex.Unlock()
+            b.storage.DeleteUserState(ctx, message.From.ID)
+            b.sendMessage(message.Chat.ID, "❌ माफ़ करना! आपका Transaction ID वेरिफाई नहीं हो सका, इसलिए यह Entry रजिस्टर नहीं की जा सकती।")
+            return
+        case "refunded":
+            b.paymentMutex.Unlock()
+            b.storage.DeleteUserState(ctx, message.From.ID)
+            b.sendMessage(message.Chat.ID, "↩️ इस Transaction ID का refund हो चुका है, इसलिए यह Entry रजिस्टर नहीं की जा सकती।")
+            return
+        }
+    }
+
+    drawID, err := b.entryDraw(ctx, state.SelectedAmount)
+    if err != nil {
+        b.paymentMutex.Unlock()
+        log.Printf("Failed to open draw: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
     entry := &models.LotteryEntry{
         EntryID:      fmt.Sprintf("ENTRY%d", time.Now().UnixNano()),
         UserID:       message.From.ID,
@@ -234,10 +378,13 @@ func (b *Bot) handleLuckyNumberSubmission(ctx context.Context, message *tgbotapi
         LuckyNumber:  number,
         EntryDate:    time.Now(),
         EntryTime:    time.Now(),
-        Status:       "active",
+        Status:       entryStatus,
+        DrawID:       drawID,
     }
 
-    if err := b.storage.SaveLotteryEntry(ctx, entry); err != nil {
+    err = b.storage.SaveLotteryEntry(ctx, entry)
+    b.paymentMutex.Unlock()
+    if err != nil {
         log.Printf("Failed to save lottery entry: %v", err)
         b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
         return
@@ -245,12 +392,19 @@ func (b *Bot) handleLuckyNumberSubmission(ctx context.Context, message *tgbotapi
 
     b.storage.DeleteUserState(ctx, message.From.ID)
 
+    registered := "आपकी Lottery Entry सफलतापूर्वक रजिस्टर हो गई है।"
+    if entryStatus == "pending" {
+        registered = "आपकी Lottery Entry पेमेंट वेरिफाई होते ही active हो जाएगी।"
+    }
+
     msg := fmt.Sprintf(
-        "👍 आपका नंबर %d चुना गया है! आपकी Lottery Entry सफलतापूर्वक रजिस्टर हो गई है। \n\n"+
-            "Result [Lottery Result Announcement Time] पर Lottery Proof चैनल %s में announce किए जाएंगे। \n\n"+
+        "👍 आपका नंबर %d चुना गया है! %s \n\n"+
+            "Result %s Lottery Proof चैनल %s में announce किए जाएंगे। \n\n"+
             "शुभकामनाएं!",
         number,
-        b.config.Channels.LotteryProof,
+        registered,
+        b.entryDrawText(ctx, drawID, state.SelectedAmount),
+        b.cfg().Channels.LotteryProof,
     )
 
     buttons := [][]string{
@@ -279,6 +433,7 @@ func (b *Bot) handleWinnerCountSubmission(ctx context.Context, message *tgbotapi
     }
 
     state.CurrentState = "selecting_winner_method"
+    state.WinnerCount = count
     state.LastUpdated = time.Now()
     b.storage.SaveUserState(ctx, state)
 
@@ -295,7 +450,7 @@ func (b *Bot) handleUnexpectedInput(ctx context.Context, message *tgbotapi.Messa
         log.Printf("Failed to update invalid attempts: %v", err)
     }
 
-    if state.InvalidAttempts >= b.config.Limits.MaxInvalidAttempts {
+    if state.InvalidAttempts >= b.cfg().Limits.MaxInvalidAttempts {
         b.storage.DeleteUserState(ctx, message.From.ID)
         b.handleStartCommand(ctx, message)
         return
@@ -305,7 +460,7 @@ func (b *Bot) handleUnexpectedInput(ctx context.Context, message *tgbotapi.Messa
         "⚠️ माफ़ करना, मुझे आपका इनपुट समझ में नहीं आया!\n\n"+
             "कृपया दिए गए विकल्पों में से चुनें या सही format में input भेजें।\n\n"+
             "किसी भी समस्या के लिए, %s पर Lottery Win चैनल से संपर्क करें।",
-        b.config.Channels.LotteryWin,
+        b.cfg().Channels.LotteryWin,
     )
 
     b.sendMessage(message.Chat.ID, msg)
@@ -318,6 +473,10 @@ func (b *Bot) handleViewDataCommand(ctx context.Context, message *tgbotapi.Messa
     }
 
     args := strings.Fields(message.CommandArguments())
+    b.recordAdminAction(ctx, message.From.ID, "view_data", map[string]interface{}{
+        "args": args,
+    })
+
     if len(args) < 2 {
         b.sendViewDataHelp(message.Chat.ID)
         return
@@ -345,8 +504,10 @@ func (b *Bot) handleSelectWinnerCommand(ctx context.Context, message *tgbotapi.M
         return
     }
 
+    b.recordAdminAction(ctx, message.From.ID, "select_winner", map[string]interface{}{})
+
     var buttons [][]string
-    for _, price := range b.config.Tickets.Prices {
+    for _, price := range b.cfg().Tickets.Prices {
         priceStr := fmt.Sprintf("₹%.0f", price)
         buttons = append(buttons, []string{
             fmt.Sprintf("%s|winner_amount:%.0f", priceStr, price),
@@ -373,6 +534,10 @@ func (b *Bot) handleExportUsersCommand(ctx context.Context, message *tgbotapi.Me
         return
     }
 
+    b.recordAdminAction(ctx, message.From.ID, "export_all_users", map[string]interface{}{
+        "users": len(users),
+    })
+
     var data string
     data = "User ID,Username,First Name,Last Name,Joined Date,Status\n"
     for _, user := range users {
@@ -400,7 +565,7 @@ func (b *Bot) handleExportUsersCommand(ctx context.Context, message *tgbotapi.Me
 func (b *Bot) handleAmountSelection(ctx context.Context, callback *tgbotapi.CallbackQuery, data string) {
     if data == "start" {
         var buttons [][]string
-        for _, price := range b.config.Tickets.Prices {
+        for _, price := range b.cfg().Tickets.Prices {
             priceStr := fmt.Sprintf("₹%.0f", price)
             buttons = append(buttons, []string{
                 fmt.Sprintf("%s|select_amount:%.0f", priceStr, price),
@@ -422,13 +587,37 @@ func (b *Bot) handleAmountSelection(ctx context.Context, callback *tgbotapi.Call
         return
     }
 
+    if !b.scheduler.SalesOpen(amount) {
+        b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf(
+            "⏰ ₹%.0f टिकट की बिक्री अभी बंद है, draw %s होगा। Draw के बाद फिर से कोशिश करें।",
+            amount,
+            b.nextDrawText(amount),
+        ))
+        return
+    }
+
+    if b.payments != nil {
+        b.startGatewayPayment(ctx, callback, amount)
+        return
+    }
+
+    // The Unique Code is the payment note, so it has to exist before the
+    // user pays
     state := &models.UserState{
         UserID:         callback.From.ID,
         CurrentState:   "awaiting_transaction_id",
         SelectedAmount: amount,
+        UniqueCode:     newUniqueCode(),
         LastUpdated:    time.Now(),
     }
 
+    qr, err := b.paymentQR(amount, state.UniqueCode)
+    if err != nil {
+        log.Printf("Failed to create payment QR: %v", err)
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
     if err := b.storage.SaveUserState(ctx, state); err != nil {
         log.Printf("Failed to save user state: %v", err)
         return
@@ -436,8 +625,10 @@ func (b *Bot) handleAmountSelection(ctx context.Context, callback *tgbotapi.Call
 
     msg := fmt.Sprintf(
         "लॉटरी टिकट ₹%.0f के लिए पेमेंट करने के लिए नीचे दिए गए QR कोड का उपयोग करें: \n\n"+
+            "आपका Unique Code है: %s (पेमेंट नोट में यही रहने दें) \n\n"+
             "पेमेंट करने के बाद, Transaction ID और पेमेंट का स्क्रीनशॉट भेजें।",
         amount,
+        state.UniqueCode,
     )
 
     buttons := [][]string{
@@ -445,12 +636,36 @@ func (b *Bot) handleAmountSelection(ctx context.Context, callback *tgbotapi.Call
     }
 
     keyboard := b.createInlineKeyboard(buttons)
-    photo := tgbotapi.NewPhoto(callback.Message.Chat.ID, tgbotapi.FileURL(b.config.Payment.QRCodeLink))
+    photo := tgbotapi.NewPhoto(callback.Message.Chat.ID, qr)
     photo.Caption = msg
     photo.ReplyMarkup = keyboard
     b.api.Send(photo)
 }
 
+// paymentQR returns a QR code for this ticket's amount and Unique Code, or
+// the static QR image when no payee VPA is configured
+func (b *Bot) paymentQR(amount float64, uniqueCode string) (tgbotapi.RequestFileData, error) {
+    cfg := b.cfg().Payment
+    if cfg.UPI.VPA == "" {
+        return tgbotapi.FileURL(cfg.QRCodeLink), nil
+    }
+
+    png, err := upi.Payment{
+        VPA:       cfg.UPI.VPA,
+        PayeeName: cfg.UPI.PayeeName,
+        Amount:    amount,
+        Note:      uniqueCode,
+    }.QRCode()
+    if err != nil {
+        return nil, err
+    }
+    return tgbotapi.FileBytes{Name: uniqueCode + ".png", Bytes: png}, nil
+}
+
+func newUniqueCode() string {
+    return fmt.Sprintf("LC%d", time.Now().UnixNano())
+}
+
 func (b *Bot) handleNavigation(ctx context.Context, callback *tgbotapi.CallbackQuery, action string) {
     switch action {
     case "home":
@@ -488,11 +703,173 @@ func (b *Bot) handleWinnerAmountSelection(ctx context.Context, callback *tgbotap
         return
     }
 
+    b.recordAdminAction(ctx, callback.From.ID, "winner_amount", map[string]interface{}{
+        "amount": amount,
+    })
+
     msg := "कितने लोग जीतेंगे? (1-10 के बीच एक नंबर भेजें)"
     edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, msg)
     b.api.Send(edit)
 }
 
+func (b *Bot) handleWinnerMethodSelection(ctx context.Context, callback *tgbotapi.CallbackQuery, method string) {
+    if !b.isAdmin(callback.From.ID) {
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    if _, ok := draw.MethodNames[method]; !ok {
+        log.Printf("Invalid winner method: %s", method)
+        return
+    }
+
+    state, err := b.storage.GetUserState(ctx, callback.From.ID)
+    if err != nil {
+        log.Printf("Failed to get admin state: %v", err)
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    if state.CurrentState != "selecting_winner_method" {
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ कृपया /select_winner कमांड से फिर से शुरू करें।")
+        return
+    }
+
+    b.recordAdminAction(ctx, callback.From.ID, "winner_method", map[string]interface{}{
+        "amount":       state.SelectedAmount,
+        "method":       method,
+        "winner_count": state.WinnerCount,
+    })
+
+    if method == draw.MethodManual {
+        b.promptManualWinners(ctx, callback.Message.Chat.ID, state)
+        return
+    }
+
+    b.runDraw(ctx, callback.Message.Chat.ID, callback.From.ID, draw.Request{
+        Date:        time.Now(),
+        Amount:      state.SelectedAmount,
+        Method:      method,
+        WinnerCount: state.WinnerCount,
+        DrawID:      b.currentDraw(ctx, state.SelectedAmount),
+    })
+}
+
+func (b *Bot) promptManualWinners(ctx context.Context, chatID int64, state *models.UserState) {
+    entries, err := b.draws.EligibleEntries(ctx, draw.Request{
+        Date:   time.Now(),
+        Amount: state.SelectedAmount,
+        DrawID: b.currentDraw(ctx, state.SelectedAmount),
+    })
+    if err != nil {
+        log.Printf("Failed to get entries: %v", err)
+        b.sendMessage(chatID, "⚠️ Failed to get entries")
+        return
+    }
+
+    if len(entries) == 0 {
+        b.storage.DeleteUserState(ctx, state.UserID)
+        b.sendMessage(chatID, fmt.Sprintf("₹%.0f के लिए कोई active entry नहीं है।", state.SelectedAmount))
+        return
+    }
+
+    state.CurrentState = "awaiting_manual_winners"
+    state.LastUpdated = time.Now()
+    if err := b.storage.SaveUserState(ctx, state); err != nil {
+        log.Printf("Failed to save admin state: %v", err)
+        b.sendMessage(chatID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    msg := fmt.Sprintf("₹%.0f की active entries:\n\n", state.SelectedAmount)
+    for _, entry := range entries {
+        msg += fmt.Sprintf("%s - User %d - Number %d\n", entry.UniqueCode, entry.UserID, entry.LuckyNumber)
+    }
+    msg += fmt.Sprintf("\nWinners के Unique Code भेजें (ज़्यादा से ज़्यादा %d, space या comma से अलग करें):", state.WinnerCount)
+
+    b.sendMessage(chatID, msg)
+}
+
+func (b *Bot) handleManualWinnerSubmission(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
+    if !b.isAdmin(message.From.ID) {
+        b.storage.DeleteUserState(ctx, message.From.ID)
+        return
+    }
+
+    codes := strings.FieldsFunc(message.Text, func(r rune) bool {
+        return r == ',' || r == ' ' || r == '\n'
+    })
+
+    b.runDraw(ctx, message.Chat.ID, message.From.ID, draw.Request{
+        Date:        time.Now(),
+        Amount:      state.SelectedAmount,
+        Method:      draw.MethodManual,
+        WinnerCount: state.WinnerCount,
+        Codes:       codes,
+        DrawID:      b.currentDraw(ctx, state.SelectedAmount),
+    })
+}
+
+func (b *Bot) runDraw(ctx context.Context, chatID int64, adminID int64, req draw.Request) {
+    result, err := b.runEngine(ctx, req)
+    if err == draw.ErrNoEntries {
+        b.storage.DeleteUserState(ctx, adminID)
+        b.sendMessage(chatID, fmt.Sprintf("₹%.0f के लिए कोई active entry नहीं है।", req.Amount))
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to run draw: %v", err)
+        b.sendMessage(chatID, fmt.Sprintf("⚠️ Draw failed: %v", err))
+        return
+    }
+
+    b.storage.DeleteUserState(ctx, adminID)
+
+    var winnerEntries []string
+    for _, entry := range result.WinningEntries {
+        winnerEntries = append(winnerEntries, entry.EntryID)
+    }
+    b.recordAdminAction(ctx, adminID, "draw", map[string]interface{}{
+        "amount":  req.Amount,
+        "method":  req.Method,
+        "entries": len(result.Entries),
+        "winners": winnerEntries,
+    })
+
+    msg := drawSummary(result)
+    if b.announceDraw(ctx, result) {
+        msg += "\n📢 Result posted to the proof channel"
+    }
+
+    b.sendMessage(chatID, msg)
+    b.notifyWinners(result)
+    b.sendPayoutChecklist(chatID, result.Winners)
+}
+
+func drawSummary(result *draw.Result) string {
+    msg := fmt.Sprintf(
+        "🎉 Draw complete!\n\n"+
+            "Amount: ₹%.0f\n"+
+            "Method: %s\n"+
+            "Total entries: %d\n\n"+
+            "Winners:\n",
+        result.Amount,
+        draw.MethodNames[result.Method],
+        len(result.Entries),
+    )
+    for i, entry := range result.WinningEntries {
+        msg += fmt.Sprintf(
+            "%d. User %d - Code %s - Number %d - ₹%.2f\n",
+            i+1,
+            entry.UserID,
+            entry.UniqueCode,
+            entry.LuckyNumber,
+            result.Winners[i].WinningAmount,
+        )
+    }
+    return msg
+}
+
 func (b *Bot) handleViewUserData(ctx context.Context, chatID int64, username string) {
     // Implementation for viewing user data
     // You would need to add a method to storage interface to search by username
@@ -616,7 +993,7 @@ func (b *Bot) checkRateLimit(userID int64) bool {
 
     if val, ok := b.rateLimiter.Load(key); ok {
         lastTime := val.(time.Time)
-        if now.Sub(lastTime).Seconds() < float64(60/b.config.Limits.CommandRateLimit) {
+        if now.Sub(lastTime).Seconds() < float64(60/b.cfg().Limits.CommandRateLimit) {
             return false
         }
     }
@@ -657,9 +1034,22 @@ func (b *Bot) createInlineKeyboard(buttons [][]string) tgbotapi.InlineKeyboardMa
     return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
 }
 
+// NotifyAdmins sends text to every configured admin
+func (b *Bot) NotifyAdmins(text string) {
+    for _, adminID := range b.cfg().Admin.IDs {
+        chatID, err := strconv.ParseInt(adminID, 10, 64)
+        if err != nil {
+            continue
+        }
+        if _, err := b.sendMessage(chatID, text); err != nil {
+            log.Printf("Failed to notify admin %s: %v", adminID, err)
+        }
+    }
+}
+
 func (b *Bot) isAdmin(userID int64) bool {
     userIDStr := strconv.FormatInt(userID, 10)
-    for _, adminID := range b.config.Admin.IDs {
+    for _, adminID := range b.cfg().Admin.IDs {
         if adminID == userIDStr {
             return true
         }
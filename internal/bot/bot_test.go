@@ -0,0 +1,1343 @@
+package bot
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/bot/bottest"
+    "github.com/gsshankar104/telegram-bot/internal/config"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+    "github.com/gsshankar104/telegram-bot/internal/payment/upigateway"
+    "github.com/gsshankar104/telegram-bot/internal/payment/upigateway/upigatewaytest"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler/schedulertest"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+    "github.com/gsshankar104/telegram-bot/internal/storage/memory"
+)
+
+var (
+    buyer = bottest.User{ID: 1001, UserName: "buyer", FirstName: "Buyer"}
+    admin = bottest.User{ID: 9001, UserName: "admin", FirstName: "Admin"}
+)
+
+func newTestBot(t *testing.T) (*Bot, *bottest.Client, *memory.MemoryStorage) {
+    t.Helper()
+
+    cfg := &config.Config{
+        Bot:      config.BotConfig{Name: "Test Lottery"},
+        Admin:    config.AdminConfig{IDs: []string{"9001"}},
+        Channels: config.ChannelsConfig{LotteryProof: "https://t.me/proof", LotteryWin: "https://t.me/win"},
+        Payment:  config.PaymentConfig{QRCodeLink: "https://example.com/qr.png"},
+        Tickets:  config.TicketsConfig{Prices: []float64{100, 200}},
+        // 60/6000 rounds down to zero seconds, so scripted updates are never throttled
+        Limits: config.LimitsConfig{MaxInvalidAttempts: 3, CommandRateLimit: 6000},
+    }
+
+    client := bottest.NewClient()
+    store := memory.NewMemoryStorage()
+    return NewWithClient(client, store, cfg), client, store
+}
+
+func TestPurchaseFlow(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    b.handleUpdate(ctx, buyer.Command("/start"))
+    if _, err := store.GetUser(ctx, buyer.ID); err != nil {
+        t.Fatalf("user not saved on /start: %v", err)
+    }
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Test Lottery") {
+        t.Errorf("welcome message = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:start"))
+    if edits := client.Edits(); len(edits) != 1 || !strings.Contains(edits[0].Text, "टिकट की कीमत") {
+        t.Fatalf("price menu edits = %+v", edits)
+    }
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    photos := client.Photos()
+    if len(photos) != 1 || !strings.Contains(photos[0].Caption, "₹100") {
+        t.Fatalf("payment QR photos = %+v", photos)
+    }
+    state, _ := store.GetUserState(ctx, buyer.ID)
+    if state.CurrentState != "awaiting_transaction_id" || state.SelectedAmount != 100 {
+        t.Fatalf("state after amount selection = %+v", state)
+    }
+
+    b.handleUpdate(ctx, buyer.Text("TXN123"))
+    state, _ = store.GetUserState(ctx, buyer.ID)
+    if state.CurrentState != "awaiting_lucky_number" || state.TransactionID != "TXN123" {
+        t.Fatalf("state after transaction ID = %+v", state)
+    }
+    txn, err := store.GetTransaction(ctx, "TXN123")
+    if err != nil {
+        t.Fatalf("transaction not saved: %v", err)
+    }
+    if txn.UserID != buyer.ID || txn.Amount != 100 || txn.Status != "pending" {
+        t.Errorf("transaction = %+v", txn)
+    }
+    if !sentTo(client, admin.ID, "payment:approve:TXN123") {
+        t.Errorf("payment review card not sent to admin")
+    }
+
+    // A second buyer cannot reuse the same transaction ID
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:100"))
+    b.handleUpdate(ctx, other.Text("TXN123"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "पहले ही इस्तेमाल") {
+        t.Errorf("duplicate transaction reply = %q", msg.Text)
+    }
+    if otherState, _ := store.GetUserState(ctx, other.ID); otherState.CurrentState != "awaiting_transaction_id" {
+        t.Errorf("duplicate transaction advanced state to %q", otherState.CurrentState)
+    }
+
+    b.handleUpdate(ctx, buyer.Text("420"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "1 से 100") {
+        t.Errorf("out of range number reply = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, buyer.Text("42"))
+    entries, _ := store.GetEntriesByDate(ctx, time.Now())
+    if len(entries) != 1 {
+        t.Fatalf("saved %d entries, want 1", len(entries))
+    }
+    if entries[0].LuckyNumber != 42 || entries[0].TicketAmount != 100 || entries[0].TransactionID != "TXN123" {
+        t.Errorf("entry = %+v", entries[0])
+    }
+    if entries[0].Status != "pending" {
+        t.Errorf("entry status before verification = %q, want pending", entries[0].Status)
+    }
+
+    state, _ = store.GetUserState(ctx, buyer.ID)
+    if state.CurrentState != "" {
+        t.Errorf("state not cleared after entry: %+v", state)
+    }
+
+    if answers := client.CallbackAnswers(); len(answers) != 3 {
+        t.Errorf("answered %d callbacks, want 3", len(answers))
+    }
+}
+
+func TestSelectWinnerFlow(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    for i, number := range []string{"7", "7", "9"} {
+        user := bottest.User{ID: int64(2000 + i), FirstName: "Player"}
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        b.handleUpdate(ctx, user.Text(fmt.Sprintf("TXN%d", i)))
+        b.handleUpdate(ctx, user.Text(number))
+        b.handleUpdate(ctx, admin.Callback(fmt.Sprintf("payment:approve:TXN%d", i)))
+    }
+
+    b.handleUpdate(ctx, buyer.Command("/select_winner"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Admin नहीं") {
+        t.Errorf("non-admin /select_winner reply = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("2"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:most_guessed"))
+
+    if !sentTo(client, admin.ID, "Draw complete") {
+        t.Fatalf("admin did not get the draw result")
+    }
+
+    winners, _ := store.GetWinnersByDate(ctx, time.Now())
+    if len(winners) != 2 {
+        t.Fatalf("recorded %d winners, want 2", len(winners))
+    }
+    for _, winner := range winners {
+        if winner.UserID != 2000 && winner.UserID != 2001 {
+            t.Errorf("unexpected winner %d, want the two players who picked 7", winner.UserID)
+        }
+    }
+
+    statuses := map[string]int{}
+    entries, _ := store.GetEntriesByDate(ctx, time.Now())
+    for _, entry := range entries {
+        statuses[entry.Status]++
+    }
+    if statuses["winner"] != 2 || statuses["expired"] != 1 {
+        t.Errorf("entry statuses = %v", statuses)
+    }
+}
+
+func TestPaymentReview(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    buy := func(user bottest.User, txnID string) {
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        b.handleUpdate(ctx, user.Text(txnID))
+        b.handleUpdate(ctx, user.Text("42"))
+    }
+    entryStatus := func(txnID string) string {
+        entries, _ := store.GetEntriesByTransaction(ctx, txnID)
+        if len(entries) != 1 {
+            t.Fatalf("%s has %d entries, want 1", txnID, len(entries))
+        }
+        return entries[0].Status
+    }
+
+    good := bottest.User{ID: 3001, FirstName: "Good"}
+    bad := bottest.User{ID: 3002, FirstName: "Bad"}
+    buy(good, "UPI-001")
+    buy(bad, "UPI-002")
+
+    b.handleUpdate(ctx, admin.Command("/pending"))
+    if !sentTo(client, admin.ID, "2 pending payments") {
+        t.Errorf("/pending did not list the backlog")
+    }
+
+    b.handleUpdate(ctx, good.Callback("payment:approve:UPI-001"))
+    if txn, _ := store.GetTransaction(ctx, "UPI-001"); txn.Status != "pending" {
+        t.Errorf("non-admin approval changed status to %q", txn.Status)
+    }
+
+    b.handleUpdate(ctx, admin.Callback("payment:approve:UPI-001"))
+    if txn, _ := store.GetTransaction(ctx, "UPI-001"); txn.Status != "verified" {
+        t.Errorf("approved transaction status = %q", txn.Status)
+    }
+    if status := entryStatus("UPI-001"); status != "active" {
+        t.Errorf("approved entry status = %q", status)
+    }
+    if !sentTo(client, good.ID, "वेरिफाई हो गया") {
+        t.Errorf("buyer not told about approval")
+    }
+
+    b.handleUpdate(ctx, admin.Callback("payment:reject:UPI-002"))
+    if txn, _ := store.GetTransaction(ctx, "UPI-002"); txn.Status != "rejected" {
+        t.Errorf("rejected transaction status = %q", txn.Status)
+    }
+    if status := entryStatus("UPI-002"); status != "rejected" {
+        t.Errorf("rejected entry status = %q", status)
+    }
+    if !sentTo(client, bad.ID, "वेरिफाई नहीं हो सका") {
+        t.Errorf("buyer not told about rejection")
+    }
+
+    // A second tap on a settled card must not flip it
+    b.handleUpdate(ctx, admin.Callback("payment:reject:UPI-001"))
+    if txn, _ := store.GetTransaction(ctx, "UPI-001"); txn.Status != "verified" {
+        t.Errorf("settled transaction changed to %q", txn.Status)
+    }
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "already verified") {
+        t.Errorf("second decision reply = %q", msg.Text)
+    }
+
+    actions, _ := store.GetAdminActions(ctx, storage.AdminActionFilter{})
+    types := map[string]int{}
+    for _, action := range actions {
+        types[action.ActionType]++
+    }
+    if types["payment_approve"] != 1 || types["payment_reject"] != 1 || types["pending"] != 1 {
+        t.Errorf("audit action types = %v", types)
+    }
+
+    // IDs that could break callback data are refused up front
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Text("bad|id:with separators"))
+    if used, _ := store.IsTransactionUsed(ctx, "bad|id:with separators"); used {
+        t.Errorf("invalid transaction ID was saved")
+    }
+}
+
+// sentTo reports whether any message to chatID contains substr in its text
+// or inline keyboard
+func sentTo(client *bottest.Client, chatID int64, substr string) bool {
+    for _, msg := range client.Messages() {
+        if msg.ChatID != chatID {
+            continue
+        }
+        if strings.Contains(msg.Text, substr) {
+            return true
+        }
+        if keyboard, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+            for _, row := range keyboard.InlineKeyboard {
+                for _, button := range row {
+                    if button.CallbackData != nil && strings.Contains(*button.CallbackData, substr) {
+                        return true
+                    }
+                }
+            }
+        }
+    }
+    return false
+}
+
+func TestPaymentScreenshot(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    // Screenshot first, then the Transaction ID as text
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Photo("PHOTO1", ""))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "स्क्रीनशॉट मिल गया") {
+        t.Errorf("screenshot reply = %q", msg.Text)
+    }
+    b.handleUpdate(ctx, buyer.Text("SHOT-1"))
+    txn, err := store.GetTransaction(ctx, "SHOT-1")
+    if err != nil {
+        t.Fatalf("transaction not saved: %v", err)
+    }
+    if txn.ScreenshotFileID != "PHOTO1" || txn.ScreenshotType != "photo" {
+        t.Errorf("transaction screenshot = %q/%q, want the largest photo size", txn.ScreenshotFileID, txn.ScreenshotType)
+    }
+
+    var reviewed bool
+    for _, photo := range client.Photos() {
+        if photo.ChatID == admin.ID && strings.Contains(photo.Caption, "SHOT-1") {
+            reviewed = photo.File == tgbotapi.FileID("PHOTO1")
+        }
+    }
+    if !reviewed {
+        t.Errorf("admin review card does not carry the screenshot")
+    }
+    b.handleUpdate(ctx, buyer.Text("42"))
+
+    // A document with the ID in its caption does both in one step
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:200"))
+    b.handleUpdate(ctx, other.Document("DOC1", "application/zip", "SHOT-2"))
+    if used, _ := store.IsTransactionUsed(ctx, "SHOT-2"); used {
+        t.Errorf("transaction saved from a non-image attachment")
+    }
+    b.handleUpdate(ctx, other.Document("DOC1", "image/png", " SHOT-2 "))
+    txn, err = store.GetTransaction(ctx, "SHOT-2")
+    if err != nil {
+        t.Fatalf("captioned transaction not saved: %v", err)
+    }
+    if txn.ScreenshotFileID != "DOC1" || txn.ScreenshotType != "document" {
+        t.Errorf("transaction screenshot = %q/%q", txn.ScreenshotFileID, txn.ScreenshotType)
+    }
+    var docs int
+    for _, doc := range client.Documents() {
+        if doc.ChatID == admin.ID {
+            docs++
+        }
+    }
+    if docs != 1 {
+        t.Errorf("sent %d document review cards to admin, want 1", docs)
+    }
+
+    // A screenshot sent after the Transaction ID joins the pending payment
+    // and is not mistaken for a lucky number
+    third := bottest.User{ID: 1003, FirstName: "Third"}
+    b.handleUpdate(ctx, third.Callback("select_amount:100"))
+    b.handleUpdate(ctx, third.Text("SHOT-3"))
+    client.Reset()
+    b.handleUpdate(ctx, third.Photo("PHOTO3", ""))
+    if state, _ := store.GetUserState(ctx, third.ID); state.CurrentState != "awaiting_lucky_number" {
+        t.Errorf("late screenshot changed state to %q", state.CurrentState)
+    }
+    if txn, _ := store.GetTransaction(ctx, "SHOT-3"); txn.ScreenshotFileID != "PHOTO3" || txn.ScreenshotType != "photo" {
+        t.Errorf("late screenshot = %q/%q, want it on the transaction", txn.ScreenshotFileID, txn.ScreenshotType)
+    }
+    reviewed = false
+    for _, photo := range client.Photos() {
+        if photo.ChatID == admin.ID && strings.Contains(photo.Caption, "SHOT-3") && strings.Contains(photo.Caption, "Screenshot: attached") {
+            reviewed = photo.File == tgbotapi.FileID("PHOTO3")
+        }
+    }
+    if !reviewed {
+        t.Errorf("admins were not sent a review card with the late screenshot")
+    }
+
+    // Once the payment is settled there is nothing to attach it to
+    b.handleUpdate(ctx, admin.Callback("payment:approve:SHOT-3"))
+    b.handleUpdate(ctx, third.Photo("PHOTO4", ""))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "सिर्फ़ पेमेंट करते समय") {
+        t.Errorf("screenshot after approval reply = %q", msg.Text)
+    }
+    if txn, _ := store.GetTransaction(ctx, "SHOT-3"); txn.ScreenshotFileID != "PHOTO3" {
+        t.Errorf("screenshot after approval replaced the reviewed one")
+    }
+}
+
+func TestGatewayPurchase(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    gatewayServer, gateway := upigatewaytest.NewServer("secret")
+    defer gatewayServer.Close()
+    b.SetPaymentProvider(upigateway.New(upigateway.Config{BaseURL: gatewayServer.URL, APIKey: "secret"}))
+
+    callbacks := httptest.NewServer(b.PaymentCallbackHandler(ctx))
+    defer callbacks.Close()
+    gateway.WebhookURL = callbacks.URL
+
+    buy := func(user bottest.User) string {
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        state, _ := store.GetUserState(ctx, user.ID)
+        if state.CurrentState != "awaiting_lucky_number" || state.TransactionID == "" {
+            t.Fatalf("state after amount selection = %+v", state)
+        }
+        txn, err := store.GetTransaction(ctx, state.TransactionID)
+        if err != nil {
+            t.Fatalf("gateway transaction not saved: %v", err)
+        }
+        if txn.Status != "pending" || txn.Provider != "upigateway" || txn.ProviderRef == "" {
+            t.Errorf("transaction = %+v", txn)
+        }
+        if !sentTo(client, user.ID, "pay_check:"+txn.TransactionID) {
+            t.Errorf("payment message has no check button")
+        }
+        b.handleUpdate(ctx, user.Text("42"))
+        return txn.TransactionID
+    }
+    entryStatus := func(txnID string) string {
+        entries, _ := store.GetEntriesByTransaction(ctx, txnID)
+        if len(entries) != 1 {
+            t.Fatalf("%s has %d entries, want 1", txnID, len(entries))
+        }
+        return entries[0].Status
+    }
+
+    // Webhook path
+    orderID := buy(buyer)
+    if status := entryStatus(orderID); status != "pending" {
+        t.Errorf("entry status before payment = %q", status)
+    }
+    if err := gateway.Pay(orderID); err != nil {
+        t.Fatalf("Pay: %v", err)
+    }
+    if txn, _ := store.GetTransaction(ctx, orderID); txn.Status != "verified" {
+        t.Errorf("transaction status after webhook = %q", txn.Status)
+    }
+    if status := entryStatus(orderID); status != "active" {
+        t.Errorf("entry status after webhook = %q", status)
+    }
+
+    // Repeated webhooks are harmless
+    if err := gateway.Pay(orderID); err != nil {
+        t.Errorf("repeated webhook: %v", err)
+    }
+
+    // Check-button path with webhooks lost
+    gateway.WebhookURL = ""
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    orderID = buy(other)
+    b.handleUpdate(ctx, other.Callback("pay_check:"+orderID))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "अभी तक नहीं मिला") {
+        t.Errorf("unpaid check reply = %q", msg.Text)
+    }
+    gateway.Pay(orderID)
+    b.handleUpdate(ctx, buyer.Callback("pay_check:"+orderID))
+    if txn, _ := store.GetTransaction(ctx, orderID); txn.Status != "pending" {
+        t.Errorf("another user's check settled the order")
+    }
+    b.handleUpdate(ctx, other.Callback("pay_check:"+orderID))
+    if status := entryStatus(orderID); status != "active" {
+        t.Errorf("entry status after check = %q", status)
+    }
+
+    // Failed payments reject the entry
+    third := bottest.User{ID: 1003, FirstName: "Third"}
+    orderID = buy(third)
+    gateway.WebhookURL = callbacks.URL
+    if err := gateway.Fail(orderID); err != nil {
+        t.Fatalf("Fail: %v", err)
+    }
+    if status := entryStatus(orderID); status != "rejected" {
+        t.Errorf("entry status after failed payment = %q", status)
+    }
+
+    resp, err := http.PostForm(callbacks.URL, url.Values{"client_txn_id": {"ORD-unknown"}})
+    if err != nil {
+        t.Fatalf("callback: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusNotFound {
+        t.Errorf("callback for unknown order = %d, want 404", resp.StatusCode)
+    }
+}
+
+func TestGatewayPaidBeforeLuckyNumber(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    gatewayServer, gateway := upigatewaytest.NewServer("secret")
+    defer gatewayServer.Close()
+    b.SetPaymentProvider(upigateway.New(upigateway.Config{BaseURL: gatewayServer.URL, APIKey: "secret"}))
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    state, _ := store.GetUserState(ctx, buyer.ID)
+    orderID := state.TransactionID
+    if err := gateway.Pay(orderID); err != nil {
+        t.Fatalf("Pay: %v", err)
+    }
+
+    // Paying before picking a number has no entry to activate yet
+    client.Reset()
+    b.handleUpdate(ctx, buyer.Callback("pay_check:"+orderID))
+    if txn, _ := store.GetTransaction(ctx, orderID); txn.Status != "verified" {
+        t.Fatalf("transaction status after check = %q", txn.Status)
+    }
+    if sentTo(client, buyer.ID, "active है") || !sentTo(client, buyer.ID, "Lucky Number भेजें") {
+        t.Errorf("buyer without an entry was not asked for a lucky number: %+v", client.Messages())
+    }
+
+    b.handleUpdate(ctx, buyer.Text("42"))
+    entries, _ := store.GetEntriesByTransaction(ctx, orderID)
+    if len(entries) != 1 || entries[0].Status != "active" {
+        t.Errorf("entries after picking a number = %+v, want one active", entries)
+    }
+}
+
+func TestDynamicUPIQR(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    cfg := *b.cfg()
+    cfg.Payment.UPI = config.UPIConfig{VPA: "lottery@okaxis", PayeeName: "Test Lottery"}
+    b.SetConfig(&cfg)
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:200"))
+    state, _ := store.GetUserState(ctx, buyer.ID)
+    if state.UniqueCode == "" {
+        t.Fatalf("no Unique Code issued at amount selection")
+    }
+
+    photos := client.Photos()
+    if len(photos) != 1 {
+        t.Fatalf("sent %d photos, want 1", len(photos))
+    }
+    qr, ok := photos[0].File.(tgbotapi.FileBytes)
+    if !ok || !bytes.HasPrefix(qr.Bytes, []byte("\x89PNG")) {
+        t.Fatalf("QR was not sent as generated PNG bytes: %T", photos[0].File)
+    }
+    if !strings.Contains(photos[0].Caption, state.UniqueCode) {
+        t.Errorf("caption %q does not show the Unique Code", photos[0].Caption)
+    }
+
+    // Another buyer gets a different code
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:200"))
+    if otherState, _ := store.GetUserState(ctx, other.ID); otherState.UniqueCode == state.UniqueCode {
+        t.Errorf("two buyers share Unique Code %s", state.UniqueCode)
+    }
+
+    b.handleUpdate(ctx, buyer.Text("UTR-1"))
+    txn, err := store.GetTransaction(ctx, "UTR-1")
+    if err != nil {
+        t.Fatalf("transaction not saved: %v", err)
+    }
+    if txn.UniqueCode != state.UniqueCode {
+        t.Errorf("transaction Unique Code = %q, want the one on the QR (%q)", txn.UniqueCode, state.UniqueCode)
+    }
+    b.handleUpdate(ctx, buyer.Text("42"))
+    entries, _ := store.GetEntriesByTransaction(ctx, "UTR-1")
+    if len(entries) != 1 || entries[0].UniqueCode != state.UniqueCode {
+        t.Errorf("entries = %+v", entries)
+    }
+}
+
+func TestReconcileStatement(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    // Two buyers: one typed their Transaction ID wrong but kept the
+    // Unique Code in the UPI note, the other never paid
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Text("WRONG-ID"))
+    b.handleUpdate(ctx, buyer.Text("42"))
+    paid, err := store.GetTransaction(ctx, "WRONG-ID")
+    if err != nil {
+        t.Fatalf("transaction not saved: %v", err)
+    }
+
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:200"))
+    b.handleUpdate(ctx, other.Text("UNPAID-1"))
+    b.handleUpdate(ctx, other.Text("7"))
+
+    statement := "Date,Narration,UPI Ref No,Credit\n" +
+        "15/03/2024,UPI/buyer/" + paid.UniqueCode + ",412345678901,100.00\n" +
+        "15/03/2024,UPI/stranger/gift,412345678999,500.00\n"
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(statement))
+    }))
+    defer server.Close()
+    client.SetFileURL("STMT1", server.URL+"/statement.csv")
+
+    // Only admins may reconcile
+    b.handleUpdate(ctx, buyer.Command("/reconcile"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Admin नहीं") {
+        t.Errorf("non-admin /reconcile reply = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, admin.Command("/reconcile"))
+    b.handleUpdate(ctx, admin.Document("STMT1", "text/csv", ""))
+
+    txn, _ := store.GetTransaction(ctx, "WRONG-ID")
+    if txn.Status != "verified" {
+        t.Errorf("matched transaction status = %q, want verified", txn.Status)
+    }
+    entries, _ := store.GetEntriesByTransaction(ctx, "WRONG-ID")
+    if len(entries) != 1 || entries[0].Status != "active" {
+        t.Errorf("matched entries = %+v, want one active", entries)
+    }
+    if txn, _ := store.GetTransaction(ctx, "UNPAID-1"); txn.Status != "pending" {
+        t.Errorf("unpaid transaction status = %q, want pending", txn.Status)
+    }
+    if !sentTo(client, admin.ID, "Verified: 1") || !sentTo(client, admin.ID, "Unmatched rows: 1") {
+        t.Errorf("admin did not get the reconcile summary")
+    }
+
+    var report tgbotapi.FileBytes
+    for _, doc := range client.Documents() {
+        if file, ok := doc.File.(tgbotapi.FileBytes); ok && doc.ChatID == admin.ID {
+            report = file
+        }
+    }
+    if !strings.HasPrefix(report.Name, "reconcile-") {
+        t.Fatalf("reconcile report not sent to admin")
+    }
+    for _, want := range []string{"matched", "unmatched_row", "not_in_statement,,,,,UNPAID-1"} {
+        if !bytes.Contains(report.Bytes, []byte(want)) {
+            t.Errorf("report missing %q:\n%s", want, report.Bytes)
+        }
+    }
+
+    // The statement step is one-shot
+    if state, err := store.GetUserState(ctx, admin.ID); err == nil && state.CurrentState == "awaiting_statement" {
+        t.Errorf("admin still awaiting a statement after upload")
+    }
+}
+
+func TestRefund(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Text("REF-1"))
+    b.handleUpdate(ctx, buyer.Text("42"))
+    b.handleUpdate(ctx, admin.Callback("payment:approve:REF-1"))
+
+    b.handleUpdate(ctx, buyer.Command("/refund REF-1 RF9"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Admin नहीं") {
+        t.Errorf("non-admin /refund reply = %q", msg.Text)
+    }
+
+    // Manual payments need the bank's refund reference
+    b.handleUpdate(ctx, admin.Command("/refund REF-1"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "refund reference required") {
+        t.Errorf("/refund without reference reply = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, admin.Command("/refund REF-1 UPIRF9"))
+    txn, _ := store.GetTransaction(ctx, "REF-1")
+    if txn.Status != "refunded" || txn.RefundRef != "UPIRF9" || txn.RefundedAt.IsZero() {
+        t.Errorf("refunded transaction = %+v", txn)
+    }
+    entries, _ := store.GetEntriesByTransaction(ctx, "REF-1")
+    if len(entries) != 1 || entries[0].Status != "refunded" {
+        t.Errorf("entries after refund = %+v, want one refunded", entries)
+    }
+    if !sentTo(client, buyer.ID, "UPIRF9") {
+        t.Errorf("buyer was not told about the refund")
+    }
+    if eligible, _ := b.draws.EligibleEntries(ctx, draw.Request{Amount: 100, DrawID: entries[0].DrawID}); len(eligible) != 0 {
+        t.Errorf("refunded entry is still eligible for the draw")
+    }
+
+    b.handleUpdate(ctx, admin.Command("/refund REF-1 UPIRF10"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "already refunded") {
+        t.Errorf("second /refund reply = %q", msg.Text)
+    }
+
+    // Gateway payments are refunded through the gateway
+    gatewayServer, gateway := upigatewaytest.NewServer("secret")
+    defer gatewayServer.Close()
+    b.SetPaymentProvider(upigateway.New(upigateway.Config{BaseURL: gatewayServer.URL, APIKey: "secret"}))
+
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:100"))
+    state, _ := store.GetUserState(ctx, other.ID)
+    orderID := state.TransactionID
+    b.handleUpdate(ctx, other.Text("7"))
+    if err := gateway.Pay(orderID); err != nil {
+        t.Fatalf("Pay: %v", err)
+    }
+    b.handleUpdate(ctx, other.Callback("pay_check:"+orderID))
+
+    b.handleUpdate(ctx, admin.Command("/refund "+orderID))
+    txn, _ = store.GetTransaction(ctx, orderID)
+    if txn.Status != "refunded" || !strings.HasPrefix(txn.RefundRef, "RF") {
+        t.Errorf("gateway refund = %q/%q", txn.Status, txn.RefundRef)
+    }
+    if order, _ := gateway.Order(orderID); order.Refunded != 100 {
+        t.Errorf("gateway refunded %.2f, want 100", order.Refunded)
+    }
+}
+
+// slowRefunds is a payment provider whose refunds wait for release
+type slowRefunds struct {
+    payment.Provider
+    started chan struct{}
+    release chan struct{}
+}
+
+func (p *slowRefunds) Name() string { return "slow" }
+
+func (p *slowRefunds) Refund(ctx context.Context, orderID string, amount float64) (*payment.Refund, error) {
+    close(p.started)
+    <-p.release
+    return &payment.Refund{OrderID: orderID, RefundID: "RF-SLOW", Amount: amount}, nil
+}
+
+func TestRefundIsNotRacedByDraw(t *testing.T) {
+    b, _, store := newTestBot(t)
+    ctx := context.Background()
+
+    now := time.Now()
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    store.SaveTransaction(ctx, &models.Transaction{TransactionID: "ORD1", UserID: buyer.ID, Amount: 100, Date: now, Status: "verified", Provider: "slow"})
+    store.SaveLotteryEntry(ctx, &models.LotteryEntry{EntryID: "E1", TransactionID: "ORD1", UserID: buyer.ID, TicketAmount: 100, LuckyNumber: 7, EntryDate: now, EntryTime: now, Status: "active"})
+    store.SaveLotteryEntry(ctx, &models.LotteryEntry{EntryID: "E2", TransactionID: "ORD2", UserID: other.ID, TicketAmount: 100, LuckyNumber: 8, EntryDate: now, EntryTime: now.Add(time.Minute), Status: "active"})
+
+    provider := &slowRefunds{started: make(chan struct{}), release: make(chan struct{})}
+    b.SetPaymentProvider(provider)
+
+    refunded := make(chan struct{})
+    go func() {
+        b.handleUpdate(ctx, admin.Command("/refund ORD1"))
+        close(refunded)
+    }()
+    <-provider.started
+
+    // The draw runs while the gateway refund is in flight without waiting
+    // for it, and leaves out the entry being refunded even though FCFS
+    // would pick it first
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("1"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:fcfs"))
+
+    winners, _ := store.GetWinnersByDate(ctx, now)
+    if len(winners) != 1 || winners[0].EntryID != "E2" {
+        t.Errorf("draw during the refund picked %+v, want only E2", winners)
+    }
+
+    close(provider.release)
+    <-refunded
+    if entries, _ := store.GetEntriesByTransaction(ctx, "ORD1"); len(entries) != 1 || entries[0].Status != "refunded" {
+        t.Errorf("entry after refund and draw = %+v, want refunded", entries)
+    }
+}
+
+// failingRefunds is a payment provider whose refunds always fail
+type failingRefunds struct {
+    payment.Provider
+}
+
+func (failingRefunds) Name() string { return "failing" }
+
+func (failingRefunds) Refund(ctx context.Context, orderID string, amount float64) (*payment.Refund, error) {
+    return nil, errors.New("gateway unavailable")
+}
+
+func TestRefundRules(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+    b.SetPaymentProvider(failingRefunds{})
+
+    now := time.Now()
+    store.SaveDraw(ctx, &models.Draw{DrawID: "DRAWN", TicketAmount: 100, Status: "drawn"})
+    store.OpenDraw(ctx, &models.Draw{DrawID: "OPEN", TicketAmount: 100})
+    for _, txn := range []struct {
+        id, status, drawID string
+    }{
+        {"EXPIRED", "expired", "DRAWN"},
+        {"STALE", "active", "DRAWN"},
+        {"FAILS", "active", "OPEN"},
+    } {
+        store.SaveTransaction(ctx, &models.Transaction{TransactionID: txn.id, UserID: buyer.ID, Amount: 100, Date: now, Status: "verified", Provider: "failing"})
+        store.SaveLotteryEntry(ctx, &models.LotteryEntry{EntryID: "E-" + txn.id, TransactionID: txn.id, UserID: buyer.ID, TicketAmount: 100, UniqueCode: "C-" + txn.id, EntryDate: now, EntryTime: now, Status: txn.status, DrawID: txn.drawID})
+    }
+
+    // Only pending or active entries of an undrawn draw can be refunded
+    b.handleUpdate(ctx, admin.Command("/refund EXPIRED RF1"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "C-EXPIRED is expired") {
+        t.Errorf("/refund of an expired entry = %q", msg.Text)
+    }
+    b.handleUpdate(ctx, admin.Command("/refund STALE RF2"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "already been drawn") {
+        t.Errorf("/refund of an entry in a drawn draw = %q", msg.Text)
+    }
+    for _, id := range []string{"EXPIRED", "STALE"} {
+        if txn, _ := store.GetTransaction(ctx, id); txn.Status != "verified" {
+            t.Errorf("%s after a refused refund is %s", id, txn.Status)
+        }
+    }
+
+    // A failed gateway refund puts the entry back in its draw
+    b.handleUpdate(ctx, admin.Command("/refund FAILS"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "gateway refund failed") {
+        t.Errorf("/refund with a failing gateway = %q", msg.Text)
+    }
+    entries, _ := store.GetEntriesByTransaction(ctx, "FAILS")
+    if len(entries) != 1 || entries[0].Status != "active" || entries[0].DrawID != "OPEN" {
+        t.Errorf("entry after a failed refund = %+v, want active in OPEN", entries)
+    }
+}
+
+func TestWinnerPayout(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    players := []bottest.User{{ID: 2000, FirstName: "Player"}, {ID: 2001, FirstName: "Player"}}
+    for i, user := range players {
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        b.handleUpdate(ctx, user.Text(fmt.Sprintf("TXN%d", i)))
+        b.handleUpdate(ctx, user.Text("7"))
+        b.handleUpdate(ctx, admin.Callback(fmt.Sprintf("payment:approve:TXN%d", i)))
+    }
+
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("2"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:random"))
+
+    winners, _ := store.GetWinnersByDate(ctx, time.Now())
+    if len(winners) != 2 {
+        t.Fatalf("recorded %d winners, want 2", len(winners))
+    }
+    for _, winner := range winners {
+        if !sentTo(client, winner.UserID, "बधाई हो") {
+            t.Errorf("winner %d was not told they won", winner.UserID)
+        }
+        if !sentTo(client, admin.ID, "payout:"+winner.WinnerID) {
+            t.Errorf("payout checklist has no button for %s", winner.WinnerID)
+        }
+    }
+
+    b.handleUpdate(ctx, admin.Command("/payouts pending"))
+    if !sentTo(client, admin.ID, "2 pending payouts, total ₹200.00") {
+        t.Errorf("/payouts pending did not list both winners")
+    }
+
+    paid := winners[0]
+    b.handleUpdate(ctx, players[0].Callback("payout:"+paid.WinnerID))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Admin नहीं") {
+        t.Errorf("non-admin payout reply = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, admin.Callback("payout:"+paid.WinnerID))
+    b.handleUpdate(ctx, admin.Text("bad ref!"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Invalid reference") {
+        t.Errorf("invalid reference reply = %q", msg.Text)
+    }
+    b.handleUpdate(ctx, admin.Text("UTR123456"))
+
+    got, _ := store.GetWinner(ctx, paid.WinnerID)
+    if got.PaymentStatus != "completed" || got.PaymentTransactionID != "UTR123456" {
+        t.Errorf("winner after payout = %+v", got)
+    }
+    if !sentTo(client, paid.UserID, "UTR123456") {
+        t.Errorf("winner was not told about the payout")
+    }
+
+    // A second confirmation is refused
+    b.handleUpdate(ctx, admin.Callback("payout:"+paid.WinnerID))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "पहले ही हो चुका") {
+        t.Errorf("repeat payout reply = %q", msg.Text)
+    }
+
+    client.Reset()
+    b.handleUpdate(ctx, admin.Command("/payouts pending"))
+    if !sentTo(client, admin.ID, "1 pending payouts, total ₹100.00") || sentTo(client, admin.ID, "payout:"+paid.WinnerID) {
+        t.Errorf("/payouts pending still lists the paid winner")
+    }
+}
+
+func TestDrawAnnouncement(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    const proofChat = -1001234567890
+    cfg := *b.cfg()
+    cfg.Channels.LotteryProofChatID = proofChat
+    b.SetConfig(&cfg)
+
+    players := []bottest.User{
+        {ID: 2000, UserName: "rahul_kumar", FirstName: "Rahul"},
+        {ID: 2001, FirstName: "Player"},
+    }
+    for i, user := range players {
+        b.handleUpdate(ctx, user.Command("/start"))
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        b.handleUpdate(ctx, user.Text(fmt.Sprintf("TXN%d", i)))
+        b.handleUpdate(ctx, user.Text(strconv.Itoa(7+i)))
+        b.handleUpdate(ctx, admin.Callback(fmt.Sprintf("payment:approve:TXN%d", i)))
+    }
+
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("2"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:fcfs"))
+
+    var posts []tgbotapi.MessageConfig
+    for _, msg := range client.Messages() {
+        if msg.ChatID == proofChat {
+            posts = append(posts, msg)
+        }
+    }
+    // The seed hash is published when the draw opens, the result at the end
+    if len(posts) != 2 {
+        t.Fatalf("posted %d messages to the proof channel, want 2", len(posts))
+    }
+    commitment, result := posts[0], posts[1]
+    for _, want := range []string{"Ticket: ₹100", "First Come First Serve", "Total entries: 2",
+        "7 (1 entry), 8 (1 entry)", "@ra***r - Number 7", "Pl***r - Number 8"} {
+        if !strings.Contains(result.Text, want) {
+            t.Errorf("announcement missing %q:\n%s", want, result.Text)
+        }
+    }
+    if strings.Contains(result.Text, "rahul_kumar") {
+        t.Errorf("announcement leaks the full username")
+    }
+
+    winners, _ := store.GetWinnersByDate(ctx, time.Now())
+    if len(winners) == 0 || winners[0].DrawID == "" {
+        t.Fatalf("winners are not linked to a draw: %+v", winners)
+    }
+    record, err := store.GetDraw(ctx, winners[0].DrawID)
+    if err != nil {
+        t.Fatalf("draw not saved: %v", err)
+    }
+    if record.AnnouncementChatID != proofChat || record.AnnouncementMessageID == 0 || record.EntryCount != 2 {
+        t.Errorf("draw record = %+v", record)
+    }
+    if !strings.Contains(commitment.Text, record.SeedHash) || strings.Contains(commitment.Text, record.Seed) {
+        t.Errorf("commitment does not publish just the seed hash:\n%s", commitment.Text)
+    }
+    if !strings.Contains(result.Text, "Seed: "+record.Seed) {
+        t.Errorf("result does not reveal the seed:\n%s", result.Text)
+    }
+    if !sentTo(client, admin.ID, "posted to the proof channel") {
+        t.Errorf("admin was not told the result was posted")
+    }
+}
+
+func TestScheduledDraw(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    cfg := *b.cfg()
+    cfg.Tickets.Schedules = []config.DrawSchedule{
+        {Price: 100, Cron: "0 21 * * *", TimeZone: "Asia/Kolkata", WinnerCount: 1, SalesCutoff: 30 * time.Minute},
+    }
+    b.SetConfig(&cfg)
+
+    ist, _ := time.LoadLocation("Asia/Kolkata")
+    drawTime := time.Date(2024, 3, 15, 21, 0, 0, 0, ist)
+    clock := schedulertest.NewClock(drawTime.Add(-2 * time.Hour))
+    b.SetClock(clock)
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Text("TXN-S1"))
+    b.handleUpdate(ctx, buyer.Text("42"))
+    b.handleUpdate(ctx, admin.Callback("payment:approve:TXN-S1"))
+    if !sentTo(client, buyer.ID, drawTime.Format("02 Jan 2006, 03:04 PM")) {
+        t.Errorf("confirmation does not name the draw time")
+    }
+
+    // Sales close 30 minutes before the draw
+    clock.Set(drawTime.Add(-20 * time.Minute))
+    other := bottest.User{ID: 1002, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:100"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "बिक्री अभी बंद") {
+        t.Errorf("purchase after cutoff reply = %q", msg.Text)
+    }
+    if state, _ := store.GetUserState(ctx, other.ID); state.CurrentState != "" {
+        t.Errorf("purchase after cutoff started a payment: %q", state.CurrentState)
+    }
+
+    clock.Set(drawTime)
+    b.scheduler.Tick(ctx)
+    b.scheduler.Tick(ctx)
+
+    winners, _ := store.GetWinnersByUser(ctx, buyer.ID)
+    if len(winners) != 1 {
+        t.Fatalf("buyer won %d times, want exactly 1", len(winners))
+    }
+    if winners[0].DrawID != scheduler.DrawID(100, drawTime) {
+        t.Errorf("winner draw ID = %q", winners[0].DrawID)
+    }
+    if !sentTo(client, admin.ID, "Scheduled draw") || !sentTo(client, buyer.ID, "बधाई हो") {
+        t.Errorf("scheduled draw was not reported")
+    }
+
+    // Sales reopen for the next draw, and a restart does not draw again
+    b.handleUpdate(ctx, other.Callback("select_amount:100"))
+    if state, _ := store.GetUserState(ctx, other.ID); state.CurrentState != "awaiting_transaction_id" {
+        t.Errorf("sales did not reopen after the draw: %q", state.CurrentState)
+    }
+    b.SetClock(clock)
+    b.scheduler.Tick(ctx)
+    if winners, _ := store.GetWinnersByUser(ctx, buyer.ID); len(winners) != 1 {
+        t.Errorf("restart re-ran the draw")
+    }
+}
+
+func TestEntriesAfterSalesCutoff(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    cfg := *b.cfg()
+    cfg.Tickets.Schedules = []config.DrawSchedule{
+        {Price: 100, Cron: "0 21 * * *", TimeZone: "Asia/Kolkata", WinnerCount: 1, SalesCutoff: 30 * time.Minute},
+    }
+    b.SetConfig(&cfg)
+
+    ist, _ := time.LoadLocation("Asia/Kolkata")
+    tonight := time.Date(2024, 3, 15, 21, 0, 0, 0, ist)
+    tomorrow := tonight.AddDate(0, 0, 1)
+    dayAfter := tonight.AddDate(0, 0, 2)
+    clock := schedulertest.NewClock(tonight.Add(-time.Hour))
+    b.SetClock(clock)
+
+    entryOf := func(txnID string) *models.LotteryEntry {
+        t.Helper()
+        entries, _ := store.GetEntriesByTransaction(ctx, txnID)
+        if len(entries) != 1 {
+            t.Fatalf("%s: %d entries, want 1", txnID, len(entries))
+        }
+        return entries[0]
+    }
+
+    // One entry is confirmed before the cutoff but its payment is verified
+    // after it; another buyer starts paying before the cutoff and picks a
+    // number after it
+    early := bottest.User{ID: 1005, FirstName: "Early"}
+    b.handleUpdate(ctx, early.Callback("select_amount:100"))
+    b.handleUpdate(ctx, early.Text("TXN-C1"))
+    b.handleUpdate(ctx, early.Text("5"))
+    if entry := entryOf("TXN-C1"); entry.DrawID != scheduler.DrawID(100, tonight) {
+        t.Fatalf("entry before the cutoff joined %q, want tonight's draw", entry.DrawID)
+    }
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Text("TXN-C2"))
+
+    clock.Set(tonight.Add(-20 * time.Minute))
+    b.scheduler.Tick(ctx)
+    if d, _ := store.GetDraw(ctx, scheduler.DrawID(100, tonight)); d.Status != "closed" {
+        t.Errorf("tonight's draw is %s after the cutoff, want closed", d.Status)
+    }
+
+    client.Reset()
+    b.handleUpdate(ctx, buyer.Text("8"))
+    if entry := entryOf("TXN-C2"); entry.DrawID != scheduler.DrawID(100, tomorrow) {
+        t.Errorf("entry after the cutoff joined %q, want tomorrow's draw", entry.DrawID)
+    }
+    if !sentTo(client, buyer.ID, tomorrow.Format("02 Jan 2006, 03:04 PM")) {
+        t.Errorf("confirmation does not name tomorrow's draw time")
+    }
+
+    b.handleUpdate(ctx, admin.Callback("payment:approve:TXN-C1"))
+    b.handleUpdate(ctx, admin.Callback("payment:approve:TXN-C2"))
+    if entry := entryOf("TXN-C1"); entry.Status != "active" || entry.DrawID != scheduler.DrawID(100, tomorrow) {
+        t.Errorf("entry approved after the cutoff = %s in %q, want active in tomorrow's draw", entry.Status, entry.DrawID)
+    }
+
+    clock.Set(tonight)
+    b.scheduler.Tick(ctx)
+    if d, _ := store.GetDraw(ctx, scheduler.DrawID(100, tonight)); d.Status != "drawn" || d.EntryCount != 0 {
+        t.Errorf("tonight's draw = %+v, want drawn with no entries", d)
+    }
+
+    // An admin draws tomorrow's draw early; new entries join the draw after
+    // it rather than an unscheduled one
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("1"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:random"))
+    if d, _ := store.GetDraw(ctx, scheduler.DrawID(100, tomorrow)); d.Status != "drawn" || d.EntryCount != 2 {
+        t.Fatalf("tomorrow's draw after the early draw = %+v", d)
+    }
+
+    other := bottest.User{ID: 1006, FirstName: "Other"}
+    b.handleUpdate(ctx, other.Callback("select_amount:100"))
+    b.handleUpdate(ctx, other.Text("TXN-C3"))
+    b.handleUpdate(ctx, other.Text("9"))
+    if entry := entryOf("TXN-C3"); entry.DrawID != scheduler.DrawID(100, dayAfter) {
+        t.Errorf("entry after an early draw joined %q, want the day after's draw", entry.DrawID)
+    }
+}
+
+func TestDrawRounds(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    cfg := *b.cfg()
+    cfg.Tickets.Schedules = []config.DrawSchedule{
+        {Price: 100, Cron: "0 9,21 * * *", TimeZone: "Asia/Kolkata", WinnerCount: 1},
+    }
+    b.SetConfig(&cfg)
+
+    ist, _ := time.LoadLocation("Asia/Kolkata")
+    morning := time.Date(2024, 3, 15, 9, 0, 0, 0, ist)
+    evening := time.Date(2024, 3, 15, 21, 0, 0, 0, ist)
+    nextMorning := time.Date(2024, 3, 16, 9, 0, 0, 0, ist)
+    clock := schedulertest.NewClock(morning.Add(-time.Hour))
+    b.SetClock(clock)
+
+    buy := func(user bottest.User, txnID, number string) *models.LotteryEntry {
+        t.Helper()
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        b.handleUpdate(ctx, user.Text(txnID))
+        b.handleUpdate(ctx, user.Text(number))
+        entries, _ := store.GetEntriesByTransaction(ctx, txnID)
+        if len(entries) != 1 {
+            t.Fatalf("%s: %d entries, want 1", txnID, len(entries))
+        }
+        return entries[0]
+    }
+
+    // Two draws a day: a morning entry joins the 09:00 draw
+    entry := buy(buyer, "TXN-R1", "7")
+    b.handleUpdate(ctx, admin.Callback("payment:approve:TXN-R1"))
+    if entry.DrawID != scheduler.DrawID(100, morning) {
+        t.Errorf("morning entry draw = %q, want the 09:00 draw", entry.DrawID)
+    }
+
+    // This payment is only verified after the 09:00 draw
+    late := bottest.User{ID: 1003, FirstName: "Late"}
+    lateEntry := buy(late, "TXN-R2", "9")
+
+    clock.Set(morning)
+    b.scheduler.Tick(ctx)
+
+    record, err := store.GetDraw(ctx, scheduler.DrawID(100, morning))
+    if err != nil {
+        t.Fatalf("09:00 draw not saved: %v", err)
+    }
+    if record.Status != "drawn" || record.EntryCount != 1 || record.Seed == "" ||
+        record.OpenedAt.IsZero() || record.ClosedAt.IsZero() || record.DrawnAt.IsZero() {
+        t.Errorf("09:00 draw = %+v", record)
+    }
+    if !sentTo(client, buyer.ID, "बधाई हो") {
+        t.Errorf("09:00 winner was not notified")
+    }
+
+    clock.Set(morning.Add(time.Hour))
+    b.handleUpdate(ctx, admin.Callback("payment:approve:TXN-R2"))
+    moved, _ := store.GetEntriesByTransaction(ctx, "TXN-R2")
+    if moved[0].Status != "active" || moved[0].DrawID != scheduler.DrawID(100, evening) {
+        t.Errorf("late entry = %+v, want active in the 21:00 draw (was %s)", moved[0], lateEntry.DrawID)
+    }
+
+    // A late night entry carries past midnight into the next morning's draw
+    clock.Set(evening.Add(150 * time.Minute))
+    b.scheduler.Tick(ctx)
+    night := bottest.User{ID: 1004, FirstName: "Night"}
+    if entry := buy(night, "TXN-R3", "3"); entry.DrawID != scheduler.DrawID(100, nextMorning) {
+        t.Errorf("late night entry draw = %q, want the next 09:00 draw", entry.DrawID)
+    }
+    if winners, _ := store.GetWinnersByUser(ctx, late.ID); len(winners) != 1 || winners[0].DrawID != scheduler.DrawID(100, evening) {
+        t.Errorf("late entry did not win the 21:00 draw: %+v", winners)
+    }
+
+    open, _ := store.ListDraws(ctx, storage.DrawFilter{Status: "open", TicketAmount: 100})
+    if len(open) != 1 || open[0].DrawID != scheduler.DrawID(100, nextMorning) {
+        t.Errorf("open draws = %+v, want only the next 09:00 draw", open)
+    }
+}
+
+func TestVerifyDraw(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    for i := 0; i < 3; i++ {
+        user := bottest.User{ID: int64(2000 + i), FirstName: "Player"}
+        b.handleUpdate(ctx, user.Callback("select_amount:100"))
+        b.handleUpdate(ctx, user.Text(fmt.Sprintf("TXN-V%d", i)))
+        b.handleUpdate(ctx, user.Text(strconv.Itoa(10+i)))
+        b.handleUpdate(ctx, admin.Callback(fmt.Sprintf("payment:approve:TXN-V%d", i)))
+    }
+    entries, _ := store.GetEntriesByTransaction(ctx, "TXN-V0")
+    drawID := entries[0].DrawID
+
+    // Before the draw only the commitment is shown
+    b.handleUpdate(ctx, buyer.Command("/verify "+drawID))
+    record, _ := store.GetDraw(ctx, drawID)
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, record.SeedHash) || strings.Contains(msg.Text, record.Seed) {
+        t.Errorf("/verify on an open draw = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("2"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:random"))
+
+    client.Reset()
+    b.handleUpdate(ctx, buyer.Command("/verify "+drawID))
+    msg := client.LastMessage()
+    for _, want := range []string{"Entries: 3", "Seed matches the published hash", "Matches the announced winners"} {
+        if !strings.Contains(msg.Text, want) {
+            t.Errorf("/verify missing %q:\n%s", want, msg.Text)
+        }
+    }
+
+    // The entry file recomputes the same winners offline
+    docs := client.Documents()
+    if len(docs) != 1 {
+        t.Fatalf("sent %d files, want the entry file", len(docs))
+    }
+    file, ok := docs[0].File.(tgbotapi.FileBytes)
+    if !ok {
+        t.Fatalf("entry file is %T", docs[0].File)
+    }
+    exported, err := draw.ReadEntries(bytes.NewReader(file.Bytes))
+    if err != nil {
+        t.Fatalf("ReadEntries: %v", err)
+    }
+    stored, _ := store.GetEntriesByDraw(ctx, drawID)
+    for _, entry := range stored {
+        if bytes.Contains(file.Bytes, []byte(entry.UniqueCode)) {
+            t.Errorf("entry file exposes unique code %s", entry.UniqueCode)
+        }
+    }
+    if !strings.Contains(msg.Text, "Entries hash: "+draw.HashEntries(exported)) {
+        t.Errorf("/verify entries hash does not match the entry file:\n%s", msg.Text)
+    }
+    record, _ = store.GetDraw(ctx, drawID)
+    winners := draw.SelectFair(record.Seed, exported, record.WinnerCount)
+    if len(winners) != 2 {
+        t.Fatalf("recomputed %d winners, want 2", len(winners))
+    }
+    status := make(map[string]string)
+    for _, entry := range stored {
+        status[entry.EntryID] = entry.Status
+    }
+    for _, winner := range winners {
+        if status[winner.EntryID] != "winner" {
+            t.Errorf("recomputed winner %s did not win", winner.EntryID)
+        }
+    }
+}
+
+func TestVerifyUncommittedDraw(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    const proofChat = -1001234567890
+    cfg := *b.cfg()
+    cfg.Channels.LotteryProofChatID = proofChat
+    b.SetConfig(&cfg)
+
+    b.handleUpdate(ctx, buyer.Callback("select_amount:100"))
+    b.handleUpdate(ctx, buyer.Text("TXN-U0"))
+    b.handleUpdate(ctx, buyer.Text("10"))
+    b.handleUpdate(ctx, admin.Callback("payment:approve:TXN-U0"))
+    entries, _ := store.GetEntriesByTransaction(ctx, "TXN-U0")
+    drawID := entries[0].DrawID
+
+    // A draw opened before seeds were committed has no seed or hash
+    record, _ := store.GetDraw(ctx, drawID)
+    record.Seed, record.SeedHash = "", ""
+    if err := store.SaveDraw(ctx, record); err != nil {
+        t.Fatalf("SaveDraw: %v", err)
+    }
+
+    b.handleUpdate(ctx, admin.Command("/select_winner"))
+    b.handleUpdate(ctx, admin.Callback("winner_amount:100"))
+    b.handleUpdate(ctx, admin.Text("1"))
+    b.handleUpdate(ctx, admin.Callback("winner_method:random"))
+
+    record, _ = store.GetDraw(ctx, drawID)
+    if !draw.Drawn(record) || record.Seed == "" || record.SeedHash != "" {
+        t.Fatalf("draw record = %+v, want a seed without a hash", record)
+    }
+    if !sentTo(client, proofChat, "seed hash पहले से publish नहीं हुआ था") {
+        t.Errorf("announcement does not say the seed was not committed")
+    }
+
+    client.Reset()
+    b.handleUpdate(ctx, buyer.Command("/verify "+drawID))
+    msg := client.LastMessage()
+    if !strings.Contains(msg.Text, "No seed hash was published") || strings.Contains(msg.Text, "Seed matches") {
+        t.Errorf("/verify on an uncommitted draw = %q", msg.Text)
+    }
+}
+
+// cachedStore reports fixed cache stats for a memory store
+type cachedStore struct {
+    *memory.MemoryStorage
+}
+
+func (cachedStore) CacheStats() storage.CacheStats {
+    return storage.CacheStats{Hits: 30, Misses: 10}
+}
+
+func TestStatsCommand(t *testing.T) {
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    b.handleUpdate(ctx, buyer.Command("/stats"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "Admin नहीं") {
+        t.Errorf("/stats from a buyer = %q", msg.Text)
+    }
+
+    b.handleUpdate(ctx, admin.Command("/stats"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "not used by this storage") {
+        t.Errorf("/stats without a cache = %q", msg.Text)
+    }
+
+    b = NewWithClient(client, cachedStore{store}, b.cfg())
+    b.handleUpdate(ctx, admin.Command("/stats"))
+    if msg := client.LastMessage(); !strings.Contains(msg.Text, "30 hits, 10 misses (75.0% hit rate)") {
+        t.Errorf("/stats with a cache = %q", msg.Text)
+    }
+}
+
+func TestAuditDatesAreLocalDays(t *testing.T) {
+    local := time.Local
+    time.Local = time.FixedZone("IST", 5*60*60+30*60)
+    t.Cleanup(func() { time.Local = local })
+
+    b, client, store := newTestBot(t)
+    ctx := context.Background()
+
+    day := time.Date(2026, time.October, 16, 0, 0, 0, 0, time.Local)
+    for _, action := range []struct {
+        name string
+        at   time.Time
+    }{
+        {"day_before", day.Add(-30 * time.Minute)},
+        {"day_start", day.Add(30 * time.Minute)},
+        {"day_end", day.Add(23*time.Hour + 59*time.Minute)},
+        {"day_after", day.Add(24*time.Hour + 30*time.Minute)},
+    } {
+        store.SaveAdminAction(ctx, &models.AdminAction{AdminID: admin.ID, ActionType: action.name, Timestamp: action.at})
+    }
+
+    b.handleUpdate(ctx, admin.Command("/audit from 2026-10-16 to 2026-10-16"))
+    msg := client.LastMessage().Text
+    for _, want := range []string{"Action: day_start", "Action: day_end"} {
+        if !strings.Contains(msg, want) {
+            t.Errorf("/audit for the day is missing %q:\n%s", want, msg)
+        }
+    }
+    for _, unwanted := range []string{"Action: day_before", "Action: day_after"} {
+        if strings.Contains(msg, unwanted) {
+            t.Errorf("/audit for the day includes %q:\n%s", unwanted, msg)
+        }
+    }
+}
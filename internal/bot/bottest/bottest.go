@@ -0,0 +1,209 @@
+// Package bottest provides a recording fake of the Telegram client so bot
+// flows can be driven by scripted updates in go test.
+package bottest
+
+import (
+    "fmt"
+    "sync"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Client records everything the bot sends instead of calling Telegram.
+// It satisfies bot.Client.
+type Client struct {
+    mutex     sync.Mutex
+    sent      []tgbotapi.Chattable
+    requested []tgbotapi.Chattable
+    raw       []RawRequest
+    fileURLs  map[string]string
+    nextID    int
+
+    // Updates is returned by GetUpdatesChan; push scripted updates into it
+    Updates chan tgbotapi.Update
+}
+
+// RawRequest is a MakeRequest or UploadFiles call
+type RawRequest struct {
+    Endpoint string
+    Params   tgbotapi.Params
+    Files    []tgbotapi.RequestFile
+}
+
+func NewClient() *Client {
+    return &Client{
+        Updates: make(chan tgbotapi.Update, 100),
+    }
+}
+
+func (c *Client) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    c.sent = append(c.sent, chattable)
+    c.nextID++
+
+    msg := tgbotapi.Message{MessageID: c.nextID}
+    switch v := chattable.(type) {
+    case tgbotapi.MessageConfig:
+        msg.Chat = &tgbotapi.Chat{ID: v.ChatID}
+        msg.Text = v.Text
+    case tgbotapi.EditMessageTextConfig:
+        msg.MessageID = v.MessageID
+        msg.Chat = &tgbotapi.Chat{ID: v.ChatID}
+        msg.Text = v.Text
+    case tgbotapi.PhotoConfig:
+        msg.Chat = &tgbotapi.Chat{ID: v.ChatID}
+        msg.Caption = v.Caption
+    case tgbotapi.DocumentConfig:
+        msg.Chat = &tgbotapi.Chat{ID: v.ChatID}
+        msg.Caption = v.Caption
+    }
+
+    return msg, nil
+}
+
+func (c *Client) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    c.requested = append(c.requested, chattable)
+    return &tgbotapi.APIResponse{Ok: true, Result: []byte("true")}, nil
+}
+
+func (c *Client) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+    return c.Updates
+}
+
+// SetFileURL makes GetFileDirectURL return url for fileID
+func (c *Client) SetFileURL(fileID, url string) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    if c.fileURLs == nil {
+        c.fileURLs = make(map[string]string)
+    }
+    c.fileURLs[fileID] = url
+}
+
+func (c *Client) GetFileDirectURL(fileID string) (string, error) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    url, ok := c.fileURLs[fileID]
+    if !ok {
+        return "", fmt.Errorf("file %s not found", fileID)
+    }
+    return url, nil
+}
+
+func (c *Client) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    c.raw = append(c.raw, RawRequest{Endpoint: endpoint, Params: params})
+    return &tgbotapi.APIResponse{Ok: true, Result: []byte("true")}, nil
+}
+
+func (c *Client) UploadFiles(endpoint string, params tgbotapi.Params, files []tgbotapi.RequestFile) (*tgbotapi.APIResponse, error) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    c.raw = append(c.raw, RawRequest{Endpoint: endpoint, Params: params, Files: files})
+    return &tgbotapi.APIResponse{Ok: true, Result: []byte("true")}, nil
+}
+
+// Sent returns every Chattable passed to Send, in order
+func (c *Client) Sent() []tgbotapi.Chattable {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    return append([]tgbotapi.Chattable(nil), c.sent...)
+}
+
+// Messages returns the text messages sent
+func (c *Client) Messages() []tgbotapi.MessageConfig {
+    var msgs []tgbotapi.MessageConfig
+    for _, chattable := range c.Sent() {
+        if msg, ok := chattable.(tgbotapi.MessageConfig); ok {
+            msgs = append(msgs, msg)
+        }
+    }
+    return msgs
+}
+
+// Edits returns the message text edits sent
+func (c *Client) Edits() []tgbotapi.EditMessageTextConfig {
+    var edits []tgbotapi.EditMessageTextConfig
+    for _, chattable := range c.Sent() {
+        if edit, ok := chattable.(tgbotapi.EditMessageTextConfig); ok {
+            edits = append(edits, edit)
+        }
+    }
+    return edits
+}
+
+// Photos returns the photos sent
+func (c *Client) Photos() []tgbotapi.PhotoConfig {
+    var photos []tgbotapi.PhotoConfig
+    for _, chattable := range c.Sent() {
+        if photo, ok := chattable.(tgbotapi.PhotoConfig); ok {
+            photos = append(photos, photo)
+        }
+    }
+    return photos
+}
+
+// Documents returns the documents sent
+func (c *Client) Documents() []tgbotapi.DocumentConfig {
+    var docs []tgbotapi.DocumentConfig
+    for _, chattable := range c.Sent() {
+        if doc, ok := chattable.(tgbotapi.DocumentConfig); ok {
+            docs = append(docs, doc)
+        }
+    }
+    return docs
+}
+
+// CallbackAnswers returns the callback query answers, whether they were
+// delivered through Send or Request
+func (c *Client) CallbackAnswers() []tgbotapi.CallbackConfig {
+    c.mutex.Lock()
+    all := append(append([]tgbotapi.Chattable(nil), c.sent...), c.requested...)
+    c.mutex.Unlock()
+
+    var answers []tgbotapi.CallbackConfig
+    for _, chattable := range all {
+        if answer, ok := chattable.(tgbotapi.CallbackConfig); ok {
+            answers = append(answers, answer)
+        }
+    }
+    return answers
+}
+
+// RawRequests returns the MakeRequest and UploadFiles calls
+func (c *Client) RawRequests() []RawRequest {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    return append([]RawRequest(nil), c.raw...)
+}
+
+// LastMessage returns the most recent text message, or an empty config
+func (c *Client) LastMessage() tgbotapi.MessageConfig {
+    msgs := c.Messages()
+    if len(msgs) == 0 {
+        return tgbotapi.MessageConfig{}
+    }
+    return msgs[len(msgs)-1]
+}
+
+// Reset forgets everything recorded so far
+func (c *Client) Reset() {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    c.sent = nil
+    c.requested = nil
+    c.raw = nil
+}
@@ -0,0 +1,79 @@
+package bottest
+
+import (
+    "strings"
+    "sync/atomic"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var lastUpdateID int64
+
+func nextUpdateID() int {
+    return int(atomic.AddInt64(&lastUpdateID, 1))
+}
+
+// User builds scripted updates from one private chat
+type User struct {
+    ID        int64
+    UserName  string
+    FirstName string
+}
+
+func (u User) from() *tgbotapi.User {
+    return &tgbotapi.User{ID: u.ID, UserName: u.UserName, FirstName: u.FirstName}
+}
+
+func (u User) message(text string) *tgbotapi.Message {
+    return &tgbotapi.Message{
+        MessageID: nextUpdateID(),
+        From:      u.from(),
+        Chat:      &tgbotapi.Chat{ID: u.ID, Type: "private"},
+        Text:      text,
+    }
+}
+
+// Command returns an update carrying a bot command such as "/start" or "/view_data txn X"
+func (u User) Command(text string) tgbotapi.Update {
+    msg := u.message(text)
+    command := strings.Fields(text)[0]
+    msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+    return tgbotapi.Update{UpdateID: nextUpdateID(), Message: msg}
+}
+
+// Text returns an update carrying a plain text message
+func (u User) Text(text string) tgbotapi.Update {
+    return tgbotapi.Update{UpdateID: nextUpdateID(), Message: u.message(text)}
+}
+
+// Photo returns an update carrying a photo with an optional caption
+func (u User) Photo(fileID, caption string) tgbotapi.Update {
+    msg := u.message("")
+    msg.Caption = caption
+    msg.Photo = []tgbotapi.PhotoSize{
+        {FileID: fileID + "-thumb", Width: 90, Height: 160},
+        {FileID: fileID, Width: 720, Height: 1280},
+    }
+    return tgbotapi.Update{UpdateID: nextUpdateID(), Message: msg}
+}
+
+// Document returns an update carrying a file attachment with an optional caption
+func (u User) Document(fileID, mimeType, caption string) tgbotapi.Update {
+    msg := u.message("")
+    msg.Caption = caption
+    msg.Document = &tgbotapi.Document{FileID: fileID, MimeType: mimeType}
+    return tgbotapi.Update{UpdateID: nextUpdateID(), Message: msg}
+}
+
+// Callback returns an update for an inline keyboard button press
+func (u User) Callback(data string) tgbotapi.Update {
+    return tgbotapi.Update{
+        UpdateID: nextUpdateID(),
+        CallbackQuery: &tgbotapi.CallbackQuery{
+            ID:      "cb" + strings.ReplaceAll(data, ":", "_"),
+            From:    u.from(),
+            Message: u.message(""),
+            Data:    data,
+        },
+    }
+}
@@ -0,0 +1,21 @@
+package bot
+
+import (
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Client is the part of the Telegram Bot API used by Bot. It is satisfied
+// by *tgbotapi.BotAPI and by the recording fake in package bottest.
+type Client interface {
+    Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+    Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+    GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+    GetFileDirectURL(fileID string) (string, error)
+}
+
+// rawClient is needed for API parameters the library does not model,
+// such as setWebhook's secret_token.
+type rawClient interface {
+    MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+    UploadFiles(endpoint string, params tgbotapi.Params, files []tgbotapi.RequestFile) (*tgbotapi.APIResponse, error)
+}
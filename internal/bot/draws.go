@@ -0,0 +1,125 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// maxSkippedDraws bounds how many closed scheduled draws entryDraw passes
+// over looking for one that takes entries
+const maxSkippedDraws = 7
+
+// entryDraw returns the draw a new entry for amount joins, opening it if
+// needed. Scheduled prices join the next scheduled draw whose sales are
+// still open; a draw that was closed or drawn early passes its entries on
+// to the one after. Other prices join the oldest open draw for the price.
+func (b *Bot) entryDraw(ctx context.Context, amount float64) (string, error) {
+    b.drawMutex.Lock()
+    defer b.drawMutex.Unlock()
+
+    now := b.scheduler.Now()
+    if at, ok := b.scheduler.EntryDraw(amount); ok {
+        for i := 0; i < maxSkippedDraws && !at.IsZero(); i++ {
+            id := scheduler.DrawID(amount, at)
+            existing, err := b.storage.GetDraw(ctx, id)
+            if err != nil {
+                return id, b.openDraw(ctx, &models.Draw{DrawID: id, TicketAmount: amount, Date: at, OpenedAt: now})
+            }
+            if existing.Status == "open" {
+                return id, nil
+            }
+            at = b.scheduler.DrawAfter(amount, at)
+        }
+        return "", fmt.Errorf("no scheduled ₹%.0f draw is taking entries", amount)
+    }
+
+    open, err := b.storage.ListDraws(ctx, storage.DrawFilter{Status: "open", TicketAmount: amount})
+    if err != nil {
+        return "", err
+    }
+    if len(open) > 0 {
+        return open[0].DrawID, nil
+    }
+
+    id := fmt.Sprintf("DRAW%d", now.UnixNano())
+    return id, b.openDraw(ctx, &models.Draw{DrawID: id, TicketAmount: amount, OpenedAt: now})
+}
+
+// runEngine runs a draw under drawMutex, so no entry joins or is held for
+// a refund while its winners are picked
+func (b *Bot) runEngine(ctx context.Context, req draw.Request) (*draw.Result, error) {
+    b.drawMutex.Lock()
+    defer b.drawMutex.Unlock()
+
+    return b.draws.Run(ctx, req)
+}
+
+// openDraw opens a draw with a fresh secret seed and publishes the seed's
+// hash, committing to it before any entry joins
+func (b *Bot) openDraw(ctx context.Context, d *models.Draw) error {
+    seed, err := draw.NewSeed()
+    if err != nil {
+        return err
+    }
+    d.Seed = seed
+    d.SeedHash = draw.HashSeed(seed)
+
+    if err := b.storage.OpenDraw(ctx, d); err != nil {
+        if errors.Is(err, storage.ErrDuplicateDraw) {
+            return nil
+        }
+        return err
+    }
+
+    b.announceCommitment(d)
+    return nil
+}
+
+// currentDraw returns the oldest draw for amount that has not been drawn
+// yet, or "" when entries are still only grouped by day
+func (b *Bot) currentDraw(ctx context.Context, amount float64) string {
+    draws, err := b.storage.ListDraws(ctx, storage.DrawFilter{TicketAmount: amount})
+    if err != nil {
+        log.Printf("Failed to list draws for ₹%.0f: %v", amount, err)
+        return ""
+    }
+    for _, d := range draws {
+        if !draw.Drawn(d) {
+            return d.DrawID
+        }
+    }
+    return ""
+}
+
+// relinkEntries moves entries whose draw no longer takes entries, e.g.
+// because their payment was verified after the sales cutoff, to the draw
+// now open for their price
+func (b *Bot) relinkEntries(ctx context.Context, entries []*models.LotteryEntry) {
+    moves := make(map[float64][]string)
+    for _, entry := range entries {
+        if entry.DrawID != "" {
+            if d, err := b.storage.GetDraw(ctx, entry.DrawID); err == nil && d.Status == "open" {
+                continue
+            }
+        }
+        moves[entry.TicketAmount] = append(moves[entry.TicketAmount], entry.EntryID)
+    }
+
+    for amount, ids := range moves {
+        drawID, err := b.entryDraw(ctx, amount)
+        if err != nil {
+            log.Printf("Failed to find a draw for ₹%.0f entries: %v", amount, err)
+            continue
+        }
+        if err := b.storage.UpdateEntryDraw(ctx, ids, drawID); err != nil {
+            log.Printf("Failed to move entries to draw %s: %v", drawID, err)
+        }
+    }
+}
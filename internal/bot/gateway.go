@@ -0,0 +1,258 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+)
+
+const (
+    defaultPaymentCallbackPath   = "/payment/callback"
+    defaultPaymentCallbackListen = ":8080"
+)
+
+// errUnderpaid means the gateway received less than the ticket price; the
+// transaction stays pending for an admin to resolve
+var errUnderpaid = errors.New("order underpaid")
+
+// SetPaymentProvider makes ticket purchases go through provider instead of
+// the static QR code and manual review. Call it before Start.
+func (b *Bot) SetPaymentProvider(provider payment.Provider) {
+    b.payments = provider
+}
+
+// startGatewayPayment creates a gateway order for the selected amount and
+// moves the user straight to picking a lucky number; the entry activates
+// once the gateway confirms the payment.
+func (b *Bot) startGatewayPayment(ctx context.Context, callback *tgbotapi.CallbackQuery, amount float64) {
+    chatID := callback.Message.Chat.ID
+    orderID := fmt.Sprintf("ORD%d", time.Now().UnixNano())
+
+    intent, err := b.payments.CreateIntent(ctx, payment.IntentRequest{
+        OrderID:      orderID,
+        Amount:       amount,
+        UserID:       callback.From.ID,
+        CustomerName: callback.From.FirstName,
+        Description:  fmt.Sprintf("%s ticket ₹%.0f", b.cfg().Bot.Name, amount),
+    })
+    if err != nil {
+        log.Printf("Failed to create payment intent: %v", err)
+        b.sendMessage(chatID, "⚠️ पेमेंट लिंक बनाने में समस्या हुई। कृपया कुछ देर बाद पुनः प्रयास करें।")
+        return
+    }
+
+    uniqueCode := newUniqueCode()
+    now := time.Now()
+    txn := &models.Transaction{
+        TransactionID: orderID,
+        UserID:        callback.From.ID,
+        Amount:        amount,
+        Date:          now,
+        Time:          now,
+        Status:        "pending",
+        UniqueCode:    uniqueCode,
+        Provider:      b.payments.Name(),
+        ProviderRef:   intent.ProviderRef,
+    }
+    if err := b.storage.SaveTransaction(ctx, txn); err != nil {
+        log.Printf("Failed to save gateway transaction: %v", err)
+        b.sendMessage(chatID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    state := &models.UserState{
+        UserID:         callback.From.ID,
+        CurrentState:   "awaiting_lucky_number",
+        SelectedAmount: amount,
+        TransactionID:  orderID,
+        UniqueCode:     uniqueCode,
+        LastUpdated:    now,
+    }
+    if err := b.storage.SaveUserState(ctx, state); err != nil {
+        log.Printf("Failed to save user state: %v", err)
+        b.sendMessage(chatID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    text := fmt.Sprintf(
+        "लॉटरी टिकट ₹%.0f के लिए नीचे दिए गए बटन से पेमेंट करें। \n\n"+
+            "Order ID: %s \n"+
+            "आपका Unique Code है: %s \n\n"+
+            "पेमेंट कन्फर्म होते ही आपकी Entry अपने आप active हो जाएगी। तब तक 1 से 100 के बीच कोई भी एक Lucky Number चुनें:",
+        amount,
+        orderID,
+        uniqueCode,
+    )
+
+    var rows [][]tgbotapi.InlineKeyboardButton
+    if intent.PaymentURL != "" {
+        rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+            tgbotapi.NewInlineKeyboardButtonURL(fmt.Sprintf("💳 ₹%.0f पेमेंट करें", amount), intent.PaymentURL),
+        ))
+    }
+    rows = append(rows, b.createInlineKeyboard([][]string{
+        {fmt.Sprintf("✅ मैंने पेमेंट कर दिया|pay_check:%s", orderID)},
+        {"होम|navigation:home"},
+    }).InlineKeyboard...)
+    keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+    if len(intent.QRCode) > 0 {
+        photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: orderID + ".png", Bytes: intent.QRCode})
+        photo.Caption = text
+        photo.ReplyMarkup = keyboard
+        b.api.Send(photo)
+        return
+    }
+    b.sendMessage(chatID, text, keyboard)
+}
+
+// handlePaymentCheck asks the gateway about an order when the buyer says
+// they have paid, in case the webhook was delayed or lost
+func (b *Bot) handlePaymentCheck(ctx context.Context, callback *tgbotapi.CallbackQuery, orderID string) {
+    chatID := callback.Message.Chat.ID
+
+    txn, err := b.storage.GetTransaction(ctx, orderID)
+    if err != nil || txn.UserID != callback.From.ID || b.payments == nil {
+        b.sendMessage(chatID, "⚠️ यह Order नहीं मिला।")
+        return
+    }
+
+    switch txn.Status {
+    case "verified":
+        b.sendMessage(chatID, "✅ आपका पेमेंट पहले ही कन्फर्म हो चुका है।")
+        return
+    case "rejected":
+        b.sendMessage(chatID, "❌ यह पेमेंट असफल रहा। कृपया /start से नया टिकट खरीदें।")
+        return
+    }
+
+    v, err := b.checkGatewayPayment(ctx, orderID)
+    if errors.Is(err, errUnderpaid) {
+        b.sendMessage(chatID, "⚠️ मिली हुई राशि टिकट की कीमत से कम है। Admin आपके पेमेंट की जांच करेंगे।")
+        return
+    }
+    if err != nil {
+        log.Printf("Failed to check payment %s: %v", orderID, err)
+        b.sendMessage(chatID, "⚠️ पेमेंट स्टेटस नहीं मिल सका। कृपया थोड़ी देर बाद पुनः प्रयास करें।")
+        return
+    }
+    if v.Status == payment.StatusCreated {
+        b.sendMessage(chatID, "⏳ पेमेंट अभी तक नहीं मिला। पेमेंट पूरा होने के बाद फिर से बटन दबाएं।")
+    }
+}
+
+// checkGatewayPayment asks the gateway for the order's status and settles
+// the transaction accordingly. The buyer is notified by verifyTransaction
+// or rejectTransaction.
+func (b *Bot) checkGatewayPayment(ctx context.Context, orderID string) (*payment.Verification, error) {
+    v, err := b.payments.Verify(ctx, orderID)
+    if err != nil {
+        return nil, err
+    }
+
+    txn, err := b.storage.GetTransaction(ctx, orderID)
+    if err != nil {
+        return nil, err
+    }
+    if txn.Status != "pending" {
+        return v, nil
+    }
+
+    switch v.Status {
+    case payment.StatusPaid:
+        // Never activate an entry for less than the ticket price
+        if v.Amount+0.005 < txn.Amount {
+            b.NotifyAdmins(fmt.Sprintf("⚠️ Order %s paid ₹%.2f but the ticket costs ₹%.2f. Please review with /pending.",
+                orderID, v.Amount, txn.Amount))
+            return v, errUnderpaid
+        }
+        log.Printf("Gateway confirmed %s (ref %s)", orderID, v.ProviderRef)
+        _, err = b.verifyTransaction(ctx, orderID)
+    case payment.StatusFailed:
+        _, err = b.rejectTransaction(ctx, orderID)
+    }
+    return v, err
+}
+
+// PaymentCallbackHandler returns an http.Handler for gateway webhooks. The
+// request only tells us which order changed; its status is always fetched
+// from the gateway itself.
+func (b *Bot) PaymentCallbackHandler(ctx context.Context) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            w.Header().Set("Allow", http.MethodPost)
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        parser, ok := b.payments.(payment.CallbackParser)
+        if !ok {
+            http.Error(w, "payment callbacks not enabled", http.StatusNotFound)
+            return
+        }
+
+        orderID, err := parser.ParseCallback(r)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        _, err = b.checkGatewayPayment(ctx, orderID)
+        if err != nil && !errors.Is(err, errUnderpaid) {
+            log.Printf("Payment callback for %s failed: %v", orderID, err)
+            if errors.Is(err, payment.ErrUnknownOrder) {
+                http.Error(w, "unknown order", http.StatusNotFound)
+                return
+            }
+            // Let the gateway retry
+            http.Error(w, "verification failed", http.StatusBadGateway)
+            return
+        }
+
+        w.WriteHeader(http.StatusOK)
+    })
+}
+
+func (b *Bot) startPaymentCallbacks(ctx context.Context) error {
+    cfg := b.cfg().Payment.Callback
+
+    path := cfg.Path
+    if path == "" {
+        path = defaultPaymentCallbackPath
+    }
+    listen := cfg.Listen
+    if listen == "" {
+        listen = defaultPaymentCallbackListen
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle(path, b.PaymentCallbackHandler(ctx))
+    server := &http.Server{
+        Addr:              listen,
+        Handler:           mux,
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    errChan := make(chan error, 1)
+    go func() {
+        log.Printf("Listening for payment callbacks on %s%s", listen, path)
+        errChan <- server.ListenAndServe()
+    }()
+
+    select {
+    case err := <-errChan:
+        return fmt.Errorf("payment callback server failed: %v", err)
+    case <-ctx.Done():
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        server.Shutdown(shutdownCtx)
+        return ctx.Err()
+    }
+}
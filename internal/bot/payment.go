@@ -0,0 +1,277 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "regexp"
+    "strconv"
+    "strings"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+// validTransactionID keeps IDs short and free of the separators used in
+// callback data ("payment:approve:<id>" must fit Telegram's 64 byte limit).
+var validTransactionID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,40}$`)
+
+const maxPendingShown = 20
+
+// sendPaymentReview forwards a pending transaction to every admin with
+// Approve/Reject buttons.
+func (b *Bot) sendPaymentReview(ctx context.Context, txn *models.Transaction, uniqueCode string) {
+    text := b.paymentReviewText(ctx, txn, uniqueCode)
+
+    for _, adminID := range b.cfg().Admin.IDs {
+        chatID, err := strconv.ParseInt(adminID, 10, 64)
+        if err != nil {
+            continue
+        }
+        if err := b.sendPaymentReviewCard(chatID, txn, text); err != nil {
+            log.Printf("Failed to send payment review to admin %s: %v", adminID, err)
+        }
+    }
+}
+
+// sendPaymentReviewCard sends one review card, attaching the payment
+// screenshot when the user supplied one
+func (b *Bot) sendPaymentReviewCard(chatID int64, txn *models.Transaction, text string) error {
+    keyboard := b.paymentReviewKeyboard(txn.TransactionID)
+
+    var err error
+    switch txn.ScreenshotType {
+    case "photo":
+        photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(txn.ScreenshotFileID))
+        photo.Caption = text
+        photo.ReplyMarkup = keyboard
+        _, err = b.api.Send(photo)
+    case "document":
+        doc := tgbotapi.NewDocument(chatID, tgbotapi.FileID(txn.ScreenshotFileID))
+        doc.Caption = text
+        doc.ReplyMarkup = keyboard
+        _, err = b.api.Send(doc)
+    default:
+        _, err = b.sendMessage(chatID, text, keyboard)
+    }
+    return err
+}
+
+func (b *Bot) paymentReviewText(ctx context.Context, txn *models.Transaction, uniqueCode string) string {
+    username := "-"
+    if user, err := b.storage.GetUser(ctx, txn.UserID); err == nil && user.Username != "" {
+        username = "@" + user.Username
+    }
+
+    if uniqueCode == "" {
+        if entries, err := b.storage.GetEntriesByTransaction(ctx, txn.TransactionID); err == nil && len(entries) > 0 {
+            uniqueCode = entries[0].UniqueCode
+        }
+    }
+
+    screenshot := "attached"
+    if txn.ScreenshotFileID == "" {
+        screenshot = "not sent"
+    }
+    if txn.Provider != "" {
+        screenshot = fmt.Sprintf("none (%s order %s)", txn.Provider, txn.ProviderRef)
+    }
+
+    return fmt.Sprintf(
+        "🧾 Payment Verification\n\n"+
+            "Transaction ID: %s\n"+
+            "User: %s (%d)\n"+
+            "Amount: ₹%.2f\n"+
+            "Unique Code: %s\n"+
+            "Screenshot: %s\n"+
+            "Submitted: %s",
+        txn.TransactionID,
+        username,
+        txn.UserID,
+        txn.Amount,
+        uniqueCode,
+        screenshot,
+        txn.Time.Format("2006-01-02 15:04:05"),
+    )
+}
+
+func (b *Bot) paymentReviewKeyboard(txnID string) tgbotapi.InlineKeyboardMarkup {
+    return b.createInlineKeyboard([][]string{
+        {
+            fmt.Sprintf("✅ Approve|payment:approve:%s", txnID),
+            fmt.Sprintf("❌ Reject|payment:reject:%s", txnID),
+        },
+    })
+}
+
+func (b *Bot) handlePaymentReview(ctx context.Context, callback *tgbotapi.CallbackQuery, data string) {
+    if !b.isAdmin(callback.From.ID) {
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    parts := strings.SplitN(data, ":", 2)
+    if len(parts) != 2 {
+        return
+    }
+    decision, txnID := parts[0], parts[1]
+
+    var txn *models.Transaction
+    var err error
+    switch decision {
+    case "approve":
+        txn, err = b.verifyTransaction(ctx, txnID)
+    case "reject":
+        txn, err = b.rejectTransaction(ctx, txnID)
+    default:
+        return
+    }
+
+    // Drop the buttons so the same card cannot be processed twice
+    clear := tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+        tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+    b.api.Send(clear)
+
+    if err != nil {
+        b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf("⚠️ %s: %v", txnID, err))
+        return
+    }
+
+    b.recordAdminAction(ctx, callback.From.ID, "payment_"+decision, map[string]interface{}{
+        "transaction_id": txn.TransactionID,
+        "user_id":        txn.UserID,
+        "amount":         txn.Amount,
+    })
+
+    if decision == "approve" {
+        b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf("✅ %s approved", txnID))
+    } else {
+        b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf("❌ %s rejected", txnID))
+    }
+}
+
+// verifyTransaction marks a pending transaction verified, activates its
+// entries and tells the buyer. Only a buyer whose entry was activated is
+// told it is active; one still picking a lucky number is asked to finish.
+func (b *Bot) verifyTransaction(ctx context.Context, txnID string) (*models.Transaction, error) {
+    txn, activated, err := b.settleTransaction(ctx, txnID, "verified", "active")
+    if err != nil {
+        return nil, err
+    }
+
+    if activated == 0 {
+        msg := fmt.Sprintf("✅ पेमेंट कन्फर्म! आपका Transaction ID %s वेरिफाई हो गया है।", txn.TransactionID)
+        if state, err := b.storage.GetUserState(ctx, txn.UserID); err == nil &&
+            state.CurrentState == "awaiting_lucky_number" && state.TransactionID == txn.TransactionID {
+            msg += "\n\nEntry पूरी करने के लिए 1 से 100 के बीच अपना Lucky Number भेजें।"
+        }
+        b.sendMessage(txn.UserID, msg)
+        return txn, nil
+    }
+
+    b.sendMessage(txn.UserID, fmt.Sprintf(
+        "✅ पेमेंट कन्फर्म! आपका Transaction ID %s वेरिफाई हो गया है और आपकी Lottery Entry अब active है।\n\n"+
+            "शुभकामनाएं!",
+        txn.TransactionID,
+    ))
+    return txn, nil
+}
+
+// rejectTransaction marks a pending transaction rejected along with its
+// entries and tells the buyer.
+func (b *Bot) rejectTransaction(ctx context.Context, txnID string) (*models.Transaction, error) {
+    txn, _, err := b.settleTransaction(ctx, txnID, "rejected", "rejected")
+    if err != nil {
+        return nil, err
+    }
+
+    b.sendMessage(txn.UserID, fmt.Sprintf(
+        "❌ माफ़ करना! आपका Transaction ID %s वेरिफाई नहीं हो सका, इसलिए आपकी Entry रद्द कर दी गई है।\n\n"+
+            "किसी भी समस्या के लिए, %s पर Lottery Win चैनल से संपर्क करें।",
+        txn.TransactionID,
+        b.cfg().Channels.LotteryWin,
+    ))
+    return txn, nil
+}
+
+// settleTransaction moves a pending transaction to txnStatus and its
+// pending entries to entryStatus, returning how many entries it moved
+func (b *Bot) settleTransaction(ctx context.Context, txnID, txnStatus, entryStatus string) (*models.Transaction, int, error) {
+    // Two admins tapping the same card must not both settle it
+    b.paymentMutex.Lock()
+    defer b.paymentMutex.Unlock()
+
+    txn, err := b.storage.GetTransaction(ctx, txnID)
+    if err != nil {
+        return nil, 0, fmt.Errorf("transaction not found")
+    }
+    if txn.Status != "pending" {
+        return nil, 0, fmt.Errorf("transaction is already %s", txn.Status)
+    }
+
+    if err := b.storage.UpdateTransactionStatus(ctx, txnID, txnStatus); err != nil {
+        log.Printf("Failed to update transaction %s: %v", txnID, err)
+        return nil, 0, fmt.Errorf("failed to update transaction")
+    }
+    txn.Status = txnStatus
+
+    entries, err := b.storage.GetEntriesByTransaction(ctx, txnID)
+    if err != nil {
+        log.Printf("Failed to get entries for %s: %v", txnID, err)
+        return txn, 0, nil
+    }
+
+    var ids []string
+    var settled []*models.LotteryEntry
+    for _, entry := range entries {
+        if entry.Status == "pending" {
+            ids = append(ids, entry.EntryID)
+            settled = append(settled, entry)
+        }
+    }
+    if len(ids) > 0 {
+        if err := b.storage.UpdateEntryStatus(ctx, ids, entryStatus); err != nil {
+            log.Printf("Failed to update entries for %s: %v", txnID, err)
+            return txn, 0, nil
+        }
+    }
+    if entryStatus == "active" {
+        b.relinkEntries(ctx, settled)
+    }
+
+    return txn, len(settled), nil
+}
+
+func (b *Bot) handlePendingCommand(ctx context.Context, message *tgbotapi.Message) {
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "pending", map[string]interface{}{})
+
+    txns, err := b.storage.GetTransactionsByStatus(ctx, "pending")
+    if err != nil {
+        log.Printf("Failed to get pending transactions: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ डेटा प्राप्त करने में त्रुटि हुई")
+        return
+    }
+
+    if len(txns) == 0 {
+        b.sendMessage(message.Chat.ID, "✅ कोई pending payment नहीं है")
+        return
+    }
+
+    msg := fmt.Sprintf("⏳ %d pending payments", len(txns))
+    if len(txns) > maxPendingShown {
+        msg += fmt.Sprintf(" (showing oldest %d)", maxPendingShown)
+        txns = txns[:maxPendingShown]
+    }
+    b.sendMessage(message.Chat.ID, msg)
+
+    for _, txn := range txns {
+        if err := b.sendPaymentReviewCard(message.Chat.ID, txn, b.paymentReviewText(ctx, txn, "")); err != nil {
+            log.Printf("Failed to send payment review for %s: %v", txn.TransactionID, err)
+        }
+    }
+}
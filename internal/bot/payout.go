@@ -0,0 +1,200 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+const payoutsHelp = "Usage: /payouts pending"
+
+// notifyWinners congratulates every winner of a draw
+func (b *Bot) notifyWinners(result *draw.Result) {
+    for i, entry := range result.WinningEntries {
+        b.sendMessage(entry.UserID, fmt.Sprintf(
+            "🎉 बधाई हो! आपकी Lottery Entry जीत गई है!\n\n"+
+                "Unique Code: %s\n"+
+                "Lucky Number: %d\n"+
+                "इनाम: ₹%.2f\n\n"+
+                "आपका इनाम जल्द ही भेज दिया जाएगा, भेजते ही आपको सूचना मिलेगी।",
+            entry.UniqueCode,
+            entry.LuckyNumber,
+            result.Winners[i].WinningAmount,
+        ))
+    }
+}
+
+// sendPayoutChecklist gives the admin one "mark paid" button per winner
+func (b *Bot) sendPayoutChecklist(chatID int64, winners []*models.Winner) {
+    if len(winners) == 0 {
+        return
+    }
+
+    var buttons [][]string
+    for i, winner := range winners {
+        buttons = append(buttons, []string{
+            fmt.Sprintf("💸 %d. User %d - ₹%.2f|payout:%s", i+1, winner.UserID, winner.WinningAmount, winner.WinnerID),
+        })
+    }
+
+    b.sendMessage(chatID, "💸 Payout checklist\n\nWinner को पैसे भेजने के बाद उसका button दबाएं और payout reference भेजें।",
+        b.createInlineKeyboard(buttons))
+}
+
+func (b *Bot) handlePayoutSelection(ctx context.Context, callback *tgbotapi.CallbackQuery, winnerID string) {
+    if !b.isAdmin(callback.From.ID) {
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    winner, err := b.storage.GetWinner(ctx, winnerID)
+    if err != nil {
+        b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf("⚠️ %s: winner not found", winnerID))
+        return
+    }
+    if winner.PaymentStatus == "completed" {
+        b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf("✅ User %d का payout पहले ही हो चुका है (ref %s)",
+            winner.UserID, winner.PaymentTransactionID))
+        return
+    }
+
+    state := &models.UserState{
+        UserID:       callback.From.ID,
+        CurrentState: "awaiting_payout_ref",
+        WinnerID:     winner.WinnerID,
+        LastUpdated:  time.Now(),
+    }
+    if err := b.storage.SaveUserState(ctx, state); err != nil {
+        log.Printf("Failed to save admin state: %v", err)
+        b.sendMessage(callback.Message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    b.sendMessage(callback.Message.Chat.ID, fmt.Sprintf(
+        "User %d को ₹%.2f के payout का reference (UPI/bank UTR) भेजें:",
+        winner.UserID,
+        winner.WinningAmount,
+    ))
+}
+
+func (b *Bot) handlePayoutReference(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
+    if !b.isAdmin(message.From.ID) {
+        b.storage.DeleteUserState(ctx, message.From.ID)
+        return
+    }
+
+    ref := strings.TrimSpace(message.Text)
+    if !validTransactionID.MatchString(ref) {
+        b.sendMessage(message.Chat.ID, "⚠️ Invalid reference. कृपया सही UPI/bank reference भेजें।")
+        return
+    }
+
+    b.storage.DeleteUserState(ctx, message.From.ID)
+
+    winner, err := b.completePayout(ctx, state.WinnerID, ref)
+    if err != nil {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("⚠️ %s: %v", state.WinnerID, err))
+        return
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "payout", map[string]interface{}{
+        "winner_id": winner.WinnerID,
+        "user_id":   winner.UserID,
+        "amount":    winner.WinningAmount,
+        "reference": ref,
+    })
+
+    b.sendMessage(winner.UserID, fmt.Sprintf(
+        "💸 आपका ₹%.2f का इनाम भेज दिया गया है!\n\n"+
+            "Payout Reference: %s\n\n"+
+            "किसी भी समस्या के लिए, %s पर Lottery Win चैनल से संपर्क करें।",
+        winner.WinningAmount,
+        ref,
+        b.cfg().Channels.LotteryWin,
+    ))
+
+    b.sendMessage(message.Chat.ID, fmt.Sprintf("✅ Payout to user %d marked completed (ref %s)", winner.UserID, ref))
+}
+
+func (b *Bot) completePayout(ctx context.Context, winnerID, ref string) (*models.Winner, error) {
+    // Two admins confirming the same winner must not both record a payout
+    b.paymentMutex.Lock()
+    defer b.paymentMutex.Unlock()
+
+    winner, err := b.storage.GetWinner(ctx, winnerID)
+    if err != nil {
+        return nil, fmt.Errorf("winner not found")
+    }
+    if winner.PaymentStatus == "completed" {
+        return nil, fmt.Errorf("payout is already completed (ref %s)", winner.PaymentTransactionID)
+    }
+
+    if err := b.storage.UpdateWinnerPaymentStatus(ctx, winnerID, "completed", ref); err != nil {
+        log.Printf("Failed to update payout for %s: %v", winnerID, err)
+        return nil, fmt.Errorf("failed to update winner")
+    }
+    winner.PaymentStatus = "completed"
+    winner.PaymentTransactionID = ref
+
+    return winner, nil
+}
+
+func (b *Bot) handlePayoutsCommand(ctx context.Context, message *tgbotapi.Message) {
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    args := strings.Fields(message.CommandArguments())
+    if len(args) > 1 || (len(args) == 1 && args[0] != "pending") {
+        b.sendMessage(message.Chat.ID, payoutsHelp)
+        return
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "payouts", map[string]interface{}{
+        "args": args,
+    })
+
+    winners, err := b.storage.GetWinnersByPaymentStatus(ctx, "pending")
+    if err != nil {
+        log.Printf("Failed to get pending payouts: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ डेटा प्राप्त करने में त्रुटि हुई")
+        return
+    }
+
+    if len(winners) == 0 {
+        b.sendMessage(message.Chat.ID, "✅ कोई pending payout नहीं है")
+        return
+    }
+
+    var total float64
+    for _, winner := range winners {
+        total += winner.WinningAmount
+    }
+
+    msg := fmt.Sprintf("💸 %d pending payouts, total ₹%.2f\n\n", len(winners), total)
+    shown := winners
+    if len(shown) > maxPendingShown {
+        shown = shown[:maxPendingShown]
+    }
+    for i, winner := range shown {
+        username := "-"
+        if user, err := b.storage.GetUser(ctx, winner.UserID); err == nil && user.Username != "" {
+            username = "@" + user.Username
+        }
+        msg += fmt.Sprintf("%d. User %d (%s) - ₹%.2f - won %s\n",
+            i+1, winner.UserID, username, winner.WinningAmount, winner.Date.Format("2006-01-02"))
+    }
+    if len(winners) > len(shown) {
+        msg += fmt.Sprintf("\n(showing oldest %d)", len(shown))
+    }
+
+    b.sendMessage(message.Chat.ID, msg)
+    b.sendPayoutChecklist(message.Chat.ID, shown)
+}
@@ -0,0 +1,164 @@
+package bot
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/reconcile"
+)
+
+const maxStatementSize = 5 << 20
+
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+func (b *Bot) handleReconcileCommand(ctx context.Context, message *tgbotapi.Message) {
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    state := &models.UserState{
+        UserID:       message.From.ID,
+        CurrentState: "awaiting_statement",
+        LastUpdated:  time.Now(),
+    }
+    if err := b.storage.SaveUserState(ctx, state); err != nil {
+        log.Printf("Failed to save user state: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    b.sendMessage(message.Chat.ID, "📄 Bank/UPI statement की CSV फ़ाइल document के रूप में भेजें।\n\n"+
+        "Pending payments को Transaction ID, UPI reference या Unique Code और amount से match किया जाएगा।")
+}
+
+// handleStatementUpload reconciles an uploaded statement against pending
+// transactions, verifies exact matches and replies with a CSV report
+func (b *Bot) handleStatementUpload(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
+    b.storage.DeleteUserState(ctx, message.From.ID)
+
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    doc := message.Document
+    if doc == nil {
+        b.sendMessage(message.Chat.ID, "⚠️ CSV फ़ाइल नहीं मिली, reconcile रद्द किया गया। फिर से /reconcile भेजें।")
+        return
+    }
+    if doc.FileSize > maxStatementSize {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("⚠️ Statement बहुत बड़ा है (max %d MB)।", maxStatementSize>>20))
+        return
+    }
+
+    data, err := b.downloadFile(doc.FileID, maxStatementSize)
+    if err != nil {
+        log.Printf("Failed to download statement: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ फ़ाइल डाउनलोड करने में त्रुटि हुई")
+        return
+    }
+
+    cfg := b.cfg().Payment.Statement
+    rows, skipped, err := reconcile.Parse(bytes.NewReader(data), reconcile.Columns{
+        Reference: cfg.ReferenceColumns,
+        Amount:    cfg.AmountColumns,
+        Note:      cfg.NoteColumns,
+    })
+    if err != nil {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("⚠️ Statement पढ़ा नहीं जा सका: %v", err))
+        return
+    }
+
+    pending, err := b.storage.GetTransactionsByStatus(ctx, "pending")
+    if err != nil {
+        log.Printf("Failed to get pending transactions: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ डेटा प्राप्त करने में त्रुटि हुई")
+        return
+    }
+
+    result := reconcile.Reconcile(rows, pending)
+    result.Skipped = skipped
+
+    verified := 0
+    for _, match := range result.Matched {
+        if _, err := b.verifyTransaction(ctx, match.Transaction.TransactionID); err != nil {
+            match.Status = reconcile.StatusSettleFail
+            match.Error = err.Error()
+            continue
+        }
+        verified++
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "reconcile", map[string]interface{}{
+        "file":             doc.FileName,
+        "rows":             len(rows),
+        "verified":         verified,
+        "mismatched":       len(result.Mismatched),
+        "unmatched":        len(result.Unmatched),
+        "not_in_statement": len(result.NotPaid),
+    })
+
+    var report bytes.Buffer
+    if err := result.WriteReport(&report); err != nil {
+        log.Printf("Failed to write reconcile report: %v", err)
+    }
+
+    summary := fmt.Sprintf(
+        "🧮 Reconciliation complete\n\n"+
+            "Statement credits: %d\n"+
+            "✅ Verified: %d\n"+
+            "⚠️ Amount mismatch: %d\n"+
+            "❓ Unmatched rows: %d\n"+
+            "⏳ Pending, not in statement: %d",
+        len(rows),
+        verified,
+        len(result.Mismatched),
+        len(result.Unmatched),
+        len(result.NotPaid),
+    )
+    b.sendMessage(message.Chat.ID, summary)
+
+    reportDoc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{
+        Name:  fmt.Sprintf("reconcile-%s.csv", time.Now().Format("20060102-150405")),
+        Bytes: report.Bytes(),
+    })
+    if _, err := b.api.Send(reportDoc); err != nil {
+        log.Printf("Failed to send file: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ फ़ाइल भेजने में त्रुटि हुई")
+    }
+}
+
+// downloadFile fetches an uploaded Telegram file, refusing anything over limit bytes
+func (b *Bot) downloadFile(fileID string, limit int64) ([]byte, error) {
+    url, err := b.api.GetFileDirectURL(fileID)
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := downloadClient.Get(url)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+    }
+
+    data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+    if err != nil {
+        return nil, err
+    }
+    if int64(len(data)) > limit {
+        return nil, fmt.Errorf("file larger than %d bytes", limit)
+    }
+    return data, nil
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+)
+
+const refundHelp = "Usage: /refund <transaction_id> [refund_ref]\n\n" +
+    "Manual payments के लिए bank/UPI refund reference ज़रूरी है। " +
+    "Gateway payments बिना reference के gateway से refund हो जाते हैं।"
+
+func (b *Bot) handleRefundCommand(ctx context.Context, message *tgbotapi.Message) {
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    args := strings.Fields(message.CommandArguments())
+    if len(args) == 0 || len(args) > 2 {
+        b.sendMessage(message.Chat.ID, refundHelp)
+        return
+    }
+    txnID, refundRef := args[0], ""
+    if len(args) == 2 {
+        refundRef = args[1]
+    }
+
+    txn, cancelled, err := b.refundTransaction(ctx, txnID, refundRef)
+    if err != nil {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("⚠️ %s: %v", txnID, err))
+        return
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "refund", map[string]interface{}{
+        "transaction_id": txn.TransactionID,
+        "user_id":        txn.UserID,
+        "amount":         txn.Amount,
+        "refund_ref":     txn.RefundRef,
+        "entries":        cancelled,
+    })
+
+    b.sendMessage(txn.UserID, fmt.Sprintf(
+        "↩️ आपके Transaction ID %s का ₹%.2f refund कर दिया गया है।\n\n"+
+            "Refund Reference: %s\n\n"+
+            "इससे जुड़ी Lottery Entry रद्द कर दी गई है और किसी भी draw में शामिल नहीं होगी।",
+        txn.TransactionID,
+        txn.Amount,
+        txn.RefundRef,
+    ))
+
+    b.sendMessage(message.Chat.ID, fmt.Sprintf("↩️ %s refunded (ref %s), %d entries cancelled",
+        txn.TransactionID, txn.RefundRef, len(cancelled)))
+}
+
+// refundPending marks entries whose refund is in flight; draws only pick
+// active entries, so they are left out until the refund settles
+const refundPending = "refund_pending"
+
+// refundTransaction refunds a transaction through its gateway when no
+// reference is given, then marks it and its entries refunded. Only pending
+// or active entries of a draw that has not been drawn can be refunded.
+// paymentMutex is held throughout so the transaction is not settled or
+// refunded twice meanwhile; drawMutex only while the entries are set aside,
+// so a draw can run during the gateway call without picking them.
+func (b *Bot) refundTransaction(ctx context.Context, txnID, refundRef string) (*models.Transaction, []string, error) {
+    b.paymentMutex.Lock()
+    defer b.paymentMutex.Unlock()
+
+    txn, err := b.storage.GetTransaction(ctx, txnID)
+    if err != nil {
+        return nil, nil, fmt.Errorf("transaction not found")
+    }
+    if txn.Status == "refunded" {
+        return nil, nil, fmt.Errorf("transaction is already refunded (ref %s)", txn.RefundRef)
+    }
+
+    if refundRef == "" {
+        if txn.Provider == "" {
+            return nil, nil, fmt.Errorf("refund reference required for manual payments")
+        }
+        if b.payments == nil || b.payments.Name() != txn.Provider {
+            return nil, nil, fmt.Errorf("%s is not configured, pass the refund reference", txn.Provider)
+        }
+    }
+
+    entries, err := b.holdForRefund(ctx, txnID)
+    if err != nil {
+        return nil, nil, err
+    }
+    var ids []string
+    for _, entry := range entries {
+        ids = append(ids, entry.EntryID)
+    }
+
+    if refundRef == "" {
+        refund, err := b.payments.Refund(ctx, txn.TransactionID, txn.Amount)
+        if err != nil {
+            b.releaseFromRefund(ctx, entries)
+            if errors.Is(err, payment.ErrNotSupported) {
+                return nil, nil, fmt.Errorf("%s does not support refunds, pass the refund reference", txn.Provider)
+            }
+            log.Printf("Gateway refund for %s failed: %v", txnID, err)
+            return nil, nil, fmt.Errorf("gateway refund failed: %v", err)
+        }
+        refundRef = refund.RefundID
+    }
+
+    now := time.Now()
+    if err := b.storage.RefundTransaction(ctx, txnID, refundRef, now); err != nil {
+        log.Printf("Failed to mark %s refunded (ref %s): %v", txnID, refundRef, err)
+        return nil, nil, fmt.Errorf("refund %s done but failed to update transaction", refundRef)
+    }
+    txn.Status = "refunded"
+    txn.RefundRef = refundRef
+    txn.RefundedAt = now
+
+    if len(ids) > 0 {
+        if err := b.storage.UpdateEntryStatus(ctx, ids, "refunded"); err != nil {
+            log.Printf("Failed to mark entries for %s refunded: %v", txnID, err)
+            return nil, nil, fmt.Errorf("transaction refunded but entries were not updated")
+        }
+    }
+
+    return txn, ids, nil
+}
+
+// holdForRefund checks that every entry of a transaction can still be
+// refunded and marks them refund_pending, under drawMutex so no draw runs
+// in between. The entries are returned with their previous statuses.
+func (b *Bot) holdForRefund(ctx context.Context, txnID string) ([]*models.LotteryEntry, error) {
+    b.drawMutex.Lock()
+    defer b.drawMutex.Unlock()
+
+    entries, err := b.storage.GetEntriesByTransaction(ctx, txnID)
+    if err != nil {
+        log.Printf("Failed to get entries for %s: %v", txnID, err)
+        return nil, fmt.Errorf("failed to load entries")
+    }
+
+    var ids []string
+    for _, entry := range entries {
+        switch entry.Status {
+        case "pending", "active":
+        case "winner":
+            return nil, fmt.Errorf("entry %s has already won a draw", entry.UniqueCode)
+        default:
+            return nil, fmt.Errorf("entry %s is %s", entry.UniqueCode, entry.Status)
+        }
+        if entry.DrawID != "" {
+            if d, err := b.storage.GetDraw(ctx, entry.DrawID); err == nil && draw.Drawn(d) {
+                return nil, fmt.Errorf("entry %s was in draw %s, which has already been drawn", entry.UniqueCode, d.DrawID)
+            }
+        }
+        ids = append(ids, entry.EntryID)
+    }
+
+    if len(ids) > 0 {
+        if err := b.storage.UpdateEntryStatus(ctx, ids, refundPending); err != nil {
+            log.Printf("Failed to hold entries for %s: %v", txnID, err)
+            return nil, fmt.Errorf("failed to update entries")
+        }
+    }
+    return entries, nil
+}
+
+// releaseFromRefund gives entries held by holdForRefund their previous
+// statuses back after a failed refund. Active entries whose draw was drawn
+// in the meantime missed it, so they move on to the next draw.
+func (b *Bot) releaseFromRefund(ctx context.Context, entries []*models.LotteryEntry) {
+    var missed []*models.LotteryEntry
+    func() {
+        b.drawMutex.Lock()
+        defer b.drawMutex.Unlock()
+
+        byStatus := make(map[string][]string)
+        for _, entry := range entries {
+            byStatus[entry.Status] = append(byStatus[entry.Status], entry.EntryID)
+            if entry.Status != "active" || entry.DrawID == "" {
+                continue
+            }
+            if d, err := b.storage.GetDraw(ctx, entry.DrawID); err == nil && draw.Drawn(d) {
+                missed = append(missed, entry)
+            }
+        }
+        for status, ids := range byStatus {
+            if err := b.storage.UpdateEntryStatus(ctx, ids, status); err != nil {
+                log.Printf("Failed to restore %d entries to %s: %v", len(ids), status, err)
+            }
+        }
+    }()
+
+    // relinkEntries takes drawMutex itself
+    b.relinkEntries(ctx, missed)
+}
@@ -0,0 +1,123 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strconv"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/config"
+    "github.com/gsshankar104/telegram-bot/internal/cron"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler"
+)
+
+// SetClock rebuilds the draw scheduler from the configured schedules on
+// clock. Call it before Start; tests use it to control time.
+func (b *Bot) SetClock(clock scheduler.Clock) {
+    schedules, err := drawSchedules(b.cfg().Tickets.Schedules)
+    if err != nil {
+        log.Printf("Draw schedules disabled: %v", err)
+        schedules = nil
+    }
+    b.scheduler = scheduler.New(schedules, b.storage, b.runScheduledDraw, clock)
+}
+
+func drawSchedules(configs []config.DrawSchedule) ([]scheduler.Schedule, error) {
+    var schedules []scheduler.Schedule
+    for _, c := range configs {
+        expr, err := cron.Parse(c.Cron)
+        if err != nil {
+            return nil, err
+        }
+
+        location := time.Local
+        if c.TimeZone != "" {
+            if location, err = time.LoadLocation(c.TimeZone); err != nil {
+                return nil, err
+            }
+        }
+
+        method := c.Method
+        if method == "" {
+            method = draw.MethodRandom
+        }
+        winners := c.WinnerCount
+        if winners == 0 {
+            winners = 1
+        }
+
+        schedules = append(schedules, scheduler.Schedule{
+            Price:       c.Price,
+            Cron:        expr,
+            Location:    location,
+            Method:      method,
+            WinnerCount: winners,
+            SalesCutoff: c.SalesCutoff,
+        })
+    }
+    return schedules, nil
+}
+
+// nextDrawText describes when the draw for amount happens, for user messages
+func (b *Bot) nextDrawText(amount float64) string {
+    at, ok := b.scheduler.NextDraw(amount)
+    if !ok {
+        return "draw होने के बाद"
+    }
+    return at.Format("02 Jan 2006, 03:04 PM MST") + " पर"
+}
+
+// entryDrawText describes when the draw an entry joined happens, which is
+// later than the next draw once that draw's sales have closed
+func (b *Bot) entryDrawText(ctx context.Context, drawID string, amount float64) string {
+    d, err := b.storage.GetDraw(ctx, drawID)
+    if err != nil || d.Date.IsZero() {
+        return "draw होने के बाद"
+    }
+    at := d.Date
+    if next, ok := b.scheduler.NextDraw(amount); ok {
+        at = at.In(next.Location())
+    }
+    return at.Format("02 Jan 2006, 03:04 PM MST") + " पर"
+}
+
+// runScheduledDraw performs a draw that the scheduler found due and
+// reports it to the admins
+func (b *Bot) runScheduledDraw(ctx context.Context, job scheduler.Job) error {
+    req := draw.Request{
+        DrawID:      job.DrawID,
+        Date:        job.At,
+        Amount:      job.Price,
+        Method:      job.Method,
+        WinnerCount: job.WinnerCount,
+    }
+
+    result, err := b.runEngine(ctx, req)
+    switch {
+    case err == draw.ErrDrawExists:
+        return nil
+    case err == draw.ErrNoEntries:
+        // The engine records the empty draw so it is not retried
+        b.NotifyAdmins(fmt.Sprintf("ℹ️ Scheduled ₹%.0f draw (%s): no active entries", job.Price, job.DrawID))
+        return nil
+    case err != nil:
+        b.NotifyAdmins(fmt.Sprintf("⚠️ Scheduled ₹%.0f draw (%s) failed: %v", job.Price, job.DrawID, err))
+        return err
+    }
+
+    msg := "⏰ Scheduled draw\n\n" + drawSummary(result)
+    if b.announceDraw(ctx, result) {
+        msg += "\n📢 Result posted to the proof channel"
+    }
+    b.NotifyAdmins(msg)
+    b.notifyWinners(result)
+
+    for _, adminID := range b.cfg().Admin.IDs {
+        if chatID, err := strconv.ParseInt(adminID, 10, 64); err == nil {
+            b.sendPayoutChecklist(chatID, result.Winners)
+        }
+    }
+    return nil
+}
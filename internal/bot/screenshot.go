@@ -0,0 +1,106 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+func hasScreenshot(message *tgbotapi.Message) bool {
+    return len(message.Photo) > 0 || message.Document != nil
+}
+
+// screenshotFile returns the Telegram file ID and kind of a payment
+// screenshot, or ok=false if the attachment is not an image or PDF
+func screenshotFile(message *tgbotapi.Message) (fileID, kind string, ok bool) {
+    if len(message.Photo) > 0 {
+        // Sizes are sent smallest first
+        return message.Photo[len(message.Photo)-1].FileID, "photo", true
+    }
+
+    doc := message.Document
+    if doc != nil && (strings.HasPrefix(doc.MimeType, "image/") || doc.MimeType == "application/pdf") {
+        return doc.FileID, "document", true
+    }
+    return "", "", false
+}
+
+// handleScreenshotSubmission stores a payment screenshot against the user's
+// pending purchase. A caption is treated as the Transaction ID, so users can
+// send both in one message. Once the Transaction ID is in, the screenshot is
+// attached to the transaction instead.
+func (b *Bot) handleScreenshotSubmission(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
+    if state.CurrentState != "awaiting_transaction_id" {
+        b.attachScreenshot(ctx, message, state)
+        return
+    }
+
+    fileID, kind, ok := screenshotFile(message)
+    if !ok {
+        b.sendMessage(message.Chat.ID, "⚠️ कृपया पेमेंट का स्क्रीनशॉट फोटो या PDF के रूप में भेजें।")
+        return
+    }
+
+    state.ScreenshotFileID = fileID
+    state.ScreenshotType = kind
+    state.LastUpdated = time.Now()
+
+    if err := b.storage.SaveUserState(ctx, state); err != nil {
+        log.Printf("Failed to save screenshot to user state: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+
+    if caption := strings.TrimSpace(message.Caption); caption != "" {
+        text := *message
+        text.Text = caption
+        b.handleTransactionIDSubmission(ctx, &text, state)
+        return
+    }
+
+    b.sendMessage(message.Chat.ID, "📸 स्क्रीनशॉट मिल गया! अब अपनी Transaction ID भेजें।")
+}
+
+// attachScreenshot adds a screenshot sent after the Transaction ID to the
+// manual payment while it awaits review, and sends the admins a new review
+// card carrying it
+func (b *Bot) attachScreenshot(ctx context.Context, message *tgbotapi.Message, state *models.UserState) {
+    // Hold paymentMutex so the payment is not settled while it changes
+    b.paymentMutex.Lock()
+    txn, err := b.storage.GetTransaction(ctx, state.TransactionID)
+    if state.TransactionID == "" || err != nil || txn.Status != "pending" || txn.Provider != "" {
+        b.paymentMutex.Unlock()
+        b.sendMessage(message.Chat.ID, "⚠️ स्क्रीनशॉट सिर्फ़ पेमेंट करते समय भेजें। नया टिकट खरीदने के लिए /start कमांड का उपयोग करें।")
+        return
+    }
+
+    fileID, kind, ok := screenshotFile(message)
+    if !ok {
+        b.paymentMutex.Unlock()
+        b.sendMessage(message.Chat.ID, "⚠️ कृपया पेमेंट का स्क्रीनशॉट फोटो या PDF के रूप में भेजें।")
+        return
+    }
+
+    err = b.storage.SetTransactionScreenshot(ctx, txn.TransactionID, fileID, kind)
+    b.paymentMutex.Unlock()
+    if err != nil {
+        log.Printf("Failed to attach screenshot to %s: %v", txn.TransactionID, err)
+        b.sendMessage(message.Chat.ID, "⚠️ कुछ गड़बड़ी हुई। कृपया पुनः प्रयास करें।")
+        return
+    }
+    txn.ScreenshotFileID = fileID
+    txn.ScreenshotType = kind
+
+    b.sendPaymentReview(ctx, txn, state.UniqueCode)
+
+    reply := fmt.Sprintf("📸 स्क्रीनशॉट मिल गया! इसे आपके Transaction ID %s के साथ जोड़ दिया गया है।", txn.TransactionID)
+    if state.CurrentState == "awaiting_lucky_number" {
+        reply += "\n\nअब 1 से 100 के बीच अपना Lucky Number चुनें।"
+    }
+    b.sendMessage(message.Chat.ID, reply)
+}
@@ -0,0 +1,34 @@
+package bot
+
+import (
+    "context"
+    "fmt"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// handleStatsCommand shows admins how the bot's storage is performing
+func (b *Bot) handleStatsCommand(ctx context.Context, message *tgbotapi.Message) {
+    if !b.isAdmin(message.From.ID) {
+        b.sendMessage(message.Chat.ID, "⚠️ आप Admin नहीं हैं!")
+        return
+    }
+
+    b.recordAdminAction(ctx, message.From.ID, "stats", map[string]interface{}{})
+
+    msg := "📊 Stats\n\n"
+    cached, ok := b.storage.(storage.CachedStorage)
+    if !ok {
+        msg += "🗄 Storage cache: not used by this storage"
+        b.sendMessage(message.Chat.ID, msg)
+        return
+    }
+
+    stats := cached.CacheStats()
+    msg += fmt.Sprintf("🗄 Storage cache: %d hits, %d misses", stats.Hits, stats.Misses)
+    if total := stats.Hits + stats.Misses; total > 0 {
+        msg += fmt.Sprintf(" (%.1f%% hit rate)", float64(stats.Hits)*100/float64(total))
+    }
+    b.sendMessage(message.Chat.ID, msg)
+}
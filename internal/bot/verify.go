@@ -0,0 +1,154 @@
+package bot
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "log"
+    "strings"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+const verifyHelp = "Usage: /verify <draw ID>"
+
+// handleVerifyCommand lets anyone check a draw: the revealed seed must
+// match the hash published when the draw opened, and a random draw's
+// winners must follow from the seed and the entry list. The entry list is
+// sent as a file for cmd/verifydraw; it has no unique codes, which stay
+// with their buyers.
+func (b *Bot) handleVerifyCommand(ctx context.Context, message *tgbotapi.Message) {
+    args := strings.Fields(message.CommandArguments())
+    if len(args) != 1 {
+        b.sendMessage(message.Chat.ID, verifyHelp)
+        return
+    }
+
+    d, err := b.storage.GetDraw(ctx, args[0])
+    if err != nil {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("⚠️ Draw %s नहीं मिला", args[0]))
+        return
+    }
+
+    if d.SeedHash == "" && (!draw.Drawn(d) || d.Seed == "") {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf("ℹ️ Draw %s के लिए कोई seed hash publish नहीं हुआ था, इसे verify नहीं किया जा सकता।", d.DrawID))
+        return
+    }
+    if !draw.Drawn(d) {
+        b.sendMessage(message.Chat.ID, fmt.Sprintf(
+            "🔒 Draw %s अभी %s है।\n\nSeed hash: %s\n\nDraw के बाद seed बताया जाएगा।",
+            d.DrawID, d.Status, d.SeedHash,
+        ))
+        return
+    }
+
+    entries, err := b.drawnEntries(ctx, d)
+    if err != nil {
+        log.Printf("Failed to get entries for draw %s: %v", d.DrawID, err)
+        b.sendMessage(message.Chat.ID, "⚠️ डेटा प्राप्त करने में त्रुटि हुई")
+        return
+    }
+
+    msg := fmt.Sprintf("🔍 Draw %s\n\n🎟 Ticket: ₹%.0f\n🎯 Method: %s\n👥 Entries: %d\n",
+        d.DrawID, d.TicketAmount, draw.MethodNames[d.Method], len(entries))
+    if len(entries) != d.EntryCount {
+        msg += fmt.Sprintf("⚠️ Draw record says %d entries\n", d.EntryCount)
+    }
+
+    switch {
+    case d.SeedHash == "":
+        msg += "⚠️ No seed hash was published before this draw, so the seed is not proven to have been fixed in advance\n"
+    case draw.HashSeed(d.Seed) == d.SeedHash:
+        msg += "✅ Seed matches the published hash\n"
+    default:
+        msg += "❌ Seed does NOT match the published hash\n"
+    }
+
+    if d.Method != draw.MethodRandom {
+        msg += "\nℹ️ इस method में seed का इस्तेमाल नहीं होता, winners entry list से तय होते हैं।"
+        b.sendMessage(message.Chat.ID, msg)
+        return
+    }
+
+    var announced []string
+    for _, entry := range entries {
+        if entry.Status == "winner" {
+            announced = append(announced, entry.EntryID)
+        }
+    }
+
+    var recomputed []string
+    for _, entry := range draw.SelectFair(d.Seed, entries, d.WinnerCount) {
+        recomputed = append(recomputed, entry.EntryID)
+    }
+
+    msg += fmt.Sprintf("🧾 Entries hash: %s\n", draw.HashEntries(entries))
+    msg += fmt.Sprintf("🏆 Recomputed winners: %s\n", strings.Join(recomputed, ", "))
+    if sameIDs(recomputed, announced) {
+        msg += "✅ Matches the announced winners\n"
+    } else {
+        msg += "❌ Does NOT match the announced winners\n"
+    }
+    hashFlag := ""
+    if d.SeedHash != "" {
+        hashFlag = " -hash " + d.SeedHash
+    }
+    msg += fmt.Sprintf("\nOffline check:\ngo run ./cmd/verifydraw -seed %s%s -winners %d draw-%s.csv",
+        d.Seed, hashFlag, d.WinnerCount, d.DrawID)
+    b.sendMessage(message.Chat.ID, msg)
+
+    var file bytes.Buffer
+    if err := draw.WriteEntries(&file, entries); err != nil {
+        log.Printf("Failed to write entries for draw %s: %v", d.DrawID, err)
+        return
+    }
+    doc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{
+        Name:  fmt.Sprintf("draw-%s.csv", d.DrawID),
+        Bytes: file.Bytes(),
+    })
+    if _, err := b.api.Send(doc); err != nil {
+        log.Printf("Failed to send file: %v", err)
+        b.sendMessage(message.Chat.ID, "⚠️ फ़ाइल भेजने में त्रुटि हुई")
+    }
+}
+
+// drawnEntries returns the entries that took part in a drawn draw. Entries
+// refunded after the draw still count; those refunded before never did.
+func (b *Bot) drawnEntries(ctx context.Context, d *models.Draw) ([]*models.LotteryEntry, error) {
+    entries, err := b.storage.GetEntriesByDraw(ctx, d.DrawID)
+    if err != nil {
+        return nil, err
+    }
+
+    var drawn []*models.LotteryEntry
+    for _, entry := range entries {
+        switch entry.Status {
+        case "winner", "expired":
+            drawn = append(drawn, entry)
+        case "refunded":
+            if txn, err := b.storage.GetTransaction(ctx, entry.TransactionID); err == nil && txn.RefundedAt.After(d.DrawnAt) {
+                drawn = append(drawn, entry)
+            }
+        }
+    }
+
+    return drawn, nil
+}
+
+func sameIDs(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    seen := make(map[string]bool, len(a))
+    for _, id := range a {
+        seen[id] = true
+    }
+    for _, id := range b {
+        if !seen[id] {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,149 @@
+package bot
+
+import (
+    "context"
+    "crypto/subtle"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+    // secretTokenHeader carries the secret_token passed to setWebhook
+    secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+    defaultWebhookPath   = "/webhook"
+    defaultWebhookListen = ":8443"
+    maxUpdateSize        = 1 << 20
+)
+
+// webhookHandler accepts updates POSTed by Telegram and passes them to handle
+type webhookHandler struct {
+    secretToken string
+    handle      func(update tgbotapi.Update)
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        w.Header().Set("Allow", http.MethodPost)
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+
+    // Without a secret anyone could forge updates, so refuse them all
+    token := r.Header.Get(secretTokenHeader)
+    if h.secretToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.secretToken)) != 1 {
+        http.Error(w, "invalid secret token", http.StatusUnauthorized)
+        return
+    }
+
+    var update tgbotapi.Update
+    if err := json.NewDecoder(io.LimitReader(r.Body, maxUpdateSize)).Decode(&update); err != nil {
+        http.Error(w, "invalid update", http.StatusBadRequest)
+        return
+    }
+
+    h.handle(update)
+    w.WriteHeader(http.StatusOK)
+}
+
+// WebhookHandler returns an http.Handler that feeds webhook updates into the
+// same pipeline as long polling. It can be mounted on any server.
+func (b *Bot) WebhookHandler(ctx context.Context) http.Handler {
+    return &webhookHandler{
+        secretToken: b.cfg().Bot.Webhook.SecretToken,
+        handle: func(update tgbotapi.Update) {
+            go b.handleUpdate(ctx, update)
+        },
+    }
+}
+
+func (b *Bot) startWebhook(ctx context.Context) error {
+    cfg := b.cfg().Bot.Webhook
+
+    path := cfg.Path
+    if path == "" {
+        path = defaultWebhookPath
+    }
+    listen := cfg.Listen
+    if listen == "" {
+        listen = defaultWebhookListen
+    }
+
+    if err := b.registerWebhook(); err != nil {
+        return err
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle(path, b.WebhookHandler(ctx))
+    server := &http.Server{
+        Addr:              listen,
+        Handler:           mux,
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+
+    errChan := make(chan error, 1)
+    go func() {
+        log.Printf("Listening for webhook updates on %s%s", listen, path)
+        if cfg.CertFile != "" {
+            errChan <- server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+        } else {
+            errChan <- server.ListenAndServe()
+        }
+    }()
+
+    select {
+    case err := <-errChan:
+        return fmt.Errorf("webhook server failed: %v", err)
+    case <-ctx.Done():
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        server.Shutdown(shutdownCtx)
+        return ctx.Err()
+    }
+}
+
+// registerWebhook points Telegram at the configured public URL. The bot API
+// library predates secret_token support, so the request is built by hand.
+func (b *Bot) registerWebhook() error {
+    cfg := b.cfg().Bot.Webhook
+    if cfg.URL == "" {
+        return fmt.Errorf("bot.webhook.url is required in webhook mode")
+    }
+    if cfg.SecretToken == "" {
+        return fmt.Errorf("bot.webhook.secret_token is required in webhook mode")
+    }
+
+    params := tgbotapi.Params{}
+    params["url"] = cfg.URL
+    params["secret_token"] = cfg.SecretToken
+
+    raw, ok := b.api.(rawClient)
+    if !ok {
+        return fmt.Errorf("telegram client does not support raw requests")
+    }
+
+    var err error
+    var resp *tgbotapi.APIResponse
+    if cfg.SelfSigned && cfg.CertFile != "" {
+        resp, err = raw.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{{
+            Name: "certificate",
+            Data: tgbotapi.FilePath(cfg.CertFile),
+        }})
+    } else {
+        resp, err = raw.MakeRequest("setWebhook", params)
+    }
+    if err != nil {
+        return fmt.Errorf("failed to set webhook: %v", err)
+    }
+    if !resp.Ok {
+        return fmt.Errorf("failed to set webhook: %s", resp.Description)
+    }
+
+    return nil
+}
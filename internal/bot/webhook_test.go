@@ -0,0 +1,131 @@
+package bot
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestWebhookHandler(t *testing.T) {
+    var received []tgbotapi.Update
+    server := httptest.NewServer(&webhookHandler{
+        secretToken: "s3cret",
+        handle: func(update tgbotapi.Update) {
+            received = append(received, update)
+        },
+    })
+    defer server.Close()
+
+    const update = `{"update_id": 7, "message": {"message_id": 1, "text": "/start", "chat": {"id": 42}}}`
+
+    tests := []struct {
+        name   string
+        method string
+        token  string
+        body   string
+        status int
+    }{
+        {"valid update", http.MethodPost, "s3cret", update, http.StatusOK},
+        {"missing token", http.MethodPost, "", update, http.StatusUnauthorized},
+        {"wrong token", http.MethodPost, "nope", update, http.StatusUnauthorized},
+        {"bad json", http.MethodPost, "s3cret", "{", http.StatusBadRequest},
+        {"wrong method", http.MethodGet, "s3cret", "", http.StatusMethodNotAllowed},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req, err := http.NewRequest(tt.method, server.URL, strings.NewReader(tt.body))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if tt.token != "" {
+                req.Header.Set(secretTokenHeader, tt.token)
+            }
+
+            resp, err := http.DefaultClient.Do(req)
+            if err != nil {
+                t.Fatal(err)
+            }
+            resp.Body.Close()
+
+            if resp.StatusCode != tt.status {
+                t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+            }
+        })
+    }
+
+    if len(received) != 1 {
+        t.Fatalf("handled %d updates, want 1", len(received))
+    }
+    if received[0].UpdateID != 7 || received[0].Message.Text != "/start" || received[0].Message.Chat.ID != 42 {
+        t.Errorf("decoded update = %+v", received[0])
+    }
+}
+
+func TestBotWebhookRejectsForgedUpdates(t *testing.T) {
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    tests := []struct {
+        name    string
+        secret  string
+        token   string
+        status  int
+        handled bool
+    }{
+        {"no secret configured", "", "", http.StatusUnauthorized, false},
+        {"missing token", "s3cret", "", http.StatusUnauthorized, false},
+        {"wrong token", "s3cret", "nope", http.StatusUnauthorized, false},
+        {"valid token", "s3cret", "s3cret", http.StatusOK, true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            b, _, store := newTestBot(t)
+            b.cfg().Bot.Webhook.SecretToken = tt.secret
+            server := httptest.NewServer(b.WebhookHandler(ctx))
+            defer server.Close()
+
+            body, err := json.Marshal(buyer.Command("/start"))
+            if err != nil {
+                t.Fatal(err)
+            }
+            req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if tt.token != "" {
+                req.Header.Set(secretTokenHeader, tt.token)
+            }
+            resp, err := http.DefaultClient.Do(req)
+            if err != nil {
+                t.Fatal(err)
+            }
+            resp.Body.Close()
+            if resp.StatusCode != tt.status {
+                t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+            }
+
+            // Accepted updates are handled in the background
+            handled := false
+            for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+                if _, err := store.GetUser(ctx, buyer.ID); err == nil {
+                    handled = true
+                    break
+                }
+                if !tt.handled {
+                    break
+                }
+            }
+            if handled != tt.handled {
+                t.Errorf("update handled = %v, want %v", handled, tt.handled)
+            }
+        })
+    }
+}
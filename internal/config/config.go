@@ -1,17 +1,21 @@
 package config
 
 import (
+    "errors"
     "fmt"
     "io/ioutil"
-    "sync"
+    "os"
+    "strconv"
+    "sync/atomic"
+    "time"
 
+    "github.com/gsshankar104/telegram-bot/internal/cron"
+    "github.com/gsshankar104/telegram-bot/internal/payment/upi"
     "gopkg.in/yaml.v2"
 )
 
-var (
-    config *Config
-    once   sync.Once
-)
+// current holds the active config; it is swapped atomically on reload
+var current atomic.Pointer[Config]
 
 type Config struct {
     Bot      BotConfig      `yaml:"bot"`
@@ -24,29 +28,88 @@ type Config struct {
 }
 
 type BotConfig struct {
-    Name  string `yaml:"name"`
+    Name    string        `yaml:"name"`
+    Token   string        `yaml:"token"`
+    Mode    string        `yaml:"mode"` // polling (default) or webhook
+    Webhook WebhookConfig `yaml:"webhook"`
 }
 
+type WebhookConfig struct {
+    URL         string `yaml:"url"`    // public URL registered with Telegram
+    Listen      string `yaml:"listen"` // local address, e.g. ":8443"
+    Path        string `yaml:"path"`
+    SecretToken string `yaml:"secret_token"`
+    CertFile    string `yaml:"cert_file"`
+    KeyFile     string `yaml:"key_file"`
+    SelfSigned  bool   `yaml:"self_signed"` // upload cert_file to Telegram
+}
 
 type AdminConfig struct {
     IDs []string `yaml:"ids"`
 }
 
 type DatabaseConfig struct {
-    DriveFolderID string `yaml:"drive_folder_id"`
+    Driver          string        `yaml:"driver"` // drive (default), sqlite or memory
+    DriveFolderID   string        `yaml:"drive_folder_id"`
+    CredentialsFile string        `yaml:"credentials_file"`
+    SQLitePath      string        `yaml:"sqlite_path"`
+    CacheRefresh    time.Duration `yaml:"cache_refresh"` // drive: how often to check for changes made outside the bot
 }
 
 type ChannelsConfig struct {
-    LotteryProof string `yaml:"lottery_proof"`
-    LotteryWin   string `yaml:"lottery_win"`
+    LotteryProof       string `yaml:"lottery_proof"`
+    LotteryWin         string `yaml:"lottery_win"`
+    LotteryProofChatID int64  `yaml:"lottery_proof_chat_id"` // draw results are posted here; 0 disables
 }
 
 type PaymentConfig struct {
-    QRCodeLink string `yaml:"qr_code_link"`
+    QRCodeLink string                `yaml:"qr_code_link"` // static QR, used when upi.vpa is empty
+    UPI        UPIConfig             `yaml:"upi"`
+    Provider   string                `yaml:"provider"` // manual (default) or upigateway
+    Callback   PaymentCallbackConfig `yaml:"callback"`
+    UPIGateway UPIGatewayConfig      `yaml:"upigateway"`
+    Statement  StatementConfig       `yaml:"statement"`
+}
+
+// StatementConfig names the CSV headers used by /reconcile. Each list holds
+// aliases tried in order; empty lists use built-in defaults.
+type StatementConfig struct {
+    ReferenceColumns []string `yaml:"reference_columns"` // UTR / UPI reference
+    AmountColumns    []string `yaml:"amount_columns"`    // credited amount
+    NoteColumns      []string `yaml:"note_columns"`      // remarks carrying the Unique Code
+}
+
+// UPIConfig enables per-ticket QR codes generated by the bot
+type UPIConfig struct {
+    VPA       string `yaml:"vpa"` // payee UPI ID, e.g. "lottery@okaxis"
+    PayeeName string `yaml:"payee_name"`
+}
+
+// PaymentCallbackConfig is where the bot listens for gateway webhooks
+type PaymentCallbackConfig struct {
+    Listen string `yaml:"listen"` // local address, e.g. ":8080"
+    Path   string `yaml:"path"`
+}
+
+type UPIGatewayConfig struct {
+    BaseURL     string `yaml:"base_url"`
+    APIKey      string `yaml:"api_key"`
+    RedirectURL string `yaml:"redirect_url"`
 }
 
 type TicketsConfig struct {
-    Prices []float64 `yaml:"prices"`
+    Prices    []float64      `yaml:"prices"`
+    Schedules []DrawSchedule `yaml:"schedules"` // automatic draws; prices without one are drawn by /select_winner
+}
+
+// DrawSchedule is the automatic draw for one ticket price
+type DrawSchedule struct {
+    Price       float64       `yaml:"price"`
+    Cron        string        `yaml:"cron"`         // minute hour day month weekday, e.g. "0 21 * * *"
+    TimeZone    string        `yaml:"time_zone"`    // IANA name such as Asia/Kolkata; defaults to the server's
+    Method      string        `yaml:"method"`       // random, fcfs, most_guessed or least_guessed; defaults to random
+    WinnerCount int           `yaml:"winner_count"` // defaults to 1
+    SalesCutoff time.Duration `yaml:"sales_cutoff"` // e.g. 30m; sales close this long before each draw
 }
 
 type LimitsConfig struct {
@@ -54,24 +117,194 @@ type LimitsConfig struct {
     CommandRateLimit   int `yaml:"command_rate_limit"`
 }
 
+// Load reads and validates the config file and makes it the active config
 func Load(filename string) error {
+    cfg, err := Read(filename)
+    if err != nil {
+        return err
+    }
+
+    current.Store(cfg)
+    return nil
+}
+
+// Read parses, overrides and validates the config file without activating it
+func Read(filename string) (*Config, error) {
     data, err := ioutil.ReadFile(filename)
     if err != nil {
-        return fmt.Errorf("error reading config file: %v", err)
+        return nil, fmt.Errorf("error reading config file: %v", err)
     }
 
     cfg := &Config{}
     if err := yaml.Unmarshal(data, cfg); err != nil {
-        return fmt.Errorf("error parsing config file: %v", err)
+        return nil, fmt.Errorf("error parsing config file: %v", err)
+    }
+
+    if err := applyEnv(cfg, os.LookupEnv); err != nil {
+        return nil, fmt.Errorf("error applying environment overrides:\n%v", err)
     }
+    cfg.applyDefaults()
 
-    once.Do(func() {
-        config = cfg
-    })
+    if err := cfg.Validate(); err != nil {
+        return nil, fmt.Errorf("invalid config file %s:\n%v", filename, err)
+    }
 
-    return nil
+    return cfg, nil
 }
 
 func Get() *Config {
-    return config
+    return current.Load()
+}
+
+// applyDefaults fills settings that older deployments pass through their
+// own environment variables.
+func (c *Config) applyDefaults() {
+    if c.Bot.Token == "" {
+        c.Bot.Token = os.Getenv("TELEGRAM_BOT_TOKEN")
+    }
+    if c.Database.CredentialsFile == "" {
+        c.Database.CredentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+    }
+    if c.Database.CredentialsFile == "" {
+        c.Database.CredentialsFile = "credentials.json"
+    }
+    if c.Database.CacheRefresh == 0 {
+        c.Database.CacheRefresh = time.Minute
+    }
+}
+
+// Validate checks the config for values the bot cannot run with and
+// reports every problem at once.
+func (c *Config) Validate() error {
+    var errs []error
+
+    if c.Bot.Token == "" {
+        errs = append(errs, fmt.Errorf("bot.token is required (or set LOTTERY_BOT_TOKEN)"))
+    }
+
+    switch c.Bot.Mode {
+    case "", "polling":
+    case "webhook":
+        if c.Bot.Webhook.URL == "" {
+            errs = append(errs, fmt.Errorf("bot.webhook.url is required in webhook mode"))
+        }
+        if c.Bot.Webhook.SecretToken == "" {
+            errs = append(errs, fmt.Errorf("bot.webhook.secret_token is required in webhook mode"))
+        }
+        if (c.Bot.Webhook.CertFile == "") != (c.Bot.Webhook.KeyFile == "") {
+            errs = append(errs, fmt.Errorf("bot.webhook.cert_file and bot.webhook.key_file must be set together"))
+        }
+    default:
+        errs = append(errs, fmt.Errorf("bot.mode must be polling or webhook, got %q", c.Bot.Mode))
+    }
+
+    if len(c.Admin.IDs) == 0 {
+        errs = append(errs, fmt.Errorf("admin.ids must list at least one admin"))
+    }
+    for _, id := range c.Admin.IDs {
+        if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+            errs = append(errs, fmt.Errorf("admin.ids: %q is not a numeric Telegram user ID", id))
+        }
+    }
+
+    switch c.Database.Driver {
+    case "", "drive":
+        if c.Database.DriveFolderID == "" {
+            errs = append(errs, fmt.Errorf("database.drive_folder_id is required for the drive driver"))
+        }
+        if c.Database.CacheRefresh < 0 {
+            errs = append(errs, fmt.Errorf("database.cache_refresh must not be negative"))
+        }
+    case "sqlite":
+        if c.Database.SQLitePath == "" {
+            errs = append(errs, fmt.Errorf("database.sqlite_path is required for the sqlite driver"))
+        }
+    case "memory":
+    default:
+        errs = append(errs, fmt.Errorf("database.driver must be drive, sqlite or memory, got %q", c.Database.Driver))
+    }
+
+    switch c.Payment.Provider {
+    case "", "manual":
+    case "upigateway":
+        if c.Payment.UPIGateway.BaseURL == "" {
+            errs = append(errs, fmt.Errorf("payment.upigateway.base_url is required for the upigateway provider"))
+        }
+        if c.Payment.UPIGateway.APIKey == "" {
+            errs = append(errs, fmt.Errorf("payment.upigateway.api_key is required for the upigateway provider"))
+        }
+    default:
+        errs = append(errs, fmt.Errorf("payment.provider must be manual or upigateway, got %q", c.Payment.Provider))
+    }
+
+    if c.Payment.UPI.VPA != "" && !upi.ValidVPA(c.Payment.UPI.VPA) {
+        errs = append(errs, fmt.Errorf("payment.upi.vpa: %q is not a valid UPI ID", c.Payment.UPI.VPA))
+    }
+
+    if len(c.Tickets.Prices) == 0 {
+        errs = append(errs, fmt.Errorf("tickets.prices must list at least one price"))
+    }
+    for _, price := range c.Tickets.Prices {
+        if price <= 0 {
+            errs = append(errs, fmt.Errorf("tickets.prices: %v is not a positive price", price))
+        }
+    }
+    errs = append(errs, c.Tickets.validateSchedules()...)
+
+    if c.Limits.CommandRateLimit <= 0 {
+        errs = append(errs, fmt.Errorf("limits.command_rate_limit must be positive"))
+    }
+    if c.Limits.MaxInvalidAttempts <= 0 {
+        errs = append(errs, fmt.Errorf("limits.max_invalid_attempts must be positive"))
+    }
+
+    return errors.Join(errs...)
+}
+
+// scheduledMethods are the draw package's selection methods that need no
+// admin, so a schedule can run them; manual draws are left out
+var scheduledMethods = map[string]bool{
+    "random":        true,
+    "fcfs":          true,
+    "most_guessed":  true,
+    "least_guessed": true,
+}
+
+func (t TicketsConfig) validateSchedules() []error {
+    var errs []error
+    seen := make(map[float64]bool)
+
+    for i, sched := range t.Schedules {
+        name := fmt.Sprintf("tickets.schedules[%d]", i)
+
+        listed := false
+        for _, price := range t.Prices {
+            listed = listed || price == sched.Price
+        }
+        if !listed {
+            errs = append(errs, fmt.Errorf("%s: price %v is not in tickets.prices", name, sched.Price))
+        }
+        if seen[sched.Price] {
+            errs = append(errs, fmt.Errorf("%s: price %v already has a schedule", name, sched.Price))
+        }
+        seen[sched.Price] = true
+
+        if _, err := cron.Parse(sched.Cron); err != nil {
+            errs = append(errs, fmt.Errorf("%s: %v", name, err))
+        }
+        if _, err := time.LoadLocation(sched.TimeZone); err != nil {
+            errs = append(errs, fmt.Errorf("%s: time_zone: %v", name, err))
+        }
+        if sched.Method != "" && !scheduledMethods[sched.Method] {
+            errs = append(errs, fmt.Errorf("%s: method must be random, fcfs, most_guessed or least_guessed, got %q", name, sched.Method))
+        }
+        if sched.WinnerCount < 0 {
+            errs = append(errs, fmt.Errorf("%s: winner_count must not be negative", name))
+        }
+        if sched.SalesCutoff < 0 {
+            errs = append(errs, fmt.Errorf("%s: sales_cutoff must not be negative", name))
+        }
+    }
+
+    return errs
 }
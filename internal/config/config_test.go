@@ -0,0 +1,131 @@
+package config
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+func validConfig() *Config {
+    return &Config{
+        Bot:      BotConfig{Name: "Lottery Bot", Token: "123:abc"},
+        Admin:    AdminConfig{IDs: []string{"12345"}},
+        Database: DatabaseConfig{DriveFolderID: "folder"},
+        Payment:  PaymentConfig{UPI: UPIConfig{VPA: "lottery@okaxis"}},
+        Tickets:  TicketsConfig{Prices: []float64{100, 200}},
+        Limits:   LimitsConfig{MaxInvalidAttempts: 3, CommandRateLimit: 5},
+    }
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+    if err := validConfig().Validate(); err != nil {
+        t.Fatalf("Validate() = %v, want nil", err)
+    }
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+    cfg := validConfig()
+    cfg.Admin.IDs = nil
+    cfg.Database.DriveFolderID = ""
+    cfg.Tickets.Prices = []float64{100, 0, -5}
+    cfg.Limits.CommandRateLimit = 0
+
+    err := cfg.Validate()
+    if err == nil {
+        t.Fatal("Validate() = nil, want errors")
+    }
+
+    for _, want := range []string{
+        "admin.ids",
+        "database.drive_folder_id",
+        "tickets.prices: 0",
+        "tickets.prices: -5",
+        "limits.command_rate_limit",
+    } {
+        if !strings.Contains(err.Error(), want) {
+            t.Errorf("Validate() error is missing %q:\n%v", want, err)
+        }
+    }
+}
+
+func TestValidateWebhookMode(t *testing.T) {
+    cfg := validConfig()
+    cfg.Bot.Mode = "webhook"
+    cfg.Bot.Webhook.URL = "https://example.com/telegram/webhook"
+    if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "bot.webhook.secret_token") {
+        t.Errorf("Validate() = %v, want secret_token error", err)
+    }
+
+    cfg.Bot.Webhook.SecretToken = "s3cret"
+    if err := cfg.Validate(); err != nil {
+        t.Errorf("Validate() with secret_token = %v, want nil", err)
+    }
+}
+
+func TestValidateDriverSpecificSettings(t *testing.T) {
+    cfg := validConfig()
+    cfg.Database = DatabaseConfig{Driver: "sqlite"}
+    if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "sqlite_path") {
+        t.Errorf("Validate() = %v, want sqlite_path error", err)
+    }
+
+    cfg.Database = DatabaseConfig{Driver: "memory"}
+    if err := cfg.Validate(); err != nil {
+        t.Errorf("Validate() with memory driver = %v, want nil", err)
+    }
+    cfg.Database = DatabaseConfig{DriveFolderID: "folder", CacheRefresh: -time.Minute}
+    if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cache_refresh") {
+        t.Errorf("Validate() = %v, want cache_refresh error", err)
+    }
+}
+
+func TestValidatePaymentProvider(t *testing.T) {
+    cfg := validConfig()
+    cfg.Payment.Provider = "upigateway"
+    err := cfg.Validate()
+    if err == nil || !strings.Contains(err.Error(), "payment.upigateway.base_url") || !strings.Contains(err.Error(), "payment.upigateway.api_key") {
+        t.Errorf("Validate() = %v, want base_url and api_key errors", err)
+    }
+
+    cfg.Payment.UPIGateway = UPIGatewayConfig{BaseURL: "http://localhost:8090", APIKey: "key"}
+    if err := cfg.Validate(); err != nil {
+        t.Errorf("Validate() with upigateway = %v, want nil", err)
+    }
+
+    cfg.Payment.Provider = "paypal"
+    if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "payment.provider") {
+        t.Errorf("Validate() = %v, want payment.provider error", err)
+    }
+}
+
+func TestValidateUPIVPA(t *testing.T) {
+    cfg := validConfig()
+    cfg.Payment.UPI.VPA = "not a vpa"
+    if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "payment.upi.vpa") {
+        t.Errorf("Validate() = %v, want invalid VPA error", err)
+    }
+}
+
+func TestValidateSchedules(t *testing.T) {
+    cfg := validConfig()
+    cfg.Tickets.Schedules = []DrawSchedule{
+        {Price: cfg.Tickets.Prices[0], Cron: "0 21 * * *", TimeZone: "Asia/Kolkata", Method: "random", WinnerCount: 2, SalesCutoff: 30 * time.Minute},
+    }
+    if err := cfg.Validate(); err != nil {
+        t.Fatalf("Validate() = %v, want nil", err)
+    }
+
+    cfg.Tickets.Schedules = append(cfg.Tickets.Schedules,
+        DrawSchedule{Price: cfg.Tickets.Prices[0], Cron: "0 21 * * *"},
+        DrawSchedule{Price: 999, Cron: "61 21 * * *", TimeZone: "Mars/Olympus", Method: "manual"},
+    )
+    err := cfg.Validate()
+    if err == nil {
+        t.Fatalf("Validate() = nil, want schedule errors")
+    }
+    for _, want := range []string{"already has a schedule", "not in tickets.prices", "minute", "time_zone", "method"} {
+        if !strings.Contains(err.Error(), want) {
+            t.Errorf("Validate() error missing %q:\n%v", want, err)
+        }
+    }
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "reflect"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// envPrefix starts every override variable. The rest of the name is the
+// field's YAML path in upper case, e.g. database.drive_folder_id is
+// LOTTERY_DATABASE_DRIVE_FOLDER_ID. Lists are comma separated and durations
+// are written like "1m". Appending _FILE reads the value from a file
+// instead, which suits mounted secrets. Fields that cannot be written as a
+// string, such as tickets.schedules, come only from the file; setting their
+// variable is an error rather than being ignored.
+const envPrefix = "LOTTERY"
+
+// applyEnv overrides config fields from the environment. Variables that are
+// set but cannot be parsed are reported together.
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+    return errors.Join(applyEnvStruct(reflect.ValueOf(cfg).Elem(), envPrefix, lookup)...)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) []error {
+    var errs []error
+    t := v.Type()
+
+    for i := 0; i < t.NumField(); i++ {
+        tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+        if tag == "" || tag == "-" {
+            continue
+        }
+
+        name := prefix + "_" + strings.ToUpper(tag)
+        field := v.Field(i)
+
+        if field.Kind() == reflect.Struct {
+            errs = append(errs, applyEnvStruct(field, name, lookup)...)
+            continue
+        }
+
+        value, ok, err := lookupEnv(name, lookup)
+        if err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        if !ok {
+            continue
+        }
+
+        if !envSettable(field.Type()) {
+            errs = append(errs, fmt.Errorf("%s: not supported, set it in the config file", name))
+            continue
+        }
+        if err := setField(field, value); err != nil {
+            errs = append(errs, fmt.Errorf("%s: %v", name, err))
+        }
+    }
+
+    return errs
+}
+
+// lookupEnv returns NAME if set, otherwise the contents of the file named by NAME_FILE
+func lookupEnv(name string, lookup func(string) (string, bool)) (string, bool, error) {
+    if value, ok := lookup(name); ok {
+        return value, true, nil
+    }
+
+    path, ok := lookup(name + "_FILE")
+    if !ok {
+        return "", false, nil
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return "", false, fmt.Errorf("%s_FILE: %v", name, err)
+    }
+    return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// envSettable reports whether setField can parse a value of type t
+func envSettable(t reflect.Type) bool {
+    if t.Kind() == reflect.Slice {
+        t = t.Elem()
+    }
+    switch t.Kind() {
+    case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+        return true
+    }
+    return false
+}
+
+func setField(field reflect.Value, value string) error {
+    if field.Kind() == reflect.Slice {
+        var parts []string
+        for _, part := range strings.Split(value, ",") {
+            if part = strings.TrimSpace(part); part != "" {
+                parts = append(parts, part)
+            }
+        }
+
+        slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+        for i, part := range parts {
+            if err := setScalar(slice.Index(i), part); err != nil {
+                return err
+            }
+        }
+        field.Set(slice)
+        return nil
+    }
+
+    return setScalar(field, value)
+}
+
+func setScalar(field reflect.Value, value string) error {
+    // A time.Duration is an int64 but parsed like "1m"
+    if field.Type() == reflect.TypeOf(time.Duration(0)) {
+        d, err := time.ParseDuration(value)
+        if err != nil {
+            return fmt.Errorf("invalid duration %q", value)
+        }
+        field.SetInt(int64(d))
+        return nil
+    }
+
+    switch field.Kind() {
+    case reflect.String:
+        field.SetString(value)
+    case reflect.Bool:
+        b, err := strconv.ParseBool(value)
+        if err != nil {
+            return fmt.Errorf("invalid boolean %q", value)
+        }
+        field.SetBool(b)
+    case reflect.Int, reflect.Int64:
+        n, err := strconv.ParseInt(value, 10, 64)
+        if err != nil {
+            return fmt.Errorf("invalid integer %q", value)
+        }
+        field.SetInt(n)
+    case reflect.Float64:
+        f, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            return fmt.Errorf("invalid number %q", value)
+        }
+        field.SetFloat(f)
+    default:
+        return fmt.Errorf("cannot be set from the environment")
+    }
+    return nil
+}
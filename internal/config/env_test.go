@@ -0,0 +1,83 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+func mapLookup(env map[string]string) func(string) (string, bool) {
+    return func(name string) (string, bool) {
+        value, ok := env[name]
+        return value, ok
+    }
+}
+
+func TestApplyEnvOverridesYAML(t *testing.T) {
+    secret := filepath.Join(t.TempDir(), "token")
+    if err := os.WriteFile(secret, []byte("123:from-file\n"), 0600); err != nil {
+        t.Fatal(err)
+    }
+
+    cfg := validConfig()
+    err := applyEnv(cfg, mapLookup(map[string]string{
+        "LOTTERY_ADMIN_IDS":                 "1, 2,3",
+        "LOTTERY_TICKETS_PRICES":            "50,150.5",
+        "LOTTERY_DATABASE_DRIVE_FOLDER_ID":  "env-folder",
+        "LOTTERY_LIMITS_COMMAND_RATE_LIMIT": "10",
+        "LOTTERY_BOT_WEBHOOK_SELF_SIGNED":   "true",
+        "LOTTERY_BOT_TOKEN_FILE":            secret,
+        "LOTTERY_DATABASE_CACHE_REFRESH":    "90s",
+    }))
+    if err != nil {
+        t.Fatalf("applyEnv: %v", err)
+    }
+
+    if strings.Join(cfg.Admin.IDs, "|") != "1|2|3" {
+        t.Errorf("Admin.IDs = %v", cfg.Admin.IDs)
+    }
+    if len(cfg.Tickets.Prices) != 2 || cfg.Tickets.Prices[0] != 50 || cfg.Tickets.Prices[1] != 150.5 {
+        t.Errorf("Tickets.Prices = %v", cfg.Tickets.Prices)
+    }
+    if cfg.Database.DriveFolderID != "env-folder" {
+        t.Errorf("Database.DriveFolderID = %q", cfg.Database.DriveFolderID)
+    }
+    if cfg.Limits.CommandRateLimit != 10 {
+        t.Errorf("Limits.CommandRateLimit = %d", cfg.Limits.CommandRateLimit)
+    }
+    if cfg.Database.CacheRefresh != 90*time.Second {
+        t.Errorf("Database.CacheRefresh = %v, want 1m30s", cfg.Database.CacheRefresh)
+    }
+    if !cfg.Bot.Webhook.SelfSigned {
+        t.Error("Bot.Webhook.SelfSigned not set")
+    }
+    if cfg.Bot.Token != "123:from-file" {
+        t.Errorf("Bot.Token = %q, want value from file", cfg.Bot.Token)
+    }
+
+    // Fields without a variable keep their YAML value
+    if cfg.Limits.MaxInvalidAttempts != 3 {
+        t.Errorf("Limits.MaxInvalidAttempts = %d, want unchanged", cfg.Limits.MaxInvalidAttempts)
+    }
+}
+
+func TestApplyEnvReportsInvalidValues(t *testing.T) {
+    err := applyEnv(validConfig(), mapLookup(map[string]string{
+        "LOTTERY_TICKETS_PRICES":            "100,abc",
+        "LOTTERY_LIMITS_COMMAND_RATE_LIMIT": "fast",
+        "LOTTERY_BOT_TOKEN_FILE":            "/does/not/exist",
+        "LOTTERY_DATABASE_CACHE_REFRESH":    "60",
+        "LOTTERY_TICKETS_SCHEDULES":         "",
+    }))
+    if err == nil {
+        t.Fatal("applyEnv = nil, want errors")
+    }
+
+    for _, want := range []string{"LOTTERY_TICKETS_PRICES", "LOTTERY_LIMITS_COMMAND_RATE_LIMIT", "LOTTERY_BOT_TOKEN_FILE", "LOTTERY_DATABASE_CACHE_REFRESH", "LOTTERY_TICKETS_SCHEDULES"} {
+        if !strings.Contains(err.Error(), want) {
+            t.Errorf("error is missing %s:\n%v", want, err)
+        }
+    }
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+    "context"
+    "log"
+    "os"
+    "reflect"
+    "time"
+)
+
+// Watcher reloads the config file when it changes on disk or when Reload
+// is called (e.g. on SIGHUP). A reload only takes effect if the new file
+// passes Validate; otherwise the previous config stays active.
+type Watcher struct {
+    filename string
+    interval time.Duration
+    onReload func(*Config)
+    onError  func(error)
+    trigger  chan struct{}
+}
+
+// NewWatcher creates a watcher that polls filename every interval.
+// onReload receives each new valid config after it has been activated,
+// onError each rejected reload.
+func NewWatcher(filename string, interval time.Duration, onReload func(*Config), onError func(error)) *Watcher {
+    return &Watcher{
+        filename: filename,
+        interval: interval,
+        onReload: onReload,
+        onError:  onError,
+        trigger:  make(chan struct{}, 1),
+    }
+}
+
+// Reload asks the watcher to re-read the file even if it has not changed
+func (w *Watcher) Reload() {
+    select {
+    case w.trigger <- struct{}{}:
+    default:
+    }
+}
+
+// Run watches the file until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+    ticker := time.NewTicker(w.interval)
+    defer ticker.Stop()
+
+    lastMod, lastSize := w.stat()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-w.trigger:
+            lastMod, lastSize = w.stat()
+            w.reload()
+        case <-ticker.C:
+            mod, size := w.stat()
+            if mod.Equal(lastMod) && size == lastSize {
+                continue
+            }
+            lastMod, lastSize = mod, size
+            w.reload()
+        }
+    }
+}
+
+func (w *Watcher) stat() (time.Time, int64) {
+    info, err := os.Stat(w.filename)
+    if err != nil {
+        return time.Time{}, -1
+    }
+    return info.ModTime(), info.Size()
+}
+
+func (w *Watcher) reload() {
+    cfg, err := Read(w.filename)
+    if err != nil {
+        w.onError(err)
+        return
+    }
+
+    if changed := RestartRequired(Get(), cfg); len(changed) > 0 {
+        log.Printf("Config reloaded; restart required to apply: %v", changed)
+    }
+
+    current.Store(cfg)
+    w.onReload(cfg)
+}
+
+// RestartRequired lists the settings that differ between old and new but
+// are only read at startup.
+func RestartRequired(old, new *Config) []string {
+    if old == nil || new == nil {
+        return nil
+    }
+
+    var changed []string
+    if old.Bot.Token != new.Bot.Token {
+        changed = append(changed, "bot.token")
+    }
+    if old.Bot.Mode != new.Bot.Mode {
+        changed = append(changed, "bot.mode")
+    }
+    if old.Bot.Webhook != new.Bot.Webhook {
+        changed = append(changed, "bot.webhook")
+    }
+    if old.Database != new.Database {
+        changed = append(changed, "database")
+    }
+    if old.Payment.Provider != new.Payment.Provider || old.Payment.UPIGateway != new.Payment.UPIGateway {
+        changed = append(changed, "payment.provider")
+    }
+    if old.Payment.Callback != new.Payment.Callback {
+        changed = append(changed, "payment.callback")
+    }
+    if !reflect.DeepEqual(old.Tickets.Schedules, new.Tickets.Schedules) {
+        changed = append(changed, "tickets.schedules")
+    }
+    return changed
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+const watchedConfig = `
+bot:
+  name: "Lottery Bot"
+  token: "123:abc"
+admin:
+  ids: ["1"]
+database:
+  driver: "memory"
+tickets:
+  prices: [%s]
+limits:
+  max_invalid_attempts: 3
+  command_rate_limit: 5
+`
+
+func writeConfig(t *testing.T, path, prices string) {
+    t.Helper()
+    if err := os.WriteFile(path, []byte(fmt.Sprintf(watchedConfig, prices)), 0600); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func TestWatcherReloadsValidAndRejectsInvalidConfig(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "config.yaml")
+    writeConfig(t, path, "100")
+    if err := Load(path); err != nil {
+        t.Fatalf("Load: %v", err)
+    }
+
+    reloaded := make(chan *Config, 1)
+    rejected := make(chan error, 1)
+    w := NewWatcher(path, time.Hour, func(cfg *Config) { reloaded <- cfg }, func(err error) { rejected <- err })
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go w.Run(ctx)
+
+    writeConfig(t, path, "100, 250")
+    w.Reload()
+    select {
+    case cfg := <-reloaded:
+        if len(cfg.Tickets.Prices) != 2 || Get() != cfg {
+            t.Errorf("reloaded config not activated: %+v", cfg.Tickets)
+        }
+    case err := <-rejected:
+        t.Fatalf("valid reload rejected: %v", err)
+    case <-time.After(time.Second):
+        t.Fatal("reload not triggered")
+    }
+
+    active := Get()
+    writeConfig(t, path, "0")
+    w.Reload()
+    select {
+    case <-reloaded:
+        t.Fatal("invalid config was activated")
+    case <-rejected:
+        if Get() != active {
+            t.Error("active config changed after rejected reload")
+        }
+    case <-time.After(time.Second):
+        t.Fatal("reload not triggered")
+    }
+}
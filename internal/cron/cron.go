@@ -0,0 +1,191 @@
+// Package cron parses five-field cron expressions. It has no dependencies
+// so that config can validate schedules without importing the scheduler.
+package cron
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// maxSearchDays bounds Next and Prev; five years covers "29 Feb" schedules
+const maxSearchDays = 5 * 366
+
+// Expr is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week. Fields accept "*", numbers,
+// ranges ("1-5"), lists ("0,30") and steps ("*/15"). Day of week runs
+// 0-7 with both 0 and 7 meaning Sunday.
+type Expr struct {
+    expr   string
+    minute uint64
+    hour   uint64
+    dom    uint64
+    month  uint64
+    dow    uint64
+    // As in classic cron, when both day fields are restricted a day
+    // matching either one is enough
+    domAny bool
+    dowAny bool
+}
+
+type fieldBounds struct {
+    name     string
+    min, max int
+}
+
+var cronFields = []fieldBounds{
+    {"minute", 0, 59},
+    {"hour", 0, 23},
+    {"day of month", 1, 31},
+    {"month", 1, 12},
+    {"day of week", 0, 7},
+}
+
+// Parse parses a five-field cron expression such as "0 21 * * *"
+func Parse(expr string) (*Expr, error) {
+    fields := strings.Fields(expr)
+    if len(fields) != len(cronFields) {
+        return nil, fmt.Errorf("cron %q: want 5 fields (minute hour day month weekday), got %d", expr, len(fields))
+    }
+
+    var bits [5]uint64
+    for i, field := range fields {
+        b, err := parseField(field, cronFields[i])
+        if err != nil {
+            return nil, fmt.Errorf("cron %q: %v", expr, err)
+        }
+        bits[i] = b
+    }
+
+    // Sunday is both 0 and 7
+    if bits[4]&(1<<7) != 0 {
+        bits[4] |= 1
+    }
+
+    return &Expr{
+        expr:   expr,
+        minute: bits[0],
+        hour:   bits[1],
+        dom:    bits[2],
+        month:  bits[3],
+        dow:    bits[4],
+        domAny: strings.HasPrefix(fields[2], "*"),
+        dowAny: strings.HasPrefix(fields[4], "*"),
+    }, nil
+}
+
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+    var bits uint64
+    for _, part := range strings.Split(field, ",") {
+        rangePart, step := part, 1
+        if i := strings.Index(part, "/"); i >= 0 {
+            s, err := strconv.Atoi(part[i+1:])
+            if err != nil || s < 1 {
+                return 0, fmt.Errorf("%s: bad step in %q", bounds.name, part)
+            }
+            rangePart, step = part[:i], s
+        }
+
+        lo, hi := bounds.min, bounds.max
+        switch {
+        case rangePart == "*":
+        case strings.Contains(rangePart, "-"):
+            ends := strings.SplitN(rangePart, "-", 2)
+            var err1, err2 error
+            lo, err1 = strconv.Atoi(ends[0])
+            hi, err2 = strconv.Atoi(ends[1])
+            if err1 != nil || err2 != nil {
+                return 0, fmt.Errorf("%s: bad range %q", bounds.name, rangePart)
+            }
+        default:
+            v, err := strconv.Atoi(rangePart)
+            if err != nil {
+                return 0, fmt.Errorf("%s: bad value %q", bounds.name, rangePart)
+            }
+            lo, hi = v, v
+            if step > 1 {
+                hi = bounds.max
+            }
+        }
+
+        if lo < bounds.min || hi > bounds.max || lo > hi {
+            return 0, fmt.Errorf("%s: %q is outside %d-%d", bounds.name, part, bounds.min, bounds.max)
+        }
+        for v := lo; v <= hi; v += step {
+            bits |= 1 << uint(v)
+        }
+    }
+    return bits, nil
+}
+
+func (c *Expr) String() string {
+    return c.expr
+}
+
+func (c *Expr) matchDay(t time.Time) bool {
+    if c.month&(1<<uint(t.Month())) == 0 {
+        return false
+    }
+    dom := c.dom&(1<<uint(t.Day())) != 0
+    dow := c.dow&(1<<uint(t.Weekday())) != 0
+    if c.domAny || c.dowAny {
+        return dom && dow
+    }
+    return dom || dow
+}
+
+// Next returns the first scheduled minute strictly after t, in t's
+// location, or the zero time if there is none within five years
+func (c *Expr) Next(t time.Time) time.Time {
+    t = t.Truncate(time.Minute).Add(time.Minute)
+    hour, minute := t.Hour(), t.Minute()
+    day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+    for i := 0; i < maxSearchDays; i++ {
+        if c.matchDay(day) {
+            for h := hour; h < 24; h++ {
+                if c.hour&(1<<uint(h)) != 0 {
+                    for m := minute; m < 60; m++ {
+                        if c.minute&(1<<uint(m)) != 0 {
+                            return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+                        }
+                    }
+                }
+                minute = 0
+            }
+        }
+        hour, minute = 0, 0
+        day = day.AddDate(0, 0, 1)
+    }
+    return time.Time{}
+}
+
+// Prev returns the last scheduled minute strictly before t, in t's
+// location, or the zero time if there is none within five years
+func (c *Expr) Prev(t time.Time) time.Time {
+    last := t.Truncate(time.Minute)
+    if last.Equal(t) {
+        last = last.Add(-time.Minute)
+    }
+    hour, minute := last.Hour(), last.Minute()
+    day := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, last.Location())
+
+    for i := 0; i < maxSearchDays; i++ {
+        if c.matchDay(day) {
+            for h := hour; h >= 0; h-- {
+                if c.hour&(1<<uint(h)) != 0 {
+                    for m := minute; m >= 0; m-- {
+                        if c.minute&(1<<uint(m)) != 0 {
+                            return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+                        }
+                    }
+                }
+                minute = 59
+            }
+        }
+        hour, minute = 23, 59
+        day = day.AddDate(0, 0, -1)
+    }
+    return time.Time{}
+}
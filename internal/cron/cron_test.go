@@ -0,0 +1,64 @@
+package cron
+
+import (
+    "testing"
+    "time"
+)
+
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+func at(s string) time.Time {
+    t, err := time.ParseInLocation("2006-01-02 15:04", s, ist)
+    if err != nil {
+        panic(err)
+    }
+    return t
+}
+
+func TestNextPrev(t *testing.T) {
+    tests := []struct {
+        expr, from, next, prev string
+    }{
+        {"0 21 * * *", "2024-03-15 12:00", "2024-03-15 21:00", "2024-03-14 21:00"},
+        {"0 21 * * *", "2024-03-15 21:00", "2024-03-16 21:00", "2024-03-14 21:00"},
+        {"*/15 9-10 * * *", "2024-03-15 10:50", "2024-03-16 09:00", "2024-03-15 10:45"},
+        {"30 20 * * 0", "2024-03-15 12:00", "2024-03-17 20:30", "2024-03-10 20:30"},
+        {"0 12 1,15 * *", "2024-03-15 12:01", "2024-04-01 12:00", "2024-03-15 12:00"},
+        {"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00", "2024-02-29 00:00"},
+        // Day of month and day of week restricted: either matches
+        {"0 8 1 * 1", "2024-03-15 12:00", "2024-03-18 08:00", "2024-03-11 08:00"},
+        {"0 8 * * 7", "2024-03-15 12:00", "2024-03-17 08:00", "2024-03-10 08:00"},
+    }
+
+    for _, tt := range tests {
+        e, err := Parse(tt.expr)
+        if err != nil {
+            t.Fatalf("Parse(%q): %v", tt.expr, err)
+        }
+        if got := e.Next(at(tt.from)); !got.Equal(at(tt.next)) {
+            t.Errorf("%q Next(%s) = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.next)
+        }
+        if got := e.Prev(at(tt.from)); !got.Equal(at(tt.prev)) {
+            t.Errorf("%q Prev(%s) = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.prev)
+        }
+    }
+}
+
+func TestParseErrors(t *testing.T) {
+    for _, expr := range []string{
+        "",
+        "0 21 * *",
+        "60 21 * * *",
+        "0 24 * * *",
+        "0 21 0 * *",
+        "0 21 * 13 *",
+        "0 21 * * 8",
+        "0 21-20 * * *",
+        "*/0 21 * * *",
+        "a 21 * * *",
+    } {
+        if _, err := Parse(expr); err == nil {
+            t.Errorf("Parse(%q) accepted an invalid expression", expr)
+        }
+    }
+}
@@ -0,0 +1,402 @@
+package draw
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// Selection methods offered to admins by /select_winner
+const (
+    MethodRandom       = "random"
+    MethodFCFS         = "fcfs"
+    MethodMostGuessed  = "most_guessed"
+    MethodLeastGuessed = "least_guessed"
+    MethodManual       = "manual"
+)
+
+var (
+    ErrNoEntries     = errors.New("no active entries for this draw")
+    ErrUnknownMethod = errors.New("unknown selection method")
+    ErrDrawExists    = errors.New("draw has already run")
+)
+
+// MethodNames maps selection methods to the labels shown to admins
+var MethodNames = map[string]string{
+    MethodRandom:       "Random",
+    MethodFCFS:         "First Come First Serve",
+    MethodMostGuessed:  "Most Guessed Number",
+    MethodLeastGuessed: "Least Guessed Number",
+    MethodManual:       "Manual Selection",
+}
+
+// Request describes a single draw for one ticket amount
+type Request struct {
+    Date        time.Time
+    Amount      float64
+    Method      string
+    WinnerCount int
+    // Codes holds the entry IDs or unique codes picked by an admin for MethodManual
+    Codes []string
+    // DrawID names the draw. When set, the entries linked to that draw
+    // take part and a draw that has been drawn is not run again; without
+    // it the entries made on Date take part.
+    DrawID string
+}
+
+// Result is the outcome of a completed draw
+type Result struct {
+    Request
+    Draw           *models.Draw
+    Entries        []*models.LotteryEntry
+    WinningEntries []*models.LotteryEntry
+    Winners        []*models.Winner
+}
+
+// Engine selects winners from the stored lottery entries
+type Engine struct {
+    storage storage.Storage
+    mutex   sync.Mutex
+    now     func() time.Time
+}
+
+// NewEngine creates a draw engine backed by the given storage
+func NewEngine(s storage.Storage) *Engine {
+    return &Engine{
+        storage: s,
+        now:     time.Now,
+    }
+}
+
+// EligibleEntries returns the active entries taking part in a request;
+// pending, rejected and refunded entries never take part. Entries of a
+// named draw that an interrupted run already marked as winner or expired
+// take part again.
+func (e *Engine) EligibleEntries(ctx context.Context, req Request) ([]*models.LotteryEntry, error) {
+    var entries []*models.LotteryEntry
+    var err error
+    if req.DrawID != "" {
+        entries, err = e.storage.GetEntriesByDraw(ctx, req.DrawID)
+    } else {
+        entries, err = e.storage.GetEntriesByDate(ctx, req.Date)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var eligible []*models.LotteryEntry
+    for _, entry := range entries {
+        if entry.TicketAmount != req.Amount {
+            continue
+        }
+        switch entry.Status {
+        case "active":
+        case "winner", "expired":
+            if req.DrawID == "" {
+                continue
+            }
+        default:
+            continue
+        }
+        eligible = append(eligible, entry)
+    }
+
+    return eligible, nil
+}
+
+// Drawn reports whether a draw's result is final. Records saved before
+// draws had a status are all completed draws.
+func Drawn(d *models.Draw) bool {
+    return d.Status != "open" && d.Status != "closed"
+}
+
+// Run selects the winners for a request, records them, marks the winning
+// entries as "winner" and every other eligible entry as "expired", and
+// records the draw as drawn last. An open draw is closed to new entries
+// first. A named draw without entries is recorded as drawn and ErrNoEntries
+// returned.
+//
+// The seed and the entries are stored with the draw before any result is
+// written and winner IDs derive from the draw ID, so a run that fails part
+// way can be repeated for the same draw and makes the same selection.
+func (e *Engine) Run(ctx context.Context, req Request) (*Result, error) {
+    e.mutex.Lock()
+    defer e.mutex.Unlock()
+
+    now := e.now()
+
+    var record *models.Draw
+    if req.DrawID != "" {
+        if existing, err := e.storage.GetDraw(ctx, req.DrawID); err == nil {
+            if Drawn(existing) {
+                return nil, ErrDrawExists
+            }
+            record = existing
+        }
+    }
+    if record == nil {
+        record = &models.Draw{
+            DrawID:       req.DrawID,
+            TicketAmount: req.Amount,
+            OpenedAt:     now,
+        }
+        if record.DrawID == "" {
+            record.DrawID = fmt.Sprintf("DRAW%d", now.UnixNano())
+        }
+    }
+
+    if record.Status == "open" {
+        if err := e.storage.CloseDraw(ctx, record.DrawID, now); err != nil {
+            return nil, fmt.Errorf("failed to close draw: %v", err)
+        }
+    }
+    record.Status = "closed"
+    if record.ClosedAt.IsZero() {
+        record.ClosedAt = now
+    }
+
+    entries, err := e.EligibleEntries(ctx, req)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load entries: %v", err)
+    }
+
+    if len(entries) == 0 {
+        if req.DrawID != "" {
+            markDrawn(record, req, now, 0)
+            if err := e.storage.SaveDraw(ctx, record); err != nil {
+                return nil, fmt.Errorf("failed to save draw: %v", err)
+            }
+        }
+        return nil, ErrNoEntries
+    }
+
+    // A draw that was not opened in advance never published a seed hash.
+    // It still gets a seed so the result can be recomputed, but SeedHash
+    // stays empty: nothing was committed, so nothing can be claimed.
+    if record.Seed == "" {
+        seed, err := NewSeed()
+        if err != nil {
+            return nil, err
+        }
+        record.Seed = seed
+    }
+    // Entries grouped by day join the draw, so a repeated run finds them
+    // through the draw's ID
+    if req.DrawID == "" {
+        var ids []string
+        for _, entry := range entries {
+            ids = append(ids, entry.EntryID)
+            entry.DrawID = record.DrawID
+        }
+        if err := e.storage.UpdateEntryDraw(ctx, ids, record.DrawID); err != nil {
+            return nil, fmt.Errorf("failed to link entries: %v", err)
+        }
+    }
+    if err := e.storage.SaveDraw(ctx, record); err != nil {
+        return nil, fmt.Errorf("failed to save draw: %v", err)
+    }
+
+    winning, err := e.selectEntries(req, entries, record.Seed)
+    if err != nil {
+        return nil, err
+    }
+
+    prize := pool(entries) / float64(len(winning))
+
+    var winners []*models.Winner
+    winnerIDs := make(map[string]bool)
+    for i, entry := range winning {
+        winnerIDs[entry.EntryID] = true
+        winners = append(winners, &models.Winner{
+            WinnerID:      fmt.Sprintf("WIN%s-%02d", strings.TrimPrefix(record.DrawID, "DRAW"), i),
+            UserID:        entry.UserID,
+            EntryID:       entry.EntryID,
+            WinningAmount: prize,
+            Date:          now,
+            Time:          now,
+            PaymentStatus: "pending",
+            DrawID:        record.DrawID,
+        })
+    }
+
+    var won, lost []string
+    for _, entry := range entries {
+        if winnerIDs[entry.EntryID] {
+            won = append(won, entry.EntryID)
+        } else {
+            lost = append(lost, entry.EntryID)
+        }
+    }
+
+    for _, winner := range winners {
+        if err := e.storage.SaveWinner(ctx, winner); err != nil {
+            return nil, fmt.Errorf("failed to save winner: %v", err)
+        }
+    }
+    if err := e.storage.UpdateEntryStatus(ctx, won, "winner"); err != nil {
+        return nil, fmt.Errorf("failed to mark winners: %v", err)
+    }
+    if len(lost) > 0 {
+        if err := e.storage.UpdateEntryStatus(ctx, lost, "expired"); err != nil {
+            return nil, fmt.Errorf("failed to expire entries: %v", err)
+        }
+    }
+
+    markDrawn(record, req, now, len(entries))
+    if record.WinnerCount == 0 {
+        record.WinnerCount = len(winning)
+    }
+    for _, entry := range winning {
+        record.WinningNumbers = append(record.WinningNumbers, entry.LuckyNumber)
+    }
+    if err := e.storage.SaveDraw(ctx, record); err != nil {
+        return nil, fmt.Errorf("failed to save draw: %v", err)
+    }
+
+    for _, entry := range entries {
+        if winnerIDs[entry.EntryID] {
+            entry.Status = "winner"
+        } else {
+            entry.Status = "expired"
+        }
+    }
+
+    return &Result{
+        Request:        req,
+        Draw:           record,
+        Entries:        entries,
+        WinningEntries: winning,
+        Winners:        winners,
+    }, nil
+}
+
+// markDrawn fills in the outcome of a draw on its record
+func markDrawn(record *models.Draw, req Request, now time.Time, entryCount int) {
+    record.Status = "drawn"
+    record.Method = req.Method
+    record.WinnerCount = req.WinnerCount
+    record.Date = req.Date
+    record.DrawnAt = now
+    record.EntryCount = entryCount
+}
+
+func (e *Engine) selectEntries(req Request, entries []*models.LotteryEntry, seed string) ([]*models.LotteryEntry, error) {
+    if req.Method == MethodManual {
+        return SelectManual(entries, req.Codes, req.WinnerCount)
+    }
+
+    if req.WinnerCount < 1 {
+        return nil, fmt.Errorf("winner count must be positive")
+    }
+
+    switch req.Method {
+    case MethodRandom:
+        return SelectFair(seed, entries, req.WinnerCount), nil
+    case MethodFCFS:
+        return SelectFCFS(entries, req.WinnerCount), nil
+    case MethodMostGuessed:
+        return SelectByGuessFrequency(entries, req.WinnerCount, true), nil
+    case MethodLeastGuessed:
+        return SelectByGuessFrequency(entries, req.WinnerCount, false), nil
+    default:
+        return nil, ErrUnknownMethod
+    }
+}
+
+// SelectFCFS picks the count earliest entries
+func SelectFCFS(entries []*models.LotteryEntry, count int) []*models.LotteryEntry {
+    sorted := byEntryTime(entries)
+    return sorted[:min(count, len(sorted))]
+}
+
+// SelectByGuessFrequency groups entries by lucky number and picks winners
+// from the most (or least) guessed number first, earliest entry first.
+// Numbers with the same frequency are ordered by their earliest entry.
+func SelectByGuessFrequency(entries []*models.LotteryEntry, count int, most bool) []*models.LotteryEntry {
+    groups := make(map[int][]*models.LotteryEntry)
+    var numbers []int
+    for _, entry := range byEntryTime(entries) {
+        if _, ok := groups[entry.LuckyNumber]; !ok {
+            numbers = append(numbers, entry.LuckyNumber)
+        }
+        groups[entry.LuckyNumber] = append(groups[entry.LuckyNumber], entry)
+    }
+
+    sort.SliceStable(numbers, func(i, j int) bool {
+        a, b := len(groups[numbers[i]]), len(groups[numbers[j]])
+        if most {
+            return a > b
+        }
+        return a < b
+    })
+
+    var selected []*models.LotteryEntry
+    for _, number := range numbers {
+        for _, entry := range groups[number] {
+            if len(selected) == count {
+                return selected
+            }
+            selected = append(selected, entry)
+        }
+    }
+
+    return selected
+}
+
+// SelectManual picks the entries matching the given entry IDs or unique codes
+func SelectManual(entries []*models.LotteryEntry, codes []string, count int) ([]*models.LotteryEntry, error) {
+    if len(codes) == 0 {
+        return nil, fmt.Errorf("no entries selected")
+    }
+    if count > 0 && len(codes) > count {
+        return nil, fmt.Errorf("%d entries selected but only %d winners allowed", len(codes), count)
+    }
+
+    seen := make(map[string]bool)
+    var selected []*models.LotteryEntry
+    for _, code := range codes {
+        var match *models.LotteryEntry
+        for _, entry := range entries {
+            if strings.EqualFold(entry.EntryID, code) || strings.EqualFold(entry.UniqueCode, code) {
+                match = entry
+                break
+            }
+        }
+        if match == nil {
+            return nil, fmt.Errorf("entry %s not found in this draw", code)
+        }
+        if seen[match.EntryID] {
+            continue
+        }
+        seen[match.EntryID] = true
+        selected = append(selected, match)
+    }
+
+    return selected, nil
+}
+
+func byEntryTime(entries []*models.LotteryEntry) []*models.LotteryEntry {
+    sorted := make([]*models.LotteryEntry, len(entries))
+    copy(sorted, entries)
+    sort.SliceStable(sorted, func(i, j int) bool {
+        return sorted[i].EntryTime.Before(sorted[j].EntryTime)
+    })
+    return sorted
+}
+
+// pool returns the total ticket amount collected by the entries, which is
+// split equally between the winners.
+func pool(entries []*models.LotteryEntry) float64 {
+    var total float64
+    for _, entry := range entries {
+        total += entry.TicketAmount
+    }
+    return total
+}
@@ -0,0 +1,192 @@
+package draw
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+    "github.com/gsshankar104/telegram-bot/internal/storage/memory"
+)
+
+// timedEntries returns one entry per lucky number, entered a minute apart
+// in the given order; entries at the same minute share an EntryTime
+func timedEntries(numbers []int, minutes []int) []*models.LotteryEntry {
+    start := time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC)
+    var entries []*models.LotteryEntry
+    for i, number := range numbers {
+        entries = append(entries, &models.LotteryEntry{
+            EntryID:     fmt.Sprintf("E%d", i),
+            UniqueCode:  fmt.Sprintf("CODE%d", i),
+            LuckyNumber: number,
+            EntryTime:   start.Add(time.Duration(minutes[i]) * time.Minute),
+        })
+    }
+    return entries
+}
+
+func TestSelectFCFS(t *testing.T) {
+    tests := []struct {
+        name    string
+        minutes []int
+        count   int
+        want    string
+    }{
+        {"earliest first", []int{3, 1, 2}, 2, "[E1 E2]"},
+        {"ties keep entry order", []int{1, 1, 0}, 2, "[E2 E0]"},
+        {"count above entries", []int{2, 1}, 5, "[E1 E0]"},
+        {"no entries", nil, 1, "[]"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            entries := timedEntries(make([]int, len(tt.minutes)), tt.minutes)
+            if got := fmt.Sprint(entryIDs(SelectFCFS(entries, tt.count))); got != tt.want {
+                t.Errorf("SelectFCFS = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSelectByGuessFrequency(t *testing.T) {
+    tests := []struct {
+        name    string
+        numbers []int
+        minutes []int
+        count   int
+        most    bool
+        want    string
+    }{
+        {"most guessed", []int{7, 3, 7, 5}, []int{0, 1, 2, 3}, 2, true, "[E0 E2]"},
+        {"least guessed", []int{7, 3, 7, 5}, []int{0, 1, 2, 3}, 2, false, "[E1 E3]"},
+        {"tied numbers by earliest entry", []int{4, 9, 9, 4}, []int{3, 0, 2, 1}, 2, true, "[E1 E2]"},
+        {"group taken earliest first", []int{8, 8, 8}, []int{2, 0, 1}, 2, true, "[E1 E2]"},
+        {"count above entries", []int{1, 2}, []int{0, 1}, 5, false, "[E0 E1]"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            entries := timedEntries(tt.numbers, tt.minutes)
+            if got := fmt.Sprint(entryIDs(SelectByGuessFrequency(entries, tt.count, tt.most))); got != tt.want {
+                t.Errorf("SelectByGuessFrequency = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSelectManual(t *testing.T) {
+    entries := timedEntries([]int{1, 2, 3}, []int{0, 1, 2})
+    tests := []struct {
+        name    string
+        codes   []string
+        count   int
+        want    string
+        wantErr string
+    }{
+        {"entry IDs and codes", []string{"E2", "code0"}, 2, "[E2 E0]", ""},
+        {"duplicates picked once", []string{"E1", "CODE1"}, 2, "[E1]", ""},
+        {"no count limit", []string{"E0", "E1", "E2"}, 0, "[E0 E1 E2]", ""},
+        {"unknown code", []string{"E0", "NOPE"}, 2, "", "entry NOPE not found"},
+        {"more codes than winners", []string{"E0", "E1"}, 1, "", "only 1 winners allowed"},
+        {"no codes", nil, 1, "", "no entries selected"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            selected, err := SelectManual(entries, tt.codes, tt.count)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Errorf("SelectManual error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("SelectManual: %v", err)
+            }
+            if got := fmt.Sprint(entryIDs(selected)); got != tt.want {
+                t.Errorf("SelectManual = %s, want %s", got, tt.want)
+            }
+        })
+    }
+}
+
+// failingStorage fails the first UpdateEntryStatus to status
+type failingStorage struct {
+    storage.Storage
+    failStatus string
+}
+
+func (s *failingStorage) UpdateEntryStatus(ctx context.Context, entryIDs []string, status string) error {
+    if status == s.failStatus {
+        s.failStatus = ""
+        return errors.New("write failed")
+    }
+    return s.Storage.UpdateEntryStatus(ctx, entryIDs, status)
+}
+
+func saveEntries(t *testing.T, s storage.Storage, n int) {
+    t.Helper()
+    for i := 0; i < n; i++ {
+        entry := &models.LotteryEntry{
+            EntryID:      fmt.Sprintf("E%d", i),
+            UserID:       int64(100 + i),
+            TicketAmount: 100,
+            LuckyNumber:  i + 1,
+            EntryDate:    time.Now(),
+            EntryTime:    time.Now(),
+            Status:       "active",
+        }
+        if err := s.SaveLotteryEntry(context.Background(), entry); err != nil {
+            t.Fatalf("SaveLotteryEntry: %v", err)
+        }
+    }
+}
+
+func TestRunRepeatsAfterPartialFailure(t *testing.T) {
+    ctx := context.Background()
+    store := &failingStorage{Storage: memory.NewMemoryStorage(), failStatus: "expired"}
+    saveEntries(t, store, 4)
+    engine := NewEngine(store)
+
+    // Entries grouped by day; the run fails after marking the winners
+    req := Request{Date: time.Now(), Amount: 100, Method: MethodRandom, WinnerCount: 2}
+    if _, err := engine.Run(ctx, req); err == nil {
+        t.Fatal("Run succeeded, want the injected failure")
+    }
+
+    pending, err := store.ListDraws(ctx, storage.DrawFilter{TicketAmount: 100})
+    if err != nil || len(pending) != 1 || Drawn(pending[0]) || pending[0].Seed == "" {
+        t.Fatalf("draws after the failed run = %+v, %v; want one undrawn draw with a seed", pending, err)
+    }
+
+    req.DrawID = pending[0].DrawID
+    result, err := engine.Run(ctx, req)
+    if err != nil {
+        t.Fatalf("Run again: %v", err)
+    }
+    if !Drawn(result.Draw) || len(result.Entries) != 4 {
+        t.Errorf("repeated run drew %+v from %d entries, want all 4", result.Draw, len(result.Entries))
+    }
+
+    entries, _ := store.GetEntriesByDraw(ctx, req.DrawID)
+    want := SelectFair(pending[0].Seed, entries, 2)
+    if got := fmt.Sprint(entryIDs(result.WinningEntries)); got != fmt.Sprint(entryIDs(want)) {
+        t.Errorf("repeated run picked %s, want %s from the stored seed", got, entryIDs(want))
+    }
+    winners, _ := store.GetWinnersByDate(ctx, time.Now())
+    if len(winners) != 2 {
+        t.Errorf("%d winners stored, want 2", len(winners))
+    }
+    statuses := make(map[string]int)
+    for _, entry := range entries {
+        statuses[entry.Status]++
+    }
+    if statuses["winner"] != 2 || statuses["expired"] != 2 {
+        t.Errorf("entry statuses = %v, want 2 winners and 2 expired", statuses)
+    }
+
+    if _, err := engine.Run(ctx, req); err != ErrDrawExists {
+        t.Errorf("third Run = %v, want ErrDrawExists", err)
+    }
+}
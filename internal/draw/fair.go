@@ -0,0 +1,120 @@
+package draw
+
+import (
+    crand "crypto/rand"
+    "crypto/sha256"
+    "encoding/binary"
+    "encoding/csv"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "sort"
+    "strconv"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+// Random draws are provably fair through commit-reveal: a secret seed is
+// chosen when a draw opens and only its hash is published. At draw time the
+// seed is revealed and the winners follow from it and a hash of the entry
+// list, so anyone can check that the seed was fixed before any entry was
+// made and recompute the result from the entry file /verify sends. The file
+// holds entry IDs and lucky numbers only; the unique codes stay private.
+
+// NewSeed returns a fresh secret seed for a draw, hex encoded
+func NewSeed() (string, error) {
+    b := make([]byte, 32)
+    if _, err := crand.Read(b); err != nil {
+        return "", fmt.Errorf("failed to generate seed: %v", err)
+    }
+    return hex.EncodeToString(b), nil
+}
+
+// HashSeed returns the commitment published for a seed: its SHA-256, hex
+// encoded
+func HashSeed(seed string) string {
+    sum := sha256.Sum256([]byte(seed))
+    return hex.EncodeToString(sum[:])
+}
+
+// HashEntries returns the SHA-256 of the entry list, hex encoded: one
+// "<entry ID>,<lucky number>" line per entry, sorted by entry ID and each
+// ending in a newline
+func HashEntries(entries []*models.LotteryEntry) string {
+    h := sha256.New()
+    for _, entry := range byEntryID(entries) {
+        fmt.Fprintf(h, "%s,%d\n", entry.EntryID, entry.LuckyNumber)
+    }
+    return hex.EncodeToString(h.Sum(nil))
+}
+
+// SelectFair picks count entries deterministically from seed and the entry
+// list. The entries are sorted by entry ID, and the k-th winner (counting
+// from 0) is the entry at index SHA-256("<seed>:<entries hash>:<k>") mod the
+// number of entries not yet picked, reading the first 8 bytes of the hash
+// as a big-endian integer. The entries hash is HashEntries, so changing the
+// entry list changes every pick.
+func SelectFair(seed string, entries []*models.LotteryEntry, count int) []*models.LotteryEntry {
+    remaining := byEntryID(entries)
+    entriesHash := HashEntries(entries)
+
+    var selected []*models.LotteryEntry
+    for k := 0; k < count && len(remaining) > 0; k++ {
+        sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", seed, entriesHash, k)))
+        i := binary.BigEndian.Uint64(sum[:8]) % uint64(len(remaining))
+        selected = append(selected, remaining[i])
+        remaining = append(remaining[:i], remaining[i+1:]...)
+    }
+
+    return selected
+}
+
+// byEntryID returns a copy of entries sorted by entry ID
+func byEntryID(entries []*models.LotteryEntry) []*models.LotteryEntry {
+    sorted := make([]*models.LotteryEntry, len(entries))
+    copy(sorted, entries)
+    sort.Slice(sorted, func(i, j int) bool {
+        return sorted[i].EntryID < sorted[j].EntryID
+    })
+    return sorted
+}
+
+var entryFileHeader = []string{"entry_id", "lucky_number"}
+
+// WriteEntries writes a draw's entries as the CSV file that /verify sends
+// and cmd/verifydraw reads. Unique codes are left out since anyone can run
+// /verify.
+func WriteEntries(w io.Writer, entries []*models.LotteryEntry) error {
+    cw := csv.NewWriter(w)
+    cw.Write(entryFileHeader)
+    for _, entry := range byEntryID(entries) {
+        cw.Write([]string{entry.EntryID, strconv.Itoa(entry.LuckyNumber)})
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+// ReadEntries reads an entry file written by WriteEntries
+func ReadEntries(r io.Reader) ([]*models.LotteryEntry, error) {
+    records, err := csv.NewReader(r).ReadAll()
+    if err != nil {
+        return nil, fmt.Errorf("failed to read entry file: %v", err)
+    }
+    if len(records) == 0 || len(records[0]) != len(entryFileHeader) || records[0][0] != entryFileHeader[0] {
+        return nil, fmt.Errorf("not an entry file: missing %q header", "entry_id")
+    }
+
+    var entries []*models.LotteryEntry
+    for i, record := range records[1:] {
+        number, err := strconv.Atoi(record[1])
+        if err != nil {
+            return nil, fmt.Errorf("line %d: bad lucky number %q", i+2, record[1])
+        }
+        entries = append(entries, &models.LotteryEntry{
+            EntryID:     record[0],
+            LuckyNumber: number,
+        })
+    }
+
+    return entries, nil
+}
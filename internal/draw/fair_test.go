@@ -0,0 +1,125 @@
+package draw
+
+import (
+    "bytes"
+    "fmt"
+    "strings"
+    "testing"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+func fairEntries(n int) []*models.LotteryEntry {
+    var entries []*models.LotteryEntry
+    for i := 0; i < n; i++ {
+        entries = append(entries, &models.LotteryEntry{
+            EntryID:     fmt.Sprintf("ENTRY%02d", i),
+            UniqueCode:  fmt.Sprintf("CODE%02d", i),
+            LuckyNumber: i + 1,
+        })
+    }
+    return entries
+}
+
+func entryIDs(entries []*models.LotteryEntry) []string {
+    var ids []string
+    for _, entry := range entries {
+        ids = append(ids, entry.EntryID)
+    }
+    return ids
+}
+
+func TestHashSeed(t *testing.T) {
+    if got := HashSeed("12345"); got != "5994471abb01112afcc18159f6cc74b4f511b99806da59b3caf5a9c173cacfc5" {
+        t.Errorf("HashSeed = %s", got)
+    }
+
+    seed, err := NewSeed()
+    if err != nil {
+        t.Fatalf("NewSeed: %v", err)
+    }
+    if other, _ := NewSeed(); len(seed) != 64 || other == seed {
+        t.Errorf("NewSeed = %q, %q", seed, other)
+    }
+}
+
+func TestSelectFairIsDeterministic(t *testing.T) {
+    entries := fairEntries(10)
+    want := fmt.Sprint(entryIDs(SelectFair("seed", entries, 3)))
+
+    // The input order does not matter, only the seed and the entries
+    reversed := make([]*models.LotteryEntry, len(entries))
+    for i, entry := range entries {
+        reversed[len(entries)-1-i] = entry
+    }
+    if got := fmt.Sprint(entryIDs(SelectFair("seed", reversed, 3))); got != want {
+        t.Errorf("SelectFair on reversed entries = %s, want %s", got, want)
+    }
+
+    if got := fmt.Sprint(entryIDs(SelectFair("other", entries, 3))); got == want {
+        t.Errorf("different seeds picked the same winners %s", got)
+    }
+
+    winners := SelectFair("seed", entries, 3)
+    seen := make(map[string]bool)
+    for _, winner := range winners {
+        if seen[winner.EntryID] {
+            t.Errorf("%s picked twice", winner.EntryID)
+        }
+        seen[winner.EntryID] = true
+    }
+    if len(SelectFair("seed", entries[:2], 5)) != 2 {
+        t.Errorf("SelectFair picked more winners than entries")
+    }
+}
+
+func TestHashEntries(t *testing.T) {
+    entries := fairEntries(3)
+    want := HashEntries(entries)
+
+    reordered := []*models.LotteryEntry{entries[2], entries[0], entries[1]}
+    if got := HashEntries(reordered); got != want {
+        t.Errorf("HashEntries depends on the input order: %s, want %s", got, want)
+    }
+
+    // Any change to the list changes the hash mixed into every pick
+    changed := fairEntries(3)
+    changed[1].LuckyNumber = 99
+    if HashEntries(changed) == want || HashEntries(entries[:2]) == want {
+        t.Errorf("HashEntries ignored a change to the entry list")
+    }
+
+    // Unique codes are not in the entry file, so they must not count
+    changed = fairEntries(3)
+    changed[0].UniqueCode = "OTHER"
+    if got := HashEntries(changed); got != want {
+        t.Errorf("HashEntries depends on unique codes")
+    }
+}
+
+func TestEntryFileRoundTrip(t *testing.T) {
+    entries := fairEntries(4)
+
+    var buf bytes.Buffer
+    if err := WriteEntries(&buf, entries); err != nil {
+        t.Fatalf("WriteEntries: %v", err)
+    }
+    file := buf.String()
+    read, err := ReadEntries(&buf)
+    if err != nil {
+        t.Fatalf("ReadEntries: %v", err)
+    }
+    if len(read) != 4 || read[2].EntryID != "ENTRY02" || read[2].LuckyNumber != 3 {
+        t.Errorf("ReadEntries = %+v", read)
+    }
+    if strings.Contains(file, "CODE") || read[2].UniqueCode != "" {
+        t.Errorf("entry file exposes unique codes")
+    }
+    if HashEntries(read) != HashEntries(entries) {
+        t.Errorf("entries read back hash differently")
+    }
+
+    if _, err := ReadEntries(bytes.NewBufferString("User ID,Username\n1,a\n")); err == nil {
+        t.Errorf("ReadEntries accepted a file without the entry header")
+    }
+}
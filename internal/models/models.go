@@ -22,17 +22,25 @@ type LotteryEntry struct {
     LuckyNumber  int       `json:"lucky_number"`
     EntryDate    time.Time `json:"entry_date"`
     EntryTime    time.Time `json:"entry_time"`
-    Status       string    `json:"status"` // active/winner/expired
+    Status       string    `json:"status"` // pending/active/winner/expired/rejected/refunded
+    DrawID       string    `json:"draw_id,omitempty"`
 }
 
 // Transaction represents a payment transaction
 type Transaction struct {
-    TransactionID string    `json:"transaction_id"`
-    UserID        int64     `json:"user_id"`
-    Amount        float64   `json:"amount"`
-    Date          time.Time `json:"date"`
-    Time          time.Time `json:"time"`
-    Status        string    `json:"status"` // pending/verified/rejected
+    TransactionID    string    `json:"transaction_id"`
+    UserID           int64     `json:"user_id"`
+    Amount           float64   `json:"amount"`
+    Date             time.Time `json:"date"`
+    Time             time.Time `json:"time"`
+    Status           string    `json:"status"` // pending/verified/rejected/refunded
+    UniqueCode       string    `json:"unique_code,omitempty"`        // payment note on the UPI QR
+    ScreenshotFileID string    `json:"screenshot_file_id,omitempty"` // Telegram file ID
+    ScreenshotType   string    `json:"screenshot_type,omitempty"`    // photo/document
+    Provider         string    `json:"provider,omitempty"`           // payment gateway; empty for manual review
+    ProviderRef      string    `json:"provider_ref,omitempty"`       // gateway order ID
+    RefundRef        string    `json:"refund_ref,omitempty"`         // bank/UPI or gateway refund reference
+    RefundedAt       time.Time `json:"refunded_at,omitempty"`
 }
 
 // Winner represents a lottery winner
@@ -45,6 +53,27 @@ type Winner struct {
     Time                time.Time `json:"time"`
     PaymentStatus       string    `json:"payment_status"` // pending/completed
     PaymentTransactionID string    `json:"payment_transaction_id"`
+    DrawID              string    `json:"draw_id,omitempty"`
+}
+
+// Draw is one round for a ticket tier. Entries join the open draw of
+// their tier; the draw is then closed to new entries and drawn.
+type Draw struct {
+    DrawID                string    `json:"draw_id"`
+    TicketAmount          float64   `json:"ticket_amount"`
+    Status                string    `json:"status"` // open/closed/drawn
+    Method                string    `json:"method"`
+    WinnerCount           int       `json:"winner_count"`
+    Seed                  string    `json:"seed,omitempty"`      // kept secret until the draw is drawn
+    SeedHash              string    `json:"seed_hash,omitempty"` // SHA-256 of Seed, published when the draw opens; empty if nothing was committed
+    Date                  time.Time `json:"date"`           // scheduled or actual draw time
+    OpenedAt              time.Time `json:"opened_at"`
+    ClosedAt              time.Time `json:"closed_at,omitempty"`
+    DrawnAt               time.Time `json:"drawn_at,omitempty"`
+    EntryCount            int       `json:"entry_count"`
+    WinningNumbers        []int     `json:"winning_numbers"`
+    AnnouncementChatID    int64     `json:"announcement_chat_id,omitempty"`
+    AnnouncementMessageID int       `json:"announcement_message_id,omitempty"` // result post in the proof channel
 }
 
 // UserState represents the current state of a user in the bot workflow
@@ -54,6 +83,10 @@ type UserState struct {
     SelectedAmount   float64   `json:"selected_amount,omitempty"`
     TransactionID    string    `json:"transaction_id,omitempty"`
     UniqueCode       string    `json:"unique_code,omitempty"`
+    WinnerCount      int       `json:"winner_count,omitempty"`
+    WinnerID         string    `json:"winner_id,omitempty"` // payout awaiting a reference
+    ScreenshotFileID string    `json:"screenshot_file_id,omitempty"`
+    ScreenshotType   string    `json:"screenshot_type,omitempty"`
     InvalidAttempts  int       `json:"invalid_attempts"`
     LastUpdated      time.Time `json:"last_updated"`
 }
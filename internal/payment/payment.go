@@ -0,0 +1,74 @@
+// Package payment abstracts the gateways that collect ticket payments so
+// the bot can create per-order payment requests and confirm them without
+// an admin checking every Transaction ID by hand.
+package payment
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "time"
+)
+
+// Order statuses reported by Verify
+const (
+    StatusCreated = "created" // intent exists, money not received yet
+    StatusPaid    = "paid"
+    StatusFailed  = "failed"
+)
+
+var (
+    ErrUnknownOrder = errors.New("unknown order")
+    ErrNotSupported = errors.New("operation not supported by provider")
+)
+
+// IntentRequest describes one ticket purchase
+type IntentRequest struct {
+    OrderID      string // our reference; also used as the Transaction ID
+    Amount       float64
+    UserID       int64
+    CustomerName string
+    Description  string
+}
+
+// Intent is what the user needs to pay for one order
+type Intent struct {
+    OrderID     string
+    ProviderRef string // the gateway's own order ID
+    Amount      float64
+    PaymentURL  string // page the user opens to pay, if any
+    UPIIntent   string // upi://pay deep link, if any
+    QRCode      []byte // PNG image, if the provider renders one
+    ExpiresAt   time.Time
+}
+
+// Verification is the gateway's view of an order
+type Verification struct {
+    OrderID     string
+    Status      string
+    Amount      float64
+    ProviderRef string // payment reference, e.g. the UPI UTR
+}
+
+// Refund is the result of a refund request
+type Refund struct {
+    OrderID  string
+    RefundID string
+    Amount   float64
+    Status   string
+}
+
+// Provider collects payments for ticket orders
+type Provider interface {
+    Name() string
+    CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+    Verify(ctx context.Context, orderID string) (*Verification, error)
+    Refund(ctx context.Context, orderID string, amount float64) (*Refund, error)
+}
+
+// CallbackParser is implemented by providers whose gateway notifies us when
+// an order changes. ParseCallback only extracts the order ID; callers confirm
+// the payment with Verify rather than trusting the request body.
+type CallbackParser interface {
+    ParseCallback(r *http.Request) (orderID string, err error)
+}
@@ -0,0 +1,62 @@
+// Package upi builds UPI payment links and renders them as QR codes, so
+// each ticket can be paid with its own amount and note.
+package upi
+
+import (
+    "fmt"
+    "net/url"
+    "regexp"
+    "strconv"
+    "strings"
+
+    qrcode "github.com/skip2/go-qrcode"
+)
+
+// QRSize is the edge length of generated QR images in pixels
+const QRSize = 512
+
+var validVPA = regexp.MustCompile(`^[A-Za-z0-9._-]{2,256}@[A-Za-z][A-Za-z0-9.-]{1,64}$`)
+
+// ValidVPA reports whether vpa looks like a UPI ID such as "shop@okicici"
+func ValidVPA(vpa string) bool {
+    return validVPA.MatchString(vpa)
+}
+
+// Payment describes one UPI payment request
+type Payment struct {
+    VPA       string // payee UPI ID
+    PayeeName string
+    Amount    float64
+    Note      string // shown to the payer and on the payee's statement
+}
+
+// URI returns the upi://pay link for p
+func (p Payment) URI() string {
+    params := []string{"pa=" + p.VPA}
+    if p.PayeeName != "" {
+        params = append(params, "pn="+escape(p.PayeeName))
+    }
+    params = append(params,
+        "am="+strconv.FormatFloat(p.Amount, 'f', 2, 64),
+        "cu=INR",
+    )
+    if p.Note != "" {
+        params = append(params, "tn="+escape(p.Note))
+    }
+    return "upi://pay?" + strings.Join(params, "&")
+}
+
+// QRCode renders the payment link as a PNG image
+func (p Payment) QRCode() ([]byte, error) {
+    png, err := qrcode.Encode(p.URI(), qrcode.Medium, QRSize)
+    if err != nil {
+        return nil, fmt.Errorf("failed to render UPI QR code: %v", err)
+    }
+    return png, nil
+}
+
+// escape percent-encodes a parameter value; several UPI apps show a literal
+// "+" for spaces, so they are sent as %20
+func escape(s string) string {
+    return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,64 @@
+package upi
+
+import (
+    "bytes"
+    "image/png"
+    "net/url"
+    "testing"
+)
+
+func TestURI(t *testing.T) {
+    p := Payment{VPA: "lottery@okaxis", PayeeName: "Lucky Draw & Co", Amount: 100, Note: "LC1710500000"}
+
+    want := "upi://pay?pa=lottery@okaxis&pn=Lucky%20Draw%20%26%20Co&am=100.00&cu=INR&tn=LC1710500000"
+    if got := p.URI(); got != want {
+        t.Errorf("URI() = %q, want %q", got, want)
+    }
+
+    u, err := url.Parse(p.URI())
+    if err != nil {
+        t.Fatalf("URI does not parse: %v", err)
+    }
+    q := u.Query()
+    if q.Get("pn") != "Lucky Draw & Co" || q.Get("tn") != "LC1710500000" || q.Get("am") != "100.00" {
+        t.Errorf("parsed query = %v", q)
+    }
+}
+
+func TestURIOmitsEmptyFields(t *testing.T) {
+    got := Payment{VPA: "a@upi", Amount: 49.5}.URI()
+    if want := "upi://pay?pa=a@upi&am=49.50&cu=INR"; got != want {
+        t.Errorf("URI() = %q, want %q", got, want)
+    }
+}
+
+func TestQRCode(t *testing.T) {
+    data, err := Payment{VPA: "lottery@okaxis", Amount: 100, Note: "LC1"}.QRCode()
+    if err != nil {
+        t.Fatalf("QRCode: %v", err)
+    }
+
+    img, err := png.Decode(bytes.NewReader(data))
+    if err != nil {
+        t.Fatalf("QRCode is not a PNG: %v", err)
+    }
+    if size := img.Bounds().Dx(); size != QRSize {
+        t.Errorf("QR width = %d, want %d", size, QRSize)
+    }
+}
+
+func TestValidVPA(t *testing.T) {
+    for vpa, want := range map[string]bool{
+        "lottery@okaxis":   true,
+        "9876543210@paytm": true,
+        "first.last-1@ybl": true,
+        "no-at-sign":       false,
+        "a b@upi":          false,
+        "x@upi&am=1":       false,
+        "@upi":             false,
+    } {
+        if got := ValidVPA(vpa); got != want {
+            t.Errorf("ValidVPA(%q) = %v, want %v", vpa, got, want)
+        }
+    }
+}
@@ -0,0 +1,211 @@
+// Package upigateway is a payment.Provider for hosted UPI gateways that
+// create an order per purchase and call a webhook once it is paid.
+//
+// The gateway API is JSON over HTTPS, authenticated with an API key:
+//
+//	POST /api/create_order       {key, client_txn_id, amount, p_info, customer_name, redirect_url}
+//	POST /api/check_order_status {key, client_txn_id}
+//	POST /api/refund             {key, client_txn_id, amount}
+//
+// Every response is {status, msg, data}. Webhooks are form posts carrying
+// at least client_txn_id; they are not signed, so the bot re-checks the
+// order status before trusting them.
+package upigateway
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+)
+
+const maxResponseSize = 1 << 20
+
+type Config struct {
+    BaseURL     string
+    APIKey      string
+    RedirectURL string // where the payment page sends the user afterwards
+
+    // HTTPClient defaults to a client with a 15 second timeout
+    HTTPClient *http.Client
+}
+
+type Provider struct {
+    baseURL     string
+    apiKey      string
+    redirectURL string
+    client      *http.Client
+}
+
+func New(cfg Config) *Provider {
+    client := cfg.HTTPClient
+    if client == nil {
+        client = &http.Client{Timeout: 15 * time.Second}
+    }
+
+    return &Provider{
+        baseURL:     strings.TrimRight(cfg.BaseURL, "/"),
+        apiKey:      cfg.APIKey,
+        redirectURL: cfg.RedirectURL,
+        client:      client,
+    }
+}
+
+func (p *Provider) Name() string {
+    return "upigateway"
+}
+
+type response struct {
+    Status bool            `json:"status"`
+    Msg    string          `json:"msg"`
+    Data   json.RawMessage `json:"data"`
+}
+
+type createOrderData struct {
+    OrderID    string `json:"order_id"`
+    PaymentURL string `json:"payment_url"`
+    UPIIntent  string `json:"upi_intent"`
+}
+
+type orderStatusData struct {
+    ClientTxnID string `json:"client_txn_id"`
+    Status      string `json:"status"` // created/success/failure
+    Amount      string `json:"amount"`
+    UPITxnID    string `json:"upi_txn_id"`
+}
+
+type refundData struct {
+    RefundID string `json:"refund_id"`
+    Status   string `json:"status"`
+}
+
+func (p *Provider) CreateIntent(ctx context.Context, req payment.IntentRequest) (*payment.Intent, error) {
+    var data createOrderData
+    err := p.call(ctx, "/api/create_order", map[string]string{
+        "key":           p.apiKey,
+        "client_txn_id": req.OrderID,
+        "amount":        formatAmount(req.Amount),
+        "p_info":        req.Description,
+        "customer_name": req.CustomerName,
+        "redirect_url":  p.redirectURL,
+    }, &data)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create order %s: %w", req.OrderID, err)
+    }
+
+    return &payment.Intent{
+        OrderID:     req.OrderID,
+        ProviderRef: data.OrderID,
+        Amount:      req.Amount,
+        PaymentURL:  data.PaymentURL,
+        UPIIntent:   data.UPIIntent,
+    }, nil
+}
+
+func (p *Provider) Verify(ctx context.Context, orderID string) (*payment.Verification, error) {
+    var data orderStatusData
+    err := p.call(ctx, "/api/check_order_status", map[string]string{
+        "key":           p.apiKey,
+        "client_txn_id": orderID,
+    }, &data)
+    if err != nil {
+        return nil, fmt.Errorf("failed to check order %s: %w", orderID, err)
+    }
+
+    amount, _ := strconv.ParseFloat(data.Amount, 64)
+    v := &payment.Verification{
+        OrderID:     orderID,
+        Amount:      amount,
+        ProviderRef: data.UPITxnID,
+    }
+    switch data.Status {
+    case "success":
+        v.Status = payment.StatusPaid
+    case "failure":
+        v.Status = payment.StatusFailed
+    default:
+        v.Status = payment.StatusCreated
+    }
+    return v, nil
+}
+
+func (p *Provider) Refund(ctx context.Context, orderID string, amount float64) (*payment.Refund, error) {
+    var data refundData
+    err := p.call(ctx, "/api/refund", map[string]string{
+        "key":           p.apiKey,
+        "client_txn_id": orderID,
+        "amount":        formatAmount(amount),
+    }, &data)
+    if err != nil {
+        return nil, fmt.Errorf("failed to refund order %s: %w", orderID, err)
+    }
+
+    return &payment.Refund{
+        OrderID:  orderID,
+        RefundID: data.RefundID,
+        Amount:   amount,
+        Status:   data.Status,
+    }, nil
+}
+
+// ParseCallback reads the order ID from a gateway webhook
+func (p *Provider) ParseCallback(r *http.Request) (string, error) {
+    r.Body = http.MaxBytesReader(nil, r.Body, maxResponseSize)
+    if err := r.ParseForm(); err != nil {
+        return "", fmt.Errorf("invalid callback: %v", err)
+    }
+
+    orderID := r.PostForm.Get("client_txn_id")
+    if orderID == "" {
+        return "", fmt.Errorf("callback has no client_txn_id")
+    }
+    return orderID, nil
+}
+
+func (p *Provider) call(ctx context.Context, path string, body map[string]string, data interface{}) error {
+    payload, err := json.Marshal(body)
+    if err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := p.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    var result response
+    if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&result); err != nil {
+        return fmt.Errorf("invalid gateway response (HTTP %d): %v", resp.StatusCode, err)
+    }
+    if !result.Status {
+        if strings.Contains(strings.ToLower(result.Msg), "not found") {
+            return fmt.Errorf("%w: %s", payment.ErrUnknownOrder, result.Msg)
+        }
+        return fmt.Errorf("gateway error: %s", result.Msg)
+    }
+
+    if data != nil && len(result.Data) > 0 {
+        if err := json.Unmarshal(result.Data, data); err != nil {
+            return fmt.Errorf("invalid gateway data: %v", err)
+        }
+    }
+    return nil
+}
+
+func formatAmount(amount float64) string {
+    return strconv.FormatFloat(amount, 'f', 2, 64)
+}
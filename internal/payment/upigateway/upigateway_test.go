@@ -0,0 +1,107 @@
+package upigateway
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/gsshankar104/telegram-bot/internal/payment"
+    "github.com/gsshankar104/telegram-bot/internal/payment/upigateway/upigatewaytest"
+)
+
+func TestOrderLifecycle(t *testing.T) {
+    server, gateway := upigatewaytest.NewServer("secret")
+    defer server.Close()
+
+    ctx := context.Background()
+    p := New(Config{BaseURL: server.URL + "/", APIKey: "secret"})
+
+    intent, err := p.CreateIntent(ctx, payment.IntentRequest{OrderID: "ORD1", Amount: 100, Description: "ticket"})
+    if err != nil {
+        t.Fatalf("CreateIntent: %v", err)
+    }
+    if intent.ProviderRef == "" || !strings.Contains(intent.PaymentURL, "/pay/ORD1") || !strings.Contains(intent.UPIIntent, "am=100.00") {
+        t.Errorf("intent = %+v", intent)
+    }
+
+    if _, err := p.CreateIntent(ctx, payment.IntentRequest{OrderID: "ORD1", Amount: 100}); err == nil {
+        t.Errorf("CreateIntent with a reused order ID succeeded")
+    }
+
+    v, err := p.Verify(ctx, "ORD1")
+    if err != nil {
+        t.Fatalf("Verify: %v", err)
+    }
+    if v.Status != payment.StatusCreated {
+        t.Errorf("status before payment = %q", v.Status)
+    }
+
+    if _, err := p.Refund(ctx, "ORD1", 100); err == nil {
+        t.Errorf("Refund of an unpaid order succeeded")
+    }
+
+    if err := gateway.Pay("ORD1"); err != nil {
+        t.Fatalf("Pay: %v", err)
+    }
+    v, err = p.Verify(ctx, "ORD1")
+    if err != nil {
+        t.Fatalf("Verify: %v", err)
+    }
+    if v.Status != payment.StatusPaid || v.Amount != 100 || len(v.ProviderRef) != 12 {
+        t.Errorf("verification after payment = %+v", v)
+    }
+
+    refund, err := p.Refund(ctx, "ORD1", 100)
+    if err != nil {
+        t.Fatalf("Refund: %v", err)
+    }
+    if refund.RefundID == "" || refund.Amount != 100 {
+        t.Errorf("refund = %+v", refund)
+    }
+    if _, err := p.Refund(ctx, "ORD1", 1); err == nil {
+        t.Errorf("refund beyond the order amount succeeded")
+    }
+}
+
+func TestVerifyUnknownOrder(t *testing.T) {
+    server, _ := upigatewaytest.NewServer("secret")
+    defer server.Close()
+
+    _, err := New(Config{BaseURL: server.URL, APIKey: "secret"}).Verify(context.Background(), "missing")
+    if !errors.Is(err, payment.ErrUnknownOrder) {
+        t.Errorf("Verify(missing) = %v, want ErrUnknownOrder", err)
+    }
+}
+
+func TestWrongAPIKey(t *testing.T) {
+    server, _ := upigatewaytest.NewServer("secret")
+    defer server.Close()
+
+    _, err := New(Config{BaseURL: server.URL, APIKey: "wrong"}).CreateIntent(context.Background(),
+        payment.IntentRequest{OrderID: "ORD1", Amount: 100})
+    if err == nil || !strings.Contains(err.Error(), "invalid API key") {
+        t.Errorf("CreateIntent with wrong key = %v", err)
+    }
+}
+
+func TestParseCallback(t *testing.T) {
+    p := New(Config{})
+
+    form := url.Values{"client_txn_id": {"ORD1"}, "status": {"success"}}
+    r := httptest.NewRequest(http.MethodPost, "/payment/callback", strings.NewReader(form.Encode()))
+    r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    orderID, err := p.ParseCallback(r)
+    if err != nil || orderID != "ORD1" {
+        t.Errorf("ParseCallback = %q, %v; want ORD1", orderID, err)
+    }
+
+    r = httptest.NewRequest(http.MethodPost, "/payment/callback", strings.NewReader("status=success"))
+    r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    if _, err := p.ParseCallback(r); err == nil {
+        t.Errorf("ParseCallback without client_txn_id succeeded")
+    }
+}
@@ -0,0 +1,250 @@
+// Package upigatewaytest is a local stand-in for a UPI gateway. It speaks
+// the API the upigateway provider expects, keeps orders in memory and posts
+// webhooks when an order is paid, so payment flows can be exercised in
+// tests and against a local bot without real money.
+package upigatewaytest
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// Order is the stub's record of one order
+type Order struct {
+    ClientTxnID string
+    OrderID     string
+    Amount      float64
+    Status      string // created/success/failure
+    UPITxnID    string
+    Refunded    float64
+}
+
+// Gateway is an http.Handler implementing the stub gateway
+type Gateway struct {
+    APIKey string
+
+    // WebhookURL receives a form post whenever an order is paid or fails;
+    // leave empty to disable webhooks
+    WebhookURL string
+
+    mutex  sync.Mutex
+    orders map[string]*Order
+    nextID int
+    mux    *http.ServeMux
+}
+
+func NewGateway(apiKey string) *Gateway {
+    g := &Gateway{
+        APIKey: apiKey,
+        orders: make(map[string]*Order),
+        mux:    http.NewServeMux(),
+    }
+    g.mux.HandleFunc("/api/create_order", g.handleCreateOrder)
+    g.mux.HandleFunc("/api/check_order_status", g.handleCheckOrderStatus)
+    g.mux.HandleFunc("/api/refund", g.handleRefund)
+    g.mux.HandleFunc("/pay/", g.handlePayPage)
+    return g
+}
+
+// NewServer starts the stub on a local port; close it when done
+func NewServer(apiKey string) (*httptest.Server, *Gateway) {
+    g := NewGateway(apiKey)
+    return httptest.NewServer(g), g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    g.mux.ServeHTTP(w, r)
+}
+
+// Order returns a copy of the order, or false if it does not exist
+func (g *Gateway) Order(clientTxnID string) (Order, bool) {
+    g.mutex.Lock()
+    defer g.mutex.Unlock()
+
+    order, ok := g.orders[clientTxnID]
+    if !ok {
+        return Order{}, false
+    }
+    return *order, true
+}
+
+// Pay marks an order paid and sends the webhook
+func (g *Gateway) Pay(clientTxnID string) error {
+    return g.settle(clientTxnID, "success")
+}
+
+// Fail marks an order failed and sends the webhook
+func (g *Gateway) Fail(clientTxnID string) error {
+    return g.settle(clientTxnID, "failure")
+}
+
+func (g *Gateway) settle(clientTxnID, status string) error {
+    g.mutex.Lock()
+    order, ok := g.orders[clientTxnID]
+    if !ok {
+        g.mutex.Unlock()
+        return fmt.Errorf("order %s not found", clientTxnID)
+    }
+    order.Status = status
+    if status == "success" && order.UPITxnID == "" {
+        // UTRs are 12 digits; derive one from the order number
+        n, _ := strconv.Atoi(order.OrderID)
+        order.UPITxnID = fmt.Sprintf("4%011d", n)
+    }
+    snapshot := *order
+    webhookURL := g.WebhookURL
+    g.mutex.Unlock()
+
+    if webhookURL == "" {
+        return nil
+    }
+
+    resp, err := http.PostForm(webhookURL, url.Values{
+        "client_txn_id": {snapshot.ClientTxnID},
+        "status":        {snapshot.Status},
+        "amount":        {formatAmount(snapshot.Amount)},
+        "upi_txn_id":    {snapshot.UPITxnID},
+    })
+    if err != nil {
+        return fmt.Errorf("webhook failed: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+    }
+    return nil
+}
+
+func (g *Gateway) handleCreateOrder(w http.ResponseWriter, r *http.Request) {
+    req, ok := g.decode(w, r)
+    if !ok {
+        return
+    }
+
+    amount, err := strconv.ParseFloat(req["amount"], 64)
+    if err != nil || amount <= 0 || req["client_txn_id"] == "" {
+        reply(w, false, "invalid order", nil)
+        return
+    }
+
+    g.mutex.Lock()
+    if _, exists := g.orders[req["client_txn_id"]]; exists {
+        g.mutex.Unlock()
+        reply(w, false, "client_txn_id already used", nil)
+        return
+    }
+    g.nextID++
+    order := &Order{
+        ClientTxnID: req["client_txn_id"],
+        OrderID:     strconv.Itoa(g.nextID),
+        Amount:      amount,
+        Status:      "created",
+    }
+    g.orders[order.ClientTxnID] = order
+    g.mutex.Unlock()
+
+    reply(w, true, "Order created", map[string]string{
+        "order_id":    order.OrderID,
+        "payment_url": fmt.Sprintf("http://%s/pay/%s", r.Host, url.PathEscape(order.ClientTxnID)),
+        "upi_intent":  fmt.Sprintf("upi://pay?pa=stub@upi&pn=Stub&am=%s&tr=%s", formatAmount(amount), url.QueryEscape(order.ClientTxnID)),
+    })
+}
+
+func (g *Gateway) handleCheckOrderStatus(w http.ResponseWriter, r *http.Request) {
+    req, ok := g.decode(w, r)
+    if !ok {
+        return
+    }
+
+    order, found := g.Order(req["client_txn_id"])
+    if !found {
+        reply(w, false, "Order not found", nil)
+        return
+    }
+
+    reply(w, true, "Order status", map[string]string{
+        "client_txn_id": order.ClientTxnID,
+        "status":        order.Status,
+        "amount":        formatAmount(order.Amount),
+        "upi_txn_id":    order.UPITxnID,
+    })
+}
+
+func (g *Gateway) handleRefund(w http.ResponseWriter, r *http.Request) {
+    req, ok := g.decode(w, r)
+    if !ok {
+        return
+    }
+
+    amount, err := strconv.ParseFloat(req["amount"], 64)
+    if err != nil || amount <= 0 {
+        reply(w, false, "invalid amount", nil)
+        return
+    }
+
+    g.mutex.Lock()
+    defer g.mutex.Unlock()
+
+    order, found := g.orders[req["client_txn_id"]]
+    switch {
+    case !found:
+        reply(w, false, "Order not found", nil)
+    case order.Status != "success":
+        reply(w, false, "order is not paid", nil)
+    case order.Refunded+amount > order.Amount+0.005:
+        reply(w, false, "refund exceeds order amount", nil)
+    default:
+        order.Refunded += amount
+        reply(w, true, "Refund initiated", map[string]string{
+            "refund_id": "RF" + order.OrderID,
+            "status":    "processed",
+        })
+    }
+}
+
+// handlePayPage lets a developer pay an order by opening its payment_url
+func (g *Gateway) handlePayPage(w http.ResponseWriter, r *http.Request) {
+    clientTxnID := strings.TrimPrefix(r.URL.Path, "/pay/")
+    if err := g.Pay(clientTxnID); err != nil {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+    fmt.Fprintf(w, "Stub payment for %s succeeded\n", clientTxnID)
+}
+
+func (g *Gateway) decode(w http.ResponseWriter, r *http.Request) (map[string]string, bool) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return nil, false
+    }
+
+    var req map[string]string
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "invalid JSON", http.StatusBadRequest)
+        return nil, false
+    }
+    if req["key"] != g.APIKey {
+        reply(w, false, "invalid API key", nil)
+        return nil, false
+    }
+    return req, true
+}
+
+func reply(w http.ResponseWriter, status bool, msg string, data interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "status": status,
+        "msg":    msg,
+        "data":   data,
+    })
+}
+
+func formatAmount(amount float64) string {
+    return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,260 @@
+// Package reconcile matches rows of a bank or UPI statement against
+// pending payment transactions.
+package reconcile
+
+import (
+    "encoding/csv"
+    "fmt"
+    "io"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+// Row statuses in the report
+const (
+    StatusMatched    = "matched"
+    StatusMismatch   = "amount_mismatch"
+    StatusUnmatched  = "unmatched_row"
+    StatusNotPaid    = "not_in_statement"
+    StatusSkipped    = "skipped_row"
+    StatusSettleFail = "verify_failed"
+)
+
+// Default header names, matched case-insensitively. The first header in
+// the file that matches any alias is used.
+var (
+    DefaultReferenceColumns = []string{"UTR", "UPI Ref No", "UPI Reference", "Reference", "Ref No", "Transaction ID", "Txn ID"}
+    DefaultAmountColumns    = []string{"Credit", "Deposit", "Amount", "Credit Amount", "Deposit Amt"}
+    DefaultNoteColumns      = []string{"Remarks", "Narration", "Description", "Note", "Particulars"}
+)
+
+// Columns maps statement headers to the fields we need. Empty lists fall
+// back to the defaults.
+type Columns struct {
+    Reference []string
+    Amount    []string
+    Note      []string
+}
+
+// Row is one credit from the statement
+type Row struct {
+    Line      int
+    Reference string
+    Amount    float64
+    Note      string
+}
+
+// Match pairs a statement row with a pending transaction
+type Match struct {
+    Row         Row
+    Transaction *models.Transaction
+    By          string // transaction_id, provider_ref or unique_code
+    Status      string
+    Error       string
+}
+
+// Result is the outcome of matching one statement
+type Result struct {
+    Matched    []*Match
+    Mismatched []*Match
+    Unmatched  []Row
+    Skipped    []Row
+    NotPaid    []*models.Transaction // pending transactions missing from the statement
+}
+
+// Parse reads a CSV statement. Rows without a positive amount, such as
+// debits or balance lines, are returned in skipped.
+func Parse(r io.Reader, cols Columns) (rows, skipped []Row, err error) {
+    reader := csv.NewReader(r)
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+
+    header, err := reader.Read()
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to read statement header: %v", err)
+    }
+
+    refIdx := findColumn(header, cols.Reference, DefaultReferenceColumns)
+    amountIdx := findColumn(header, cols.Amount, DefaultAmountColumns)
+    noteIdx := findColumn(header, cols.Note, DefaultNoteColumns)
+    if amountIdx < 0 {
+        return nil, nil, fmt.Errorf("statement has no amount column (headers: %s)", strings.Join(header, ", "))
+    }
+    if refIdx < 0 && noteIdx < 0 {
+        return nil, nil, fmt.Errorf("statement has no reference or note column (headers: %s)", strings.Join(header, ", "))
+    }
+
+    line := 1
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        line++
+        if err != nil {
+            return nil, nil, fmt.Errorf("line %d: %v", line, err)
+        }
+
+        row := Row{
+            Line:      line,
+            Reference: field(record, refIdx),
+            Note:      field(record, noteIdx),
+        }
+        amount, ok := parseAmount(field(record, amountIdx))
+        if !ok || amount <= 0 {
+            skipped = append(skipped, row)
+            continue
+        }
+        row.Amount = amount
+        rows = append(rows, row)
+    }
+
+    return rows, skipped, nil
+}
+
+// Reconcile matches rows against pending transactions. A transaction is
+// matched by its ID or gateway reference equalling the row's reference, or
+// by its Unique Code appearing in the row's note; each is used once.
+func Reconcile(rows []Row, pending []*models.Transaction) *Result {
+    result := &Result{}
+    used := make(map[string]bool)
+
+    for _, row := range rows {
+        txn, by := find(row, pending, used)
+        if txn == nil {
+            result.Unmatched = append(result.Unmatched, row)
+            continue
+        }
+        used[txn.TransactionID] = true
+
+        match := &Match{Row: row, Transaction: txn, By: by}
+        if math.Abs(row.Amount-txn.Amount) < 0.005 {
+            match.Status = StatusMatched
+            result.Matched = append(result.Matched, match)
+        } else {
+            match.Status = StatusMismatch
+            result.Mismatched = append(result.Mismatched, match)
+        }
+    }
+
+    for _, txn := range pending {
+        if !used[txn.TransactionID] {
+            result.NotPaid = append(result.NotPaid, txn)
+        }
+    }
+
+    return result
+}
+
+func find(row Row, pending []*models.Transaction, used map[string]bool) (*models.Transaction, string) {
+    ref := strings.TrimSpace(row.Reference)
+    note := strings.ToUpper(row.Note)
+
+    for _, txn := range pending {
+        if used[txn.TransactionID] {
+            continue
+        }
+        switch {
+        case ref != "" && strings.EqualFold(ref, txn.TransactionID):
+            return txn, "transaction_id"
+        case ref != "" && txn.ProviderRef != "" && strings.EqualFold(ref, txn.ProviderRef):
+            return txn, "provider_ref"
+        }
+    }
+
+    // Notes are free text, so only fall back to them when no reference matched
+    for _, txn := range pending {
+        if used[txn.TransactionID] || txn.UniqueCode == "" {
+            continue
+        }
+        if note != "" && strings.Contains(note, strings.ToUpper(txn.UniqueCode)) {
+            return txn, "unique_code"
+        }
+    }
+
+    return nil, ""
+}
+
+// WriteReport writes every row and pending transaction with its outcome
+func (r *Result) WriteReport(w io.Writer) error {
+    out := csv.NewWriter(w)
+    out.Write([]string{"Status", "Line", "Reference", "Note", "Statement Amount",
+        "Transaction ID", "User ID", "Expected Amount", "Matched By", "Error"})
+
+    writeMatch := func(m *Match) {
+        out.Write([]string{m.Status, strconv.Itoa(m.Row.Line), m.Row.Reference, m.Row.Note, formatAmount(m.Row.Amount),
+            m.Transaction.TransactionID, strconv.FormatInt(m.Transaction.UserID, 10), formatAmount(m.Transaction.Amount),
+            m.By, m.Error})
+    }
+    writeRow := func(status string, row Row) {
+        out.Write([]string{status, strconv.Itoa(row.Line), row.Reference, row.Note, formatAmount(row.Amount),
+            "", "", "", "", ""})
+    }
+
+    for _, m := range r.Matched {
+        writeMatch(m)
+    }
+    for _, m := range r.Mismatched {
+        writeMatch(m)
+    }
+    for _, row := range r.Unmatched {
+        writeRow(StatusUnmatched, row)
+    }
+    for _, txn := range r.NotPaid {
+        out.Write([]string{StatusNotPaid, "", "", "", "",
+            txn.TransactionID, strconv.FormatInt(txn.UserID, 10), formatAmount(txn.Amount), "", ""})
+    }
+    for _, row := range r.Skipped {
+        writeRow(StatusSkipped, row)
+    }
+
+    out.Flush()
+    return out.Error()
+}
+
+func findColumn(header, aliases, defaults []string) int {
+    if len(aliases) == 0 {
+        aliases = defaults
+    }
+    for _, alias := range aliases {
+        for i, name := range header {
+            if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), strings.TrimSpace(alias)) {
+                return i
+            }
+        }
+    }
+    return -1
+}
+
+func field(record []string, idx int) string {
+    if idx < 0 || idx >= len(record) {
+        return ""
+    }
+    return strings.TrimSpace(record[idx])
+}
+
+// parseAmount accepts values like "1,000.00", "₹ 100", "INR 100" and "100 CR"
+func parseAmount(s string) (float64, bool) {
+    s = strings.ToUpper(strings.TrimSpace(s))
+    for _, junk := range []string{"₹", "INR", "RS.", "RS", ",", "CR", " "} {
+        s = strings.ReplaceAll(s, junk, "")
+    }
+    if s == "" {
+        return 0, false
+    }
+    amount, err := strconv.ParseFloat(s, 64)
+    if err != nil {
+        return 0, false
+    }
+    return amount, true
+}
+
+func formatAmount(amount float64) string {
+    if amount == 0 {
+        return ""
+    }
+    return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,143 @@
+package reconcile
+
+import (
+    "bytes"
+    "encoding/csv"
+    "strings"
+    "testing"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+const statement = "\ufeffDate,Narration,UPI Ref No,Withdrawal,Credit,Balance\n" +
+    "15/03/2024,UPI/alice/payment,412345678901,,100.00,1100.00\n" +
+    "15/03/2024,UPI/bob/LC1710500000002,,,\"1,000.00\",2100.00\n" +
+    "15/03/2024,UPI/carol/ticket,412345678903,,150.00,2250.00\n" +
+    "15/03/2024,UPI/dave/lc1710500000009,,,200,2450.00\n" +
+    "15/03/2024,ATM withdrawal,,500.00,,1950.00\n"
+
+func pending() []*models.Transaction {
+    return []*models.Transaction{
+        {TransactionID: "412345678901", UserID: 1, Amount: 100, UniqueCode: "LC1710500000001"},
+        {TransactionID: "TYPO-BY-USER", UserID: 2, Amount: 1000, UniqueCode: "LC1710500000002"},
+        {TransactionID: "412345678903", UserID: 3, Amount: 200, UniqueCode: "LC1710500000003"},
+        {TransactionID: "ORD99", UserID: 4, Amount: 500, ProviderRef: "GW99"},
+    }
+}
+
+func TestParse(t *testing.T) {
+    rows, skipped, err := Parse(strings.NewReader(statement), Columns{})
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    if len(rows) != 4 || len(skipped) != 1 {
+        t.Fatalf("Parse returned %d rows and %d skipped, want 4 and 1", len(rows), len(skipped))
+    }
+    if rows[0].Reference != "412345678901" || rows[0].Amount != 100 || rows[0].Line != 2 {
+        t.Errorf("rows[0] = %+v", rows[0])
+    }
+    if rows[1].Amount != 1000 || rows[1].Note != "UPI/bob/LC1710500000002" {
+        t.Errorf("rows[1] = %+v", rows[1])
+    }
+    if skipped[0].Line != 6 {
+        t.Errorf("skipped = %+v, want the withdrawal on line 6", skipped)
+    }
+}
+
+func TestParseCustomColumns(t *testing.T) {
+    data := "txn_ref,paid,memo\nGW99,500,\n"
+
+    if _, _, err := Parse(strings.NewReader(data), Columns{}); err == nil {
+        t.Errorf("Parse with default columns accepted unknown headers")
+    }
+
+    rows, _, err := Parse(strings.NewReader(data), Columns{
+        Reference: []string{"TXN_REF"},
+        Amount:    []string{"paid"},
+        Note:      []string{"memo"},
+    })
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    if len(rows) != 1 || rows[0].Reference != "GW99" || rows[0].Amount != 500 {
+        t.Errorf("rows = %+v", rows)
+    }
+}
+
+func TestReconcile(t *testing.T) {
+    rows, _, err := Parse(strings.NewReader(statement), Columns{})
+    if err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+    rows = append(rows, Row{Line: 7, Reference: "gw99", Amount: 500})
+
+    result := Reconcile(rows, pending())
+
+    matched := map[string]string{}
+    for _, m := range result.Matched {
+        matched[m.Transaction.TransactionID] = m.By
+    }
+    want := map[string]string{
+        "412345678901": "transaction_id",
+        "TYPO-BY-USER": "unique_code",
+        "ORD99":        "provider_ref",
+    }
+    for id, by := range want {
+        if matched[id] != by {
+            t.Errorf("%s matched by %q, want %q", id, matched[id], by)
+        }
+    }
+    if len(result.Matched) != len(want) {
+        t.Errorf("matched %v, want %v", matched, want)
+    }
+
+    if len(result.Mismatched) != 1 || result.Mismatched[0].Transaction.TransactionID != "412345678903" {
+        t.Errorf("mismatched = %+v, want 412345678903 (paid 150 of 200)", result.Mismatched)
+    }
+    if len(result.Unmatched) != 1 || result.Unmatched[0].Line != 5 {
+        t.Errorf("unmatched = %+v, want line 5 (unknown code)", result.Unmatched)
+    }
+    if len(result.NotPaid) != 0 {
+        t.Errorf("not paid = %+v, want none", result.NotPaid)
+    }
+}
+
+func TestReconcileUsesEachTransactionOnce(t *testing.T) {
+    rows := []Row{
+        {Line: 2, Reference: "412345678901", Amount: 100},
+        {Line: 3, Reference: "412345678901", Amount: 100},
+    }
+    result := Reconcile(rows, pending())
+
+    if len(result.Matched) != 1 || len(result.Unmatched) != 1 {
+        t.Errorf("matched %d, unmatched %d; want 1 and 1", len(result.Matched), len(result.Unmatched))
+    }
+    if len(result.NotPaid) != 3 {
+        t.Errorf("not paid = %d, want 3", len(result.NotPaid))
+    }
+}
+
+func TestWriteReport(t *testing.T) {
+    rows, skipped, _ := Parse(strings.NewReader(statement), Columns{})
+    result := Reconcile(rows, pending())
+    result.Skipped = skipped
+
+    var buf bytes.Buffer
+    if err := result.WriteReport(&buf); err != nil {
+        t.Fatalf("WriteReport: %v", err)
+    }
+
+    records, err := csv.NewReader(&buf).ReadAll()
+    if err != nil {
+        t.Fatalf("report is not valid CSV: %v", err)
+    }
+
+    counts := map[string]int{}
+    for _, record := range records[1:] {
+        counts[record[0]]++
+    }
+    if counts[StatusMatched] != 2 || counts[StatusMismatch] != 1 || counts[StatusUnmatched] != 1 ||
+        counts[StatusNotPaid] != 1 || counts[StatusSkipped] != 1 {
+        t.Errorf("report rows by status = %v", counts)
+    }
+}
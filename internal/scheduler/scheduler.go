@@ -0,0 +1,237 @@
+// Package scheduler runs automatic draws for each ticket price on a cron
+// schedule and closes sales shortly before each draw.
+package scheduler
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strconv"
+    "sync"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/cron"
+    "github.com/gsshankar104/telegram-bot/internal/draw"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// DefaultCatchUp is how long after its time a missed draw still runs, e.g.
+// when the bot was restarting at the scheduled minute
+const DefaultCatchUp = 6 * time.Hour
+
+// Clock tells the scheduler the time; tests substitute a fake
+type Clock interface {
+    Now() time.Time
+    After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the wall clock
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Schedule is the automatic draw for one ticket price
+type Schedule struct {
+    Price       float64
+    Cron        *cron.Expr
+    Location    *time.Location
+    Method      string
+    WinnerCount int
+    SalesCutoff time.Duration // sales close this long before each draw
+}
+
+// Job is one draw that is due
+type Job struct {
+    DrawID      string
+    Price       float64
+    Method      string
+    WinnerCount int
+    At          time.Time // scheduled draw time
+}
+
+// RunFunc performs a due draw
+type RunFunc func(ctx context.Context, job Job) error
+
+// Scheduler triggers each schedule's draws. Draw IDs are derived from the
+// price and draw time, so a draw that has been drawn is never run again,
+// even across restarts.
+type Scheduler struct {
+    schedules []Schedule
+    storage   storage.Storage
+    run       RunFunc
+    clock     Clock
+    catchUp   time.Duration
+    mutex     sync.Mutex
+}
+
+// New creates a scheduler; a nil clock means the wall clock
+func New(schedules []Schedule, s storage.Storage, run RunFunc, clock Clock) *Scheduler {
+    if clock == nil {
+        clock = RealClock{}
+    }
+    return &Scheduler{
+        schedules: schedules,
+        storage:   s,
+        run:       run,
+        clock:     clock,
+        catchUp:   DefaultCatchUp,
+    }
+}
+
+// DrawID is the stable ID of the draw for price at the given time
+func DrawID(price float64, at time.Time) string {
+    return fmt.Sprintf("DRAW-%s-%s", strconv.FormatFloat(price, 'f', -1, 64), at.UTC().Format("200601021504"))
+}
+
+// Now returns the scheduler clock's time
+func (s *Scheduler) Now() time.Time {
+    return s.clock.Now()
+}
+
+// Run checks for due draws now and then at every scheduled time until ctx
+// is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+    if len(s.schedules) == 0 {
+        return
+    }
+
+    for {
+        s.Tick(ctx)
+
+        now := s.clock.Now()
+        var next time.Time
+        for _, sched := range s.schedules {
+            at := sched.Cron.Next(now.In(sched.Location))
+            if at.IsZero() {
+                continue
+            }
+            // Wake at the sales cutoff too, to close the draw
+            if cutoff := at.Add(-sched.SalesCutoff); sched.SalesCutoff > 0 && cutoff.After(now) {
+                at = cutoff
+            }
+            if next.IsZero() || at.Before(next) {
+                next = at
+            }
+        }
+        if next.IsZero() {
+            return
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-s.clock.After(next.Sub(now)):
+        }
+    }
+}
+
+// Tick runs the latest draw of every schedule whose time has come, unless
+// it has been drawn or is older than the catch-up window, and closes the
+// next draw once its sales cutoff has passed
+func (s *Scheduler) Tick(ctx context.Context) {
+    s.mutex.Lock()
+    defer s.mutex.Unlock()
+
+    now := s.clock.Now()
+    for _, sched := range s.schedules {
+        s.closeSales(ctx, sched, now)
+
+        at := sched.Cron.Prev(now.In(sched.Location).Add(time.Nanosecond))
+        if at.IsZero() || now.Sub(at) > s.catchUp {
+            continue
+        }
+
+        id := DrawID(sched.Price, at)
+        if d, err := s.storage.GetDraw(ctx, id); err == nil && draw.Drawn(d) {
+            continue
+        }
+
+        job := Job{
+            DrawID:      id,
+            Price:       sched.Price,
+            Method:      sched.Method,
+            WinnerCount: sched.WinnerCount,
+            At:          at,
+        }
+        log.Printf("Running scheduled draw %s", id)
+        if err := s.run(ctx, job); err != nil {
+            log.Printf("Scheduled draw %s failed: %v", id, err)
+        }
+    }
+}
+
+// closeSales closes the next draw of sched if its sales cutoff has passed,
+// so entries confirmed from now on join a later draw
+func (s *Scheduler) closeSales(ctx context.Context, sched Schedule, now time.Time) {
+    if sched.SalesCutoff <= 0 {
+        return
+    }
+    at := sched.Cron.Next(now.In(sched.Location))
+    if at.IsZero() || now.Before(at.Add(-sched.SalesCutoff)) {
+        return
+    }
+
+    id := DrawID(sched.Price, at)
+    d, err := s.storage.GetDraw(ctx, id)
+    if err != nil || d.Status != "open" {
+        return
+    }
+    if err := s.storage.CloseDraw(ctx, id, now); err != nil {
+        log.Printf("Failed to close sales for draw %s: %v", id, err)
+        return
+    }
+    log.Printf("Closed sales for draw %s", id)
+}
+
+// NextDraw returns the next draw time for price, if it is scheduled
+func (s *Scheduler) NextDraw(price float64) (time.Time, bool) {
+    sched, ok := s.schedule(price)
+    if !ok {
+        return time.Time{}, false
+    }
+    at := sched.Cron.Next(s.clock.Now().In(sched.Location))
+    return at, !at.IsZero()
+}
+
+// SalesOpen reports whether tickets for price can be bought now. Sales
+// close SalesCutoff before each draw and reopen once its time has passed.
+func (s *Scheduler) SalesOpen(price float64) bool {
+    sched, ok := s.schedule(price)
+    if !ok || sched.SalesCutoff <= 0 {
+        return true
+    }
+    now := s.clock.Now().In(sched.Location)
+    at := sched.Cron.Next(now)
+    return at.IsZero() || now.Before(at.Add(-sched.SalesCutoff))
+}
+
+// EntryDraw returns the time of the draw that a ticket for price confirmed
+// now joins: the next draw, or the one after it once sales have closed
+func (s *Scheduler) EntryDraw(price float64) (time.Time, bool) {
+    at, ok := s.NextDraw(price)
+    if !ok || s.SalesOpen(price) {
+        return at, ok
+    }
+    at = s.DrawAfter(price, at)
+    return at, !at.IsZero()
+}
+
+// DrawAfter returns the first scheduled draw time for price after at, or
+// the zero time if price is not scheduled
+func (s *Scheduler) DrawAfter(price float64, at time.Time) time.Time {
+    sched, ok := s.schedule(price)
+    if !ok {
+        return time.Time{}
+    }
+    return sched.Cron.Next(at.In(sched.Location))
+}
+
+func (s *Scheduler) schedule(price float64) (Schedule, bool) {
+    for _, sched := range s.schedules {
+        if sched.Price == price {
+            return sched, true
+        }
+    }
+    return Schedule{}, false
+}
@@ -0,0 +1,184 @@
+package scheduler_test
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/cron"
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler"
+    "github.com/gsshankar104/telegram-bot/internal/scheduler/schedulertest"
+    "github.com/gsshankar104/telegram-bot/internal/storage/memory"
+)
+
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+func schedules(t *testing.T) []scheduler.Schedule {
+    expr, err := cron.Parse("0 21 * * *")
+    if err != nil {
+        t.Fatalf("cron.Parse: %v", err)
+    }
+    return []scheduler.Schedule{{
+        Price:       100,
+        Cron:        expr,
+        Location:    ist,
+        Method:      "random",
+        WinnerCount: 1,
+        SalesCutoff: 30 * time.Minute,
+    }}
+}
+
+// recorder stands in for the bot: it saves a draw record like the engine does
+type recorder struct {
+    store *memory.MemoryStorage
+    jobs  []scheduler.Job
+}
+
+func (r *recorder) run(ctx context.Context, job scheduler.Job) error {
+    r.jobs = append(r.jobs, job)
+    return r.store.SaveDraw(ctx, &models.Draw{DrawID: job.DrawID, Date: job.At, TicketAmount: job.Price, Status: "drawn"})
+}
+
+func TestTickRunsEachDrawOnce(t *testing.T) {
+    ctx := context.Background()
+    store := memory.NewMemoryStorage()
+    clock := schedulertest.NewClock(time.Date(2024, 3, 15, 12, 0, 0, 0, ist))
+    rec := &recorder{store: store}
+
+    s := scheduler.New(schedules(t), store, rec.run, clock)
+
+    // The previous evening's draw is outside the catch-up window
+    s.Tick(ctx)
+    if len(rec.jobs) != 0 {
+        t.Fatalf("ran %d draws at noon, want 0", len(rec.jobs))
+    }
+
+    // Tickets bought during the day open the draw; an open draw still runs
+    at := time.Date(2024, 3, 15, 21, 0, 0, 0, ist)
+    if err := store.OpenDraw(ctx, &models.Draw{DrawID: scheduler.DrawID(100, at), TicketAmount: 100, OpenedAt: clock.Now()}); err != nil {
+        t.Fatalf("OpenDraw: %v", err)
+    }
+
+    clock.Set(at)
+    s.Tick(ctx)
+    s.Tick(ctx)
+    if len(rec.jobs) != 1 {
+        t.Fatalf("ran %d draws at 21:00, want 1", len(rec.jobs))
+    }
+    job := rec.jobs[0]
+    if job.DrawID != scheduler.DrawID(100, job.At) || job.Price != 100 || job.Method != "random" {
+        t.Errorf("job = %+v", job)
+    }
+    if !job.At.Equal(at) {
+        t.Errorf("job.At = %s, want %s", job.At, at)
+    }
+
+    // A restart shortly after finds the record and does not draw again
+    clock.Advance(10 * time.Minute)
+    scheduler.New(schedules(t), store, rec.run, clock).Tick(ctx)
+    if len(rec.jobs) != 1 {
+        t.Errorf("restart re-ran the draw")
+    }
+}
+
+func TestTickCatchesUpAfterDowntime(t *testing.T) {
+    ctx := context.Background()
+    store := memory.NewMemoryStorage()
+    clock := schedulertest.NewClock(time.Date(2024, 3, 15, 23, 30, 0, 0, ist))
+    rec := &recorder{store: store}
+
+    scheduler.New(schedules(t), store, rec.run, clock).Tick(ctx)
+    if len(rec.jobs) != 1 || rec.jobs[0].At.Hour() != 21 {
+        t.Errorf("missed 21:00 draw was not run on startup: %+v", rec.jobs)
+    }
+}
+
+func TestRunWaitsForNextDraw(t *testing.T) {
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    store := memory.NewMemoryStorage()
+    clock := schedulertest.NewClock(time.Date(2024, 3, 15, 20, 0, 0, 0, ist))
+    ran := make(chan scheduler.Job, 1)
+    run := func(ctx context.Context, job scheduler.Job) error {
+        ran <- job
+        return store.SaveDraw(ctx, &models.Draw{DrawID: job.DrawID, Status: "drawn"})
+    }
+
+    go scheduler.New(schedules(t), store, run, clock).Run(ctx)
+
+    // Wait until Run is blocked on the clock, then move past 21:00
+    deadline := time.Now().Add(time.Second)
+    for time.Now().Before(deadline) {
+        clock.Set(time.Date(2024, 3, 15, 21, 0, 0, 0, ist))
+        select {
+        case job := <-ran:
+            if job.At.Hour() != 21 {
+                t.Errorf("ran draw for %s", job.At)
+            }
+            return
+        case <-time.After(10 * time.Millisecond):
+        }
+    }
+    t.Fatalf("Run did not fire the 21:00 draw")
+}
+
+func TestSalesCutoff(t *testing.T) {
+    clock := schedulertest.NewClock(time.Date(2024, 3, 15, 20, 29, 0, 0, ist))
+    s := scheduler.New(schedules(t), memory.NewMemoryStorage(), nil, clock)
+
+    if !s.SalesOpen(100) {
+        t.Errorf("sales closed 31 minutes before the draw")
+    }
+    clock.Set(time.Date(2024, 3, 15, 20, 30, 0, 0, ist))
+    if s.SalesOpen(100) {
+        t.Errorf("sales open at the cutoff")
+    }
+    clock.Set(time.Date(2024, 3, 15, 21, 0, 0, 0, ist))
+    if !s.SalesOpen(100) {
+        t.Errorf("sales still closed once the draw time has passed")
+    }
+    if !s.SalesOpen(200) {
+        t.Errorf("unscheduled price is closed")
+    }
+
+    next, ok := s.NextDraw(100)
+    if !ok || !next.Equal(time.Date(2024, 3, 16, 21, 0, 0, 0, ist)) {
+        t.Errorf("NextDraw = %s, %v", next, ok)
+    }
+    if _, ok := s.NextDraw(200); ok {
+        t.Errorf("NextDraw reported a schedule for an unscheduled price")
+    }
+}
+
+func TestTickClosesSalesAtCutoff(t *testing.T) {
+    ctx := context.Background()
+    store := memory.NewMemoryStorage()
+    at := time.Date(2024, 3, 15, 21, 0, 0, 0, ist)
+    clock := schedulertest.NewClock(at.Add(-31 * time.Minute))
+    s := scheduler.New(schedules(t), store, (&recorder{store: store}).run, clock)
+
+    id := scheduler.DrawID(100, at)
+    if err := store.OpenDraw(ctx, &models.Draw{DrawID: id, TicketAmount: 100, OpenedAt: clock.Now()}); err != nil {
+        t.Fatalf("OpenDraw: %v", err)
+    }
+
+    s.Tick(ctx)
+    if d, _ := store.GetDraw(ctx, id); d.Status != "open" {
+        t.Errorf("draw is %s before the cutoff, want open", d.Status)
+    }
+
+    clock.Set(at.Add(-30 * time.Minute))
+    s.Tick(ctx)
+    d, _ := store.GetDraw(ctx, id)
+    if d.Status != "closed" || !d.ClosedAt.Equal(clock.Now()) {
+        t.Errorf("draw at the cutoff = %s closed at %s, want closed at %s", d.Status, d.ClosedAt, clock.Now())
+    }
+
+    // Tickets confirmed now join the next day's draw
+    next, ok := s.EntryDraw(100)
+    if !ok || !next.Equal(at.AddDate(0, 0, 1)) {
+        t.Errorf("EntryDraw = %s, %v; want the next day's draw", next, ok)
+    }
+}
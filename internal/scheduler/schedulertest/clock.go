@@ -0,0 +1,67 @@
+// Package schedulertest provides a manually driven clock for scheduler tests.
+package schedulertest
+
+import (
+    "sync"
+    "time"
+)
+
+// Clock only moves when Set or Advance is called
+type Clock struct {
+    mutex   sync.Mutex
+    now     time.Time
+    waiters []waiter
+}
+
+type waiter struct {
+    at time.Time
+    ch chan time.Time
+}
+
+// NewClock returns a clock stopped at now
+func NewClock(now time.Time) *Clock {
+    return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+    return c.now
+}
+
+// After fires once the clock has been moved d past the current time
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    ch := make(chan time.Time, 1)
+    at := c.now.Add(d)
+    if d <= 0 {
+        ch <- c.now
+        return ch
+    }
+    c.waiters = append(c.waiters, waiter{at: at, ch: ch})
+    return ch
+}
+
+// Set moves the clock to now, firing any timers that are due
+func (c *Clock) Set(now time.Time) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    c.now = now
+    pending := c.waiters[:0]
+    for _, w := range c.waiters {
+        if !w.at.After(now) {
+            w.ch <- now
+        } else {
+            pending = append(pending, w)
+        }
+    }
+    c.waiters = pending
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+    c.Set(c.Now().Add(d))
+}
@@ -0,0 +1,143 @@
+package drive
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// refreshOverlap widens each refresh window so a change is not missed when
+// the local clock is behind Drive's
+const refreshOverlap = time.Minute
+
+// cachedFile is a file's JSON as last read or written; a nil data with
+// revision 0 records that the file does not exist
+type cachedFile struct {
+    data     []byte
+    revision int64
+}
+
+// cachedFolder keeps every file it has read or written in memory, so
+// repeated reads do not call the Drive API. Writes go through to Drive and
+// then update the cache. A file changed elsewhere is dropped when a write
+// to it conflicts or when refresh finds it modified, and read again from
+// Drive on next use.
+type cachedFolder struct {
+    folder
+    mutex       sync.Mutex
+    files       map[string]cachedFile
+    lastRefresh time.Time
+    hits        atomic.Int64
+    misses      atomic.Int64
+}
+
+func newCachedFolder(files folder) *cachedFolder {
+    return &cachedFolder{
+        folder:      files,
+        files:       make(map[string]cachedFile),
+        lastRefresh: time.Now(),
+    }
+}
+
+func (c *cachedFolder) read(ctx context.Context, name string, v interface{}) (int64, error) {
+    c.mutex.Lock()
+    cached, ok := c.files[name]
+    c.mutex.Unlock()
+
+    if ok {
+        c.hits.Add(1)
+    } else {
+        c.misses.Add(1)
+
+        var data json.RawMessage
+        revision, err := c.folder.read(ctx, name, &data)
+        if err != nil {
+            return 0, err
+        }
+        cached = cachedFile{data: data, revision: revision}
+        c.store(name, cached)
+    }
+
+    if cached.revision == 0 {
+        return 0, nil
+    }
+    // Decoding a fresh copy each time keeps callers' changes out of the cache
+    return cached.revision, json.Unmarshal(cached.data, v)
+}
+
+func (c *cachedFolder) write(ctx context.Context, name string, v interface{}, revision int64) (int64, error) {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return 0, err
+    }
+
+    newRevision, err := c.folder.write(ctx, name, json.RawMessage(data), revision)
+    if err != nil {
+        if errors.Is(err, storage.ErrConflict) {
+            c.drop(name)
+        }
+        return 0, err
+    }
+
+    c.store(name, cachedFile{data: data, revision: newRevision})
+    return newRevision, nil
+}
+
+func (c *cachedFolder) rename(ctx context.Context, name, newName string) error {
+    err := c.folder.rename(ctx, name, newName)
+    c.drop(name)
+    c.drop(newName)
+    return err
+}
+
+// store caches a file unless a newer revision is already cached
+func (c *cachedFolder) store(name string, file cachedFile) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+
+    if cached, ok := c.files[name]; !ok || cached.revision <= file.revision {
+        c.files[name] = file
+    }
+}
+
+func (c *cachedFolder) drop(name string) {
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+    delete(c.files, name)
+}
+
+// refresh drops cached files that were modified in Drive since the last
+// refresh, e.g. by another bot instance or by hand
+func (c *cachedFolder) refresh(ctx context.Context) error {
+    c.mutex.Lock()
+    since := c.lastRefresh.Add(-refreshOverlap)
+    c.mutex.Unlock()
+
+    start := time.Now()
+    changed, err := c.folder.changed(ctx, since)
+    if err != nil {
+        return err
+    }
+
+    c.mutex.Lock()
+    defer c.mutex.Unlock()
+    for name, revision := range changed {
+        if cached, ok := c.files[name]; ok && cached.revision != revision {
+            delete(c.files, name)
+        }
+    }
+    c.lastRefresh = start
+    return nil
+}
+
+func (c *cachedFolder) stats() storage.CacheStats {
+    return storage.CacheStats{
+        Hits:   c.hits.Load(),
+        Misses: c.misses.Load(),
+    }
+}
@@ -2,166 +2,132 @@ package drive
 
 import (
     "context"
-    "encoding/json"
     "fmt"
-    "strings"
+    "log"
+    "sort"
     "sync"
     "time"
 
-    "github.com/gsshankar104/telegram-bot/internal/config"
     "github.com/gsshankar104/telegram-bot/internal/models"
     "github.com/gsshankar104/telegram-bot/internal/storage"
-    
+
+    drivev2 "google.golang.org/api/drive/v2"
     "google.golang.org/api/drive/v3"
     "google.golang.org/api/option"
 )
 
+// DriveStorage keeps the bot's data as JSON files in a Google Drive folder.
+// Users, draws and user states have one file each; transactions, entries,
+// winners and admin actions are split into day files (see layout.go).
+//
+// The mutex only orders operations within this process. Every write is
+// conditional on the file being unchanged since it was read (see modify),
+// so other bot instances and manual edits in Drive are not overwritten.
+// Files are cached in memory once read; RunCacheRefresh keeps the cache in
+// step with changes made outside this process.
 type DriveStorage struct {
-    service      *drive.Service
-    folderID     string
-    mutex        sync.RWMutex
-    fileCache    map[string]string // filename to fileId mapping
+    files    folder
+    cache    *cachedFolder
+    location *time.Location // partition days are calendar days here
+    mutex    sync.RWMutex
 }
 
 const (
-    usersFile       = "users.json"
-    entriesFile     = "lottery_entries.json"
-    transactionsFile = "transactions.json"
-    winnersFile     = "winners.json"
-    statesFile      = "user_states.json"
-    statsFile       = "statistics.json"
-    adminActionsFile = "admin_actions.json"
+    usersFile  = "users.json"
+    drawsFile  = "draws.json"
+    statesFile = "user_states.json"
+
+    // cacheStatsInterval is how often RunCacheRefresh logs CacheStats
+    cacheStatsInterval = time.Hour
 )
 
-func NewDriveStorage(ctx context.Context, credentialsFile string) (*DriveStorage, error) {
-    service, err := drive.NewService(ctx, option.WithCredentialsFile(credentialsFile))
+func NewDriveStorage(ctx context.Context, credentialsFile, folderID string) (*DriveStorage, error) {
+    credentials := option.WithCredentialsFile(credentialsFile)
+    service, err := drive.NewService(ctx, credentials)
     if err != nil {
         return nil, fmt.Errorf("failed to create Drive client: %v", err)
     }
-
-    ds := &DriveStorage{
-        service:   service,
-        folderID:  config.Get().Database.DriveFolderID,
-        fileCache: make(map[string]string),
-    }
-
-    files := []string{
-        usersFile, entriesFile, transactionsFile, winnersFile,
-        statesFile, statsFile, adminActionsFile,
-    }
-
-    for _, file := range files {
-        if err := ds.initializeFile(ctx, file); err != nil {
-            return nil, err
-        }
+    v2service, err := drivev2.NewService(ctx, credentials)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create Drive client: %v", err)
     }
 
-    return ds, nil
+    return newDriveStorage(ctx, newDriveFolder(service, v2service, folderID), time.Local)
 }
 
-func (ds *DriveStorage) initializeFile(ctx context.Context, filename string) error {
-    fileID, err := ds.findFile(ctx, filename)
-    if err != nil {
-        return err
+func newDriveStorage(ctx context.Context, files folder, location *time.Location) (*DriveStorage, error) {
+    cache := newCachedFolder(files)
+    ds := &DriveStorage{
+        files:    cache,
+        cache:    cache,
+        location: location,
     }
-
-    if fileID == "" {
-        file := &drive.File{
-            Name:     filename,
-            Parents:  []string{ds.folderID},
-            MimeType: "application/json",
-        }
-
-        created, err := ds.service.Files.Create(file).Context(ctx).Do()
-        if err != nil {
-            return fmt.Errorf("failed to create file %s: %v", filename, err)
-        }
-        fileID = created.Id
-
-        emptyData := "[]"
-        _, err = ds.service.Files.Update(fileID, nil).Media(strings.NewReader(emptyData)).Context(ctx).Do()
-        if err != nil {
-            return fmt.Errorf("failed to initialize file %s: %v", filename, err)
-        }
+    if err := ds.prepareLayout(ctx); err != nil {
+        return nil, err
     }
-
-    ds.fileCache[filename] = fileID
-    return nil
+    return ds, nil
 }
 
-func (ds *DriveStorage) findFile(ctx context.Context, filename string) (string, error) {
-    query := fmt.Sprintf("name='%s' and '%s' in parents and trashed=false", filename, ds.folderID)
-    files, err := ds.service.Files.List().Q(query).Context(ctx).Do()
-    if err != nil {
-        return "", fmt.Errorf("failed to search for file %s: %v", filename, err)
-    }
+// CacheStats returns the cache's hit and miss counts since startup
+func (ds *DriveStorage) CacheStats() storage.CacheStats {
+    return ds.cache.stats()
+}
 
-    if len(files.Files) > 0 {
-        return files.Files[0].Id, nil
+// RunCacheRefresh checks Drive for files modified outside this process
+// every interval and drops them from the cache, until ctx is done. It also
+// logs CacheStats periodically.
+func (ds *DriveStorage) RunCacheRefresh(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    lastStats := time.Now()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := ds.cache.refresh(ctx); err != nil {
+                log.Printf("Failed to refresh Drive cache: %v", err)
+            }
+            if time.Since(lastStats) >= cacheStatsInterval {
+                stats := ds.CacheStats()
+                log.Printf("Drive cache: %d hits, %d misses", stats.Hits, stats.Misses)
+                lastStats = time.Now()
+            }
+        }
     }
-    return "", nil
 }
 
+// readFile reads a single-file collection; a missing file is empty
 func (ds *DriveStorage) readFile(ctx context.Context, filename string, v interface{}) error {
-    fileID, ok := ds.fileCache[filename]
-    if !ok {
-        return fmt.Errorf("file %s not initialized", filename)
-    }
-
-    resp, err := ds.service.Files.Get(fileID).Context(ctx).Download()
-    if err != nil {
-        return fmt.Errorf("failed to download file %s: %v", filename, err)
-    }
-    defer resp.Body.Close()
-
-    if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
-        return fmt.Errorf("failed to decode JSON from file %s: %v", filename, err)
-    }
-
-    return nil
+    _, err := ds.files.read(ctx, filename, v)
+    return err
 }
 
-func (ds *DriveStorage) writeFile(ctx context.Context, filename string, v interface{}) error {
-    fileID, ok := ds.fileCache[filename]
-    if !ok {
-        return fmt.Errorf("file %s not initialized", filename)
-    }
-
-    data, err := json.Marshal(v)
-    if err != nil {
-        return fmt.Errorf("failed to marshal data: %v", err)
-    }
-
-    _, err = ds.service.Files.Update(fileID, nil).Media(strings.NewReader(string(data))).Context(ctx).Do()
-    if err != nil {
-        return fmt.Errorf("failed to write file %s: %v", filename, err)
-    }
-
-    return nil
+func sameDay(t, date time.Time) bool {
+    startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+    endDate := startDate.Add(24 * time.Hour)
+    return !t.Before(startDate) && t.Before(endDate)
 }
 
 func (ds *DriveStorage) SaveUser(ctx context.Context, user *models.User) error {
     ds.mutex.Lock()
     defer ds.mutex.Unlock()
 
-    var users []*models.User
-    if err := ds.readFile(ctx, usersFile, &users); err != nil {
-        return storage.NewStorageError("SaveUser", err)
-    }
-
-    found := false
-    for i, u := range users {
-        if u.UserID == user.UserID {
-            users[i] = user
-            found = true
-            break
+    err := modify(ctx, ds.files, usersFile, func(users *[]*models.User) error {
+        for i, u := range *users {
+            if u.UserID == user.UserID {
+                (*users)[i] = user
+                return nil
+            }
         }
+        *users = append(*users, user)
+        return nil
+    })
+    if err != nil {
+        return storage.NewStorageError("SaveUser", err)
     }
-    if !found {
-        users = append(users, user)
-    }
-
-    return ds.writeFile(ctx, usersFile, users)
+    return nil
 }
 
 func (ds *DriveStorage) GetUser(ctx context.Context, userID int64) (*models.User, error) {
@@ -198,7 +164,7 @@ func (ds *DriveStorage) GetAllUsers(ctx context.Context, fromDate, toDate time.T
     var filtered []*models.User
     for _, user := range users {
         if (fromDate.IsZero() || !user.JoinedDate.Before(fromDate)) &&
-           (toDate.IsZero() || !user.JoinedDate.After(toDate)) {
+            (toDate.IsZero() || !user.JoinedDate.After(toDate)) {
             filtered = append(filtered, user)
         }
     }
@@ -206,25 +172,60 @@ func (ds *DriveStorage) GetAllUsers(ctx context.Context, fromDate, toDate time.T
     return filtered, nil
 }
 
+// SaveTransaction claims the transaction ID in the index before writing the
+// record, so two instances cannot both accept the same transaction. If the
+// record cannot be written the claim is released again.
 func (ds *DriveStorage) SaveTransaction(ctx context.Context, txn *models.Transaction) error {
     ds.mutex.Lock()
     defer ds.mutex.Unlock()
 
-    var transactions []*models.Transaction
-    if err := ds.readFile(ctx, transactionsFile, &transactions); err != nil {
+    shard := shardName(txnsDir, txn.TransactionID)
+    claim := ref{Day: ds.day(txn.Date), Status: txn.Status}
+    err := ds.modifyRefs(ctx, shard, func(refs map[string]ref) error {
+        if _, ok := refs[txn.TransactionID]; ok {
+            return storage.ErrDuplicateTransaction
+        }
+        refs[txn.TransactionID] = claim
+        return nil
+    })
+    if err != nil {
         return storage.NewStorageError("SaveTransaction", err)
     }
 
-    transactions = append(transactions, txn)
-    return ds.writeFile(ctx, transactionsFile, transactions)
+    err = modify(ctx, ds.files, partition(txnsDir, claim.Day), func(transactions *[]*models.Transaction) error {
+        *transactions = append(*transactions, txn)
+        return nil
+    })
+    if err != nil {
+        release := ds.modifyRefs(ctx, shard, func(refs map[string]ref) error {
+            if refs[txn.TransactionID] != claim {
+                return errNoChange
+            }
+            delete(refs, txn.TransactionID)
+            return nil
+        })
+        if release != nil {
+            log.Printf("Failed to release transaction %s after a failed save: %v", txn.TransactionID, release)
+        }
+        return storage.NewStorageError("SaveTransaction", err)
+    }
+    return nil
 }
 
 func (ds *DriveStorage) GetTransaction(ctx context.Context, txnID string) (*models.Transaction, error) {
     ds.mutex.RLock()
     defer ds.mutex.RUnlock()
 
+    r, ok, err := ds.readRef(ctx, txnsDir, txnID)
+    if err != nil {
+        return nil, storage.NewStorageError("GetTransaction", err)
+    }
+    if !ok {
+        return nil, storage.NewStorageError("GetTransaction", fmt.Errorf("transaction not found"))
+    }
+
     var transactions []*models.Transaction
-    if err := ds.readFile(ctx, transactionsFile, &transactions); err != nil {
+    if err := ds.readDay(ctx, txnsDir, r.Day, &transactions); err != nil {
         return nil, storage.NewStorageError("GetTransaction", err)
     }
 
@@ -241,18 +242,16 @@ func (ds *DriveStorage) GetTransactionsByDate(ctx context.Context, date time.Tim
     ds.mutex.RLock()
     defer ds.mutex.RUnlock()
 
-    var transactions []*models.Transaction
-    if err := ds.readFile(ctx, transactionsFile, &transactions); err != nil {
-        return nil, storage.NewStorageError("GetTransactionsByDate", err)
-    }
-
-    startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-    endDate := startDate.Add(24 * time.Hour)
-
     var filtered []*models.Transaction
-    for _, txn := range transactions {
-        if txn.Date.After(startDate) && txn.Date.Before(endDate) {
-            filtered = append(filtered, txn)
+    for _, day := range ds.daysFor(date) {
+        var transactions []*models.Transaction
+        if err := ds.readDay(ctx, txnsDir, day, &transactions); err != nil {
+            return nil, storage.NewStorageError("GetTransactionsByDate", err)
+        }
+        for _, txn := range transactions {
+            if sameDay(txn.Date, date) {
+                filtered = append(filtered, txn)
+            }
         }
     }
 
@@ -263,78 +262,620 @@ func (ds *DriveStorage) IsTransactionUsed(ctx context.Context, txnID string) (bo
     ds.mutex.RLock()
     defer ds.mutex.RUnlock()
 
-    var transactions []*models.Transaction
-    if err := ds.readFile(ctx, transactionsFile, &transactions); err != nil {
+    _, ok, err := ds.readRef(ctx, txnsDir, txnID)
+    if err != nil {
         return false, storage.NewStorageError("IsTransactionUsed", err)
     }
+    return ok, nil
+}
 
-    for _, txn := range transactions {
-        if txn.TransactionID == txnID {
-            return true, nil
+func (ds *DriveStorage) GetTransactionsByStatus(ctx context.Context, status string) ([]*models.Transaction, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    days, err := ds.refDays(ctx, txnsDir, func(r ref) bool { return r.Status == status })
+    if err != nil {
+        return nil, storage.NewStorageError("GetTransactionsByStatus", err)
+    }
+
+    var filtered []*models.Transaction
+    for _, day := range days {
+        var transactions []*models.Transaction
+        if err := ds.readDay(ctx, txnsDir, day, &transactions); err != nil {
+            return nil, storage.NewStorageError("GetTransactionsByStatus", err)
+        }
+        for _, txn := range transactions {
+            if txn.Status == status {
+                filtered = append(filtered, txn)
+            }
         }
     }
 
-    return false, nil
+    return filtered, nil
+}
+
+func (ds *DriveStorage) UpdateTransactionStatus(ctx context.Context, txnID, status string) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    return ds.updateTransaction(ctx, "UpdateTransactionStatus", txnID, func(txn *models.Transaction) {
+        txn.Status = status
+    })
+}
+
+func (ds *DriveStorage) RefundTransaction(ctx context.Context, txnID, refundRef string, refundedAt time.Time) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    return ds.updateTransaction(ctx, "RefundTransaction", txnID, func(txn *models.Transaction) {
+        txn.Status = "refunded"
+        txn.RefundRef = refundRef
+        txn.RefundedAt = refundedAt
+    })
+}
+
+func (ds *DriveStorage) SetTransactionScreenshot(ctx context.Context, txnID, fileID, fileType string) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    return ds.updateTransaction(ctx, "SetTransactionScreenshot", txnID, func(txn *models.Transaction) {
+        txn.ScreenshotFileID = fileID
+        txn.ScreenshotType = fileType
+    })
+}
+
+// updateTransaction updates the record in its day file and then its status
+// in its index shard; the caller holds the write lock
+func (ds *DriveStorage) updateTransaction(ctx context.Context, operation, txnID string, update func(*models.Transaction)) error {
+    r, ok, err := ds.readRef(ctx, txnsDir, txnID)
+    if err != nil {
+        return storage.NewStorageError(operation, err)
+    }
+    if !ok {
+        return storage.NewStorageError(operation, fmt.Errorf("transaction not found"))
+    }
+
+    var status string
+    err = modify(ctx, ds.files, partition(txnsDir, r.Day), func(transactions *[]*models.Transaction) error {
+        for _, txn := range *transactions {
+            if txn.TransactionID == txnID {
+                update(txn)
+                status = txn.Status
+                return nil
+            }
+        }
+        return fmt.Errorf("transaction not found")
+    })
+    if err == nil {
+        err = ds.modifyRefs(ctx, shardName(txnsDir, txnID), func(refs map[string]ref) error {
+            r, ok := refs[txnID]
+            if !ok || r.Status == status {
+                return errNoChange
+            }
+            r.Status = status
+            refs[txnID] = r
+            return nil
+        })
+    }
+    if err != nil {
+        return storage.NewStorageError(operation, err)
+    }
+    return nil
 }
 
+// SaveLotteryEntry writes the entry before its ref, so a failed save leaves
+// no ref to a missing entry and can simply be repeated
 func (ds *DriveStorage) SaveLotteryEntry(ctx context.Context, entry *models.LotteryEntry) error {
     ds.mutex.Lock()
     defer ds.mutex.Unlock()
 
-    var entries []*models.LotteryEntry
-    if err := ds.readFile(ctx, entriesFile, &entries); err != nil {
+    day := ds.day(entry.EntryDate)
+    err := modify(ctx, ds.files, partition(entriesDir, day), func(entries *[]*models.LotteryEntry) error {
+        for i, e := range *entries {
+            if e.EntryID == entry.EntryID {
+                (*entries)[i] = entry
+                return nil
+            }
+        }
+        *entries = append(*entries, entry)
+        return nil
+    })
+    if err == nil {
+        err = ds.modifyRefs(ctx, shardName(entriesDir, entry.EntryID), func(refs map[string]ref) error {
+            refs[entry.EntryID] = ref{Day: day, Txn: entry.TransactionID, Draw: entry.DrawID}
+            return nil
+        })
+    }
+    if err != nil {
         return storage.NewStorageError("SaveLotteryEntry", err)
     }
-
-    entries = append(entries, entry)
-    return ds.writeFile(ctx, entriesFile, entries)
+    return nil
 }
 
 func (ds *DriveStorage) GetEntriesByDate(ctx context.Context, date time.Time) ([]*models.LotteryEntry, error) {
     ds.mutex.RLock()
     defer ds.mutex.RUnlock()
 
-    var entries []*models.LotteryEntry
-    if err := ds.readFile(ctx, entriesFile, &entries); err != nil {
-        return nil, storage.NewStorageError("GetEntriesByDate", err)
+    return ds.readEntries(ctx, "GetEntriesByDate", ds.daysFor(date), func(entry *models.LotteryEntry) bool {
+        return sameDay(entry.EntryDate, date)
+    })
+}
+
+func (ds *DriveStorage) GetEntriesByTransaction(ctx context.Context, txnID string) ([]*models.LotteryEntry, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    days, err := ds.refDays(ctx, entriesDir, func(r ref) bool { return r.Txn == txnID })
+    if err != nil {
+        return nil, storage.NewStorageError("GetEntriesByTransaction", err)
     }
+    return ds.readEntries(ctx, "GetEntriesByTransaction", days, func(entry *models.LotteryEntry) bool {
+        return entry.TransactionID == txnID
+    })
+}
 
-    startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-    endDate := startDate.Add(24 * time.Hour)
+func (ds *DriveStorage) GetEntriesByDraw(ctx context.Context, drawID string) ([]*models.LotteryEntry, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    days, err := ds.refDays(ctx, entriesDir, func(r ref) bool { return r.Draw == drawID })
+    if err != nil {
+        return nil, storage.NewStorageError("GetEntriesByDraw", err)
+    }
+    return ds.readEntries(ctx, "GetEntriesByDraw", days, func(entry *models.LotteryEntry) bool {
+        return entry.DrawID == drawID
+    })
+}
 
+func (ds *DriveStorage) readEntries(ctx context.Context, operation string, days []string, match func(*models.LotteryEntry) bool) ([]*models.LotteryEntry, error) {
     var filtered []*models.LotteryEntry
-    for _, entry := range entries {
-        if entry.EntryDate.After(startDate) && entry.EntryDate.Before(endDate) {
-            filtered = append(filtered, entry)
+    for _, day := range days {
+        var entries []*models.LotteryEntry
+        if err := ds.readDay(ctx, entriesDir, day, &entries); err != nil {
+            return nil, storage.NewStorageError(operation, err)
+        }
+        for _, entry := range entries {
+            if match(entry) {
+                filtered = append(filtered, entry)
+            }
         }
     }
 
     return filtered, nil
 }
 
-func (ds *DriveStorage) SaveUserState(ctx context.Context, state *models.UserState) error {
+func (ds *DriveStorage) UpdateEntryDraw(ctx context.Context, entryIDs []string, drawID string) error {
     ds.mutex.Lock()
     defer ds.mutex.Unlock()
 
-    var states []*models.UserState
-    if err := ds.readFile(ctx, statesFile, &states); err != nil {
-        return storage.NewStorageError("SaveUserState", err)
+    return ds.updateEntries(ctx, "UpdateEntryDraw", entryIDs, func(entry *models.LotteryEntry) {
+        entry.DrawID = drawID
+    })
+}
+
+func (ds *DriveStorage) UpdateEntryStatus(ctx context.Context, entryIDs []string, status string) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    return ds.updateEntries(ctx, "UpdateEntryStatus", entryIDs, func(entry *models.LotteryEntry) {
+        entry.Status = status
+    })
+}
+
+// updateEntries updates the entries in their day files and then their draw
+// in their index shards; the caller holds the write lock
+func (ds *DriveStorage) updateEntries(ctx context.Context, operation string, entryIDs []string, update func(*models.LotteryEntry)) error {
+    ids := make(map[string]bool, len(entryIDs))
+    for _, id := range entryIDs {
+        ids[id] = true
+    }
+    var days []string
+    for id := range ids {
+        r, ok, err := ds.readRef(ctx, entriesDir, id)
+        if err != nil {
+            return storage.NewStorageError(operation, err)
+        }
+        if ok {
+            days = append(days, r.Day)
+        }
+    }
+    sort.Strings(days)
+
+    draws := make(map[string]string)
+    for i, day := range days {
+        if i > 0 && day == days[i-1] {
+            continue
+        }
+
+        err := modify(ctx, ds.files, partition(entriesDir, day), func(entries *[]*models.LotteryEntry) error {
+            for _, entry := range *entries {
+                if ids[entry.EntryID] {
+                    update(entry)
+                    draws[entry.EntryID] = entry.DrawID
+                }
+            }
+            return nil
+        })
+        if err != nil {
+            return storage.NewStorageError(operation, err)
+        }
     }
 
-    found := false
-    for i, s := range states {
-        if s.UserID == state.UserID {
-            states[i] = state
-            found = true
+    shards := make(map[string][]string)
+    for id := range draws {
+        shard := shardName(entriesDir, id)
+        shards[shard] = append(shards[shard], id)
+    }
+    for shard, shardIDs := range shards {
+        err := ds.modifyRefs(ctx, shard, func(refs map[string]ref) error {
+            changed := false
+            for _, id := range shardIDs {
+                if r, ok := refs[id]; ok && r.Draw != draws[id] {
+                    r.Draw = draws[id]
+                    refs[id] = r
+                    changed = true
+                }
+            }
+            if !changed {
+                return errNoChange
+            }
+            return nil
+        })
+        if err != nil {
+            return storage.NewStorageError(operation, err)
+        }
+    }
+    return nil
+}
+
+// SaveWinner writes the winner before its ref, so a failed save leaves no
+// ref to a missing winner and can simply be repeated
+func (ds *DriveStorage) SaveWinner(ctx context.Context, winner *models.Winner) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    day := ds.day(winner.Date)
+    r, ok, err := ds.readRef(ctx, winnersDir, winner.WinnerID)
+    if ok {
+        day = r.Day
+    }
+    if err == nil {
+        err = modify(ctx, ds.files, partition(winnersDir, day), func(winners *[]*models.Winner) error {
+            for i, w := range *winners {
+                if w.WinnerID == winner.WinnerID {
+                    (*winners)[i] = winner
+                    return nil
+                }
+            }
+            *winners = append(*winners, winner)
+            return nil
+        })
+    }
+    if err == nil {
+        err = ds.modifyRefs(ctx, shardName(winnersDir, winner.WinnerID), func(refs map[string]ref) error {
+            refs[winner.WinnerID] = ref{Day: day, Status: winner.PaymentStatus, User: winner.UserID}
+            return nil
+        })
+    }
+    if err != nil {
+        return storage.NewStorageError("SaveWinner", err)
+    }
+    return nil
+}
+
+func (ds *DriveStorage) GetWinnersByDate(ctx context.Context, date time.Time) ([]*models.Winner, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    return ds.readWinners(ctx, "GetWinnersByDate", ds.daysFor(date), func(winner *models.Winner) bool {
+        return sameDay(winner.Date, date)
+    })
+}
+
+func (ds *DriveStorage) GetWinnersByUser(ctx context.Context, userID int64) ([]*models.Winner, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    days, err := ds.refDays(ctx, winnersDir, func(r ref) bool { return r.User == userID })
+    if err != nil {
+        return nil, storage.NewStorageError("GetWinnersByUser", err)
+    }
+    return ds.readWinners(ctx, "GetWinnersByUser", days, func(winner *models.Winner) bool {
+        return winner.UserID == userID
+    })
+}
+
+func (ds *DriveStorage) GetWinner(ctx context.Context, winnerID string) (*models.Winner, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    r, ok, err := ds.readRef(ctx, winnersDir, winnerID)
+    if err != nil {
+        return nil, storage.NewStorageError("GetWinner", err)
+    }
+    if !ok {
+        return nil, storage.NewStorageError("GetWinner", fmt.Errorf("winner not found"))
+    }
+
+    winners, err := ds.readWinners(ctx, "GetWinner", []string{r.Day}, func(winner *models.Winner) bool {
+        return winner.WinnerID == winnerID
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(winners) == 0 {
+        return nil, storage.NewStorageError("GetWinner", fmt.Errorf("winner not found"))
+    }
+
+    return winners[0], nil
+}
+
+func (ds *DriveStorage) GetWinnersByPaymentStatus(ctx context.Context, status string) ([]*models.Winner, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    days, err := ds.refDays(ctx, winnersDir, func(r ref) bool { return r.Status == status })
+    if err != nil {
+        return nil, storage.NewStorageError("GetWinnersByPaymentStatus", err)
+    }
+    return ds.readWinners(ctx, "GetWinnersByPaymentStatus", days, func(winner *models.Winner) bool {
+        return winner.PaymentStatus == status
+    })
+}
+
+func (ds *DriveStorage) readWinners(ctx context.Context, operation string, days []string, match func(*models.Winner) bool) ([]*models.Winner, error) {
+    var filtered []*models.Winner
+    for _, day := range days {
+        var winners []*models.Winner
+        if err := ds.readDay(ctx, winnersDir, day, &winners); err != nil {
+            return nil, storage.NewStorageError(operation, err)
+        }
+        for _, winner := range winners {
+            if match(winner) {
+                filtered = append(filtered, winner)
+            }
+        }
+    }
+
+    return filtered, nil
+}
+
+func (ds *DriveStorage) UpdateWinnerPaymentStatus(ctx context.Context, winnerID, status, paymentTxnID string) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    r, ok, err := ds.readRef(ctx, winnersDir, winnerID)
+    if err != nil {
+        return storage.NewStorageError("UpdateWinnerPaymentStatus", err)
+    }
+    if !ok {
+        return storage.NewStorageError("UpdateWinnerPaymentStatus", fmt.Errorf("winner not found"))
+    }
+
+    err = modify(ctx, ds.files, partition(winnersDir, r.Day), func(winners *[]*models.Winner) error {
+        for _, winner := range *winners {
+            if winner.WinnerID == winnerID {
+                winner.PaymentStatus = status
+                winner.PaymentTransactionID = paymentTxnID
+                return nil
+            }
+        }
+        return fmt.Errorf("winner not found")
+    })
+    if err == nil {
+        err = ds.modifyRefs(ctx, shardName(winnersDir, winnerID), func(refs map[string]ref) error {
+            r, ok := refs[winnerID]
+            if !ok || r.Status == status {
+                return errNoChange
+            }
+            r.Status = status
+            refs[winnerID] = r
+            return nil
+        })
+    }
+    if err != nil {
+        return storage.NewStorageError("UpdateWinnerPaymentStatus", err)
+    }
+    return nil
+}
+
+func (ds *DriveStorage) OpenDraw(ctx context.Context, draw *models.Draw) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    err := modify(ctx, ds.files, drawsFile, func(draws *[]*models.Draw) error {
+        for _, d := range *draws {
+            if d.DrawID == draw.DrawID {
+                return storage.ErrDuplicateDraw
+            }
+        }
+
+        d := *draw
+        d.Status = "open"
+        *draws = append(*draws, &d)
+        return nil
+    })
+    if err != nil {
+        return storage.NewStorageError("OpenDraw", err)
+    }
+    return nil
+}
+
+func (ds *DriveStorage) CloseDraw(ctx context.Context, drawID string, closedAt time.Time) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    err := modify(ctx, ds.files, drawsFile, func(draws *[]*models.Draw) error {
+        for _, draw := range *draws {
+            if draw.DrawID == drawID {
+                if draw.Status != "open" {
+                    return fmt.Errorf("draw is %s", draw.Status)
+                }
+                draw.Status = "closed"
+                draw.ClosedAt = closedAt
+                return nil
+            }
+        }
+        return fmt.Errorf("draw not found")
+    })
+    if err != nil {
+        return storage.NewStorageError("CloseDraw", err)
+    }
+    return nil
+}
+
+func (ds *DriveStorage) SaveDraw(ctx context.Context, draw *models.Draw) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    err := modify(ctx, ds.files, drawsFile, func(draws *[]*models.Draw) error {
+        for i, d := range *draws {
+            if d.DrawID == draw.DrawID {
+                (*draws)[i] = draw
+                return nil
+            }
+        }
+        *draws = append(*draws, draw)
+        return nil
+    })
+    if err != nil {
+        return storage.NewStorageError("SaveDraw", err)
+    }
+    return nil
+}
+
+func (ds *DriveStorage) GetDraw(ctx context.Context, drawID string) (*models.Draw, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    var draws []*models.Draw
+    if err := ds.readFile(ctx, drawsFile, &draws); err != nil {
+        return nil, storage.NewStorageError("GetDraw", err)
+    }
+
+    for _, draw := range draws {
+        if draw.DrawID == drawID {
+            return draw, nil
+        }
+    }
+
+    return nil, storage.NewStorageError("GetDraw", fmt.Errorf("draw not found"))
+}
+
+// ListDraws returns matching draws, oldest first
+func (ds *DriveStorage) ListDraws(ctx context.Context, filter storage.DrawFilter) ([]*models.Draw, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    var draws []*models.Draw
+    if err := ds.readFile(ctx, drawsFile, &draws); err != nil {
+        return nil, storage.NewStorageError("ListDraws", err)
+    }
+
+    var filtered []*models.Draw
+    for _, draw := range draws {
+        if (filter.Status == "" || draw.Status == filter.Status) &&
+            (filter.TicketAmount == 0 || draw.TicketAmount == filter.TicketAmount) {
+            filtered = append(filtered, draw)
+        }
+    }
+
+    sort.SliceStable(filtered, func(i, j int) bool {
+        return filtered[i].OpenedAt.Before(filtered[j].OpenedAt)
+    })
+    return filtered, nil
+}
+
+func (ds *DriveStorage) SaveAdminAction(ctx context.Context, action *models.AdminAction) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    day := ds.day(action.Timestamp)
+    err := ds.modifyIndex(ctx, func(idx *index) error {
+        if !idx.addActionDay(day) {
+            return errNoChange
+        }
+        return nil
+    })
+    if err == nil {
+        err = modify(ctx, ds.files, partition(actionsDir, day), func(actions *[]*models.AdminAction) error {
+            *actions = append(*actions, action)
+            return nil
+        })
+    }
+    if err != nil {
+        return storage.NewStorageError("SaveAdminAction", err)
+    }
+    return nil
+}
+
+// GetAdminActions returns matching admin actions, newest first. Day files
+// are read newest first until the requested page is complete.
+func (ds *DriveStorage) GetAdminActions(ctx context.Context, filter storage.AdminActionFilter) ([]*models.AdminAction, error) {
+    ds.mutex.RLock()
+    defer ds.mutex.RUnlock()
+
+    idx, err := ds.readIndex(ctx)
+    if err != nil {
+        return nil, storage.NewStorageError("GetAdminActions", err)
+    }
+
+    var filtered []*models.AdminAction
+    for d := len(idx.ActionDays) - 1; d >= 0; d-- {
+        day := idx.ActionDays[d]
+        if !filter.ToDate.IsZero() && day > ds.day(filter.ToDate) {
+            continue
+        }
+        if !filter.FromDate.IsZero() && day < ds.day(filter.FromDate) {
+            break
+        }
+        if filter.Limit > 0 && len(filtered) >= filter.Offset+filter.Limit {
             break
         }
+
+        var actions []*models.AdminAction
+        if err := ds.readDay(ctx, actionsDir, day, &actions); err != nil {
+            return nil, storage.NewStorageError("GetAdminActions", err)
+        }
+        for i := len(actions) - 1; i >= 0; i-- {
+            action := actions[i]
+            if (filter.AdminID == 0 || action.AdminID == filter.AdminID) &&
+                (filter.FromDate.IsZero() || !action.Timestamp.Before(filter.FromDate)) &&
+                (filter.ToDate.IsZero() || !action.Timestamp.After(filter.ToDate)) {
+                filtered = append(filtered, action)
+            }
+        }
     }
 
-    if !found {
-        states = append(states, state)
+    if filter.Offset >= len(filtered) {
+        return nil, nil
+    }
+    filtered = filtered[filter.Offset:]
+    if filter.Limit > 0 && len(filtered) > filter.Limit {
+        filtered = filtered[:filter.Limit]
     }
 
-    return ds.writeFile(ctx, statesFile, states)
+    return filtered, nil
+}
+
+func (ds *DriveStorage) SaveUserState(ctx context.Context, state *models.UserState) error {
+    ds.mutex.Lock()
+    defer ds.mutex.Unlock()
+
+    err := modify(ctx, ds.files, statesFile, func(states *[]*models.UserState) error {
+        for i, s := range *states {
+            if s.UserID == state.UserID {
+                (*states)[i] = state
+                return nil
+            }
+        }
+        *states = append(*states, state)
+        return nil
+    })
+    if err != nil {
+        return storage.NewStorageError("SaveUserState", err)
+    }
+    return nil
 }
 
 func (ds *DriveStorage) GetUserState(ctx context.Context, userID int64) (*models.UserState, error) {
@@ -365,17 +906,17 @@ func (ds *DriveStorage) DeleteUserState(ctx context.Context, userID int64) error
     ds.mutex.Lock()
     defer ds.mutex.Unlock()
 
-    var states []*models.UserState
-    if err := ds.readFile(ctx, statesFile, &states); err != nil {
-        return storage.NewStorageError("DeleteUserState", err)
-    }
-
-    for i, state := range states {
-        if state.UserID == userID {
-            states = append(states[:i], states[i+1:]...)
-            break
+    err := modify(ctx, ds.files, statesFile, func(states *[]*models.UserState) error {
+        for i, state := range *states {
+            if state.UserID == userID {
+                *states = append((*states)[:i], (*states)[i+1:]...)
+                return nil
+            }
         }
+        return errNoChange
+    })
+    if err != nil {
+        return storage.NewStorageError("DeleteUserState", err)
     }
-
-    return ds.writeFile(ctx, statesFile, states)
+    return nil
 }
@@ -0,0 +1,462 @@
+package drive
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "path"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+    "github.com/gsshankar104/telegram-bot/internal/storage/storagetest"
+)
+
+// memFolder is an in-memory folder that records which files were read.
+// beforeWrite, if set, runs before each write and may change files to
+// simulate another writer.
+type memFolder struct {
+    mutex       sync.Mutex
+    files       map[string][]byte
+    revisions   map[string]int64
+    modified    map[string]time.Time
+    reads       []string
+    beforeWrite func(name string)
+}
+
+func newMemFolder() *memFolder {
+    return &memFolder{
+        files:     make(map[string][]byte),
+        revisions: make(map[string]int64),
+        modified:  make(map[string]time.Time),
+    }
+}
+
+func (f *memFolder) read(ctx context.Context, name string, v interface{}) (int64, error) {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    f.reads = append(f.reads, name)
+    data, ok := f.files[name]
+    if !ok {
+        return 0, nil
+    }
+    return f.revisions[name], json.Unmarshal(data, v)
+}
+
+func (f *memFolder) write(ctx context.Context, name string, v interface{}, revision int64) (int64, error) {
+    if f.beforeWrite != nil {
+        f.beforeWrite(name)
+    }
+
+    data, err := json.Marshal(v)
+    if err != nil {
+        return 0, err
+    }
+
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+    if f.revisions[name] != revision {
+        return 0, fmt.Errorf("file %s changed: %w", name, storage.ErrConflict)
+    }
+    f.files[name] = data
+    f.revisions[name]++
+    f.modified[name] = time.Now()
+    return f.revisions[name], nil
+}
+
+// set replaces a file the way another writer would
+func (f *memFolder) set(name string, v interface{}) {
+    data, _ := json.Marshal(v)
+
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+    f.files[name] = data
+    f.revisions[name]++
+    f.modified[name] = time.Now()
+}
+
+func (f *memFolder) rename(ctx context.Context, name, newName string) error {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    data, ok := f.files[name]
+    if !ok {
+        return errNoFile
+    }
+    delete(f.files, name)
+    f.files[newName] = data
+    f.revisions[newName] = f.revisions[name] + 1
+    f.modified[newName] = time.Now()
+    delete(f.revisions, name)
+    return nil
+}
+
+func (f *memFolder) changed(ctx context.Context, since time.Time) (map[string]int64, error) {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    changed := make(map[string]int64)
+    for name, modified := range f.modified {
+        if modified.After(since) {
+            changed[name] = f.revisions[name]
+        }
+    }
+    return changed, nil
+}
+
+// partitionReads returns the day files read since the last call
+func (f *memFolder) partitionReads() []string {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    var reads []string
+    for _, name := range f.reads {
+        if dir, _ := path.Split(name); dir != "" && dir != indexDir+"/" {
+            reads = append(reads, name)
+        }
+    }
+    f.reads = nil
+    return reads
+}
+
+func newTestStorage(t *testing.T, files folder) *DriveStorage {
+    t.Helper()
+
+    ds, err := newDriveStorage(context.Background(), files, time.Local)
+    if err != nil {
+        t.Fatalf("newDriveStorage: %v", err)
+    }
+    return ds
+}
+
+func TestConformance(t *testing.T) {
+    storagetest.Run(t, func(t *testing.T) storage.Storage {
+        return newTestStorage(t, newMemFolder())
+    })
+}
+
+func TestReadsTouchOnePartition(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    day := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.Local)
+    for i, date := range []time.Time{day.AddDate(0, 0, -1), day, day.AddDate(0, 0, 1)} {
+        txnID := "TXN" + string(rune('A'+i))
+        if err := ds.SaveTransaction(ctx, &models.Transaction{TransactionID: txnID, Date: date, Status: "pending"}); err != nil {
+            t.Fatalf("SaveTransaction: %v", err)
+        }
+        if err := ds.SaveLotteryEntry(ctx, &models.LotteryEntry{EntryID: "E" + txnID, TransactionID: txnID, EntryDate: date}); err != nil {
+            t.Fatalf("SaveLotteryEntry: %v", err)
+        }
+    }
+
+    // A fresh instance has nothing cached, so every read reaches the folder
+    ds = newTestStorage(t, files)
+    files.partitionReads()
+
+    entries, err := ds.GetEntriesByDate(ctx, day)
+    if err != nil {
+        t.Fatalf("GetEntriesByDate: %v", err)
+    }
+    if len(entries) != 1 || entries[0].EntryID != "ETXNB" {
+        t.Errorf("GetEntriesByDate returned %d entries, want ETXNB only", len(entries))
+    }
+    if reads := files.partitionReads(); len(reads) != 1 || reads[0] != "entries/2026-10-16.json" {
+        t.Errorf("GetEntriesByDate read %v, want [entries/2026-10-16.json]", reads)
+    }
+
+    if _, err := ds.GetEntriesByTransaction(ctx, "TXNC"); err != nil {
+        t.Fatalf("GetEntriesByTransaction: %v", err)
+    }
+    if reads := files.partitionReads(); len(reads) != 1 || reads[0] != "entries/2026-10-17.json" {
+        t.Errorf("GetEntriesByTransaction read %v, want [entries/2026-10-17.json]", reads)
+    }
+
+    used, err := ds.IsTransactionUsed(ctx, "TXNA")
+    if err != nil || !used {
+        t.Errorf("IsTransactionUsed = %v, %v; want true", used, err)
+    }
+    if reads := files.partitionReads(); len(reads) != 0 {
+        t.Errorf("IsTransactionUsed read %v, want the index only", reads)
+    }
+}
+
+func TestSaveRewritesOneIndexShard(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    date := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.Local)
+    for i := 0; i < 50; i++ {
+        txnID := fmt.Sprintf("TXN%d", i)
+        if err := ds.SaveTransaction(ctx, &models.Transaction{TransactionID: txnID, Date: date, Status: "pending"}); err != nil {
+            t.Fatalf("SaveTransaction: %v", err)
+        }
+    }
+
+    var written []string
+    files.beforeWrite = func(name string) {
+        written = append(written, name)
+    }
+    if err := ds.SaveTransaction(ctx, &models.Transaction{TransactionID: "TXN-NEW", Date: date, Status: "pending"}); err != nil {
+        t.Fatalf("SaveTransaction: %v", err)
+    }
+    want := []string{shardName(txnsDir, "TXN-NEW"), "transactions/2026-10-16.json"}
+    if fmt.Sprint(written) != fmt.Sprint(want) {
+        t.Errorf("SaveTransaction wrote %v, want %v", written, want)
+    }
+
+    var idx map[string]json.RawMessage
+    json.Unmarshal(files.files[indexFile], &idx)
+    if _, ok := idx["transactions"]; ok {
+        t.Errorf("index.json still holds transaction refs: %s", files.files[indexFile])
+    }
+}
+
+func TestFailedSaveReleasesTransaction(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    // Every write of the day file conflicts, so the record is never written
+    files.beforeWrite = func(name string) {
+        if dir, _ := path.Split(name); dir == txnsDir+"/" {
+            files.set(name, []*models.Transaction{})
+        }
+    }
+    txn := &models.Transaction{TransactionID: "TXN1", Date: time.Now(), Status: "pending"}
+    if err := ds.SaveTransaction(ctx, txn); !errors.Is(err, storage.ErrConflict) {
+        t.Fatalf("SaveTransaction = %v, want ErrConflict", err)
+    }
+    if used, err := ds.IsTransactionUsed(ctx, "TXN1"); err != nil || used {
+        t.Errorf("IsTransactionUsed = %v, %v after a failed save; want false", used, err)
+    }
+
+    files.beforeWrite = nil
+    if err := ds.SaveTransaction(ctx, txn); err != nil {
+        t.Errorf("SaveTransaction after a failed save: %v", err)
+    }
+}
+
+func TestMigrateSingleFileLayout(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+
+    first := time.Date(2026, time.October, 15, 20, 0, 0, 0, time.Local)
+    second := first.AddDate(0, 0, 1)
+    files.set(legacyTransactionsFile, []*models.Transaction{
+        {TransactionID: "TXN1", Date: first, Status: "verified"},
+        {TransactionID: "TXN2", Date: second, Status: "pending"},
+    })
+    files.set(legacyEntriesFile, []*models.LotteryEntry{
+        {EntryID: "E1", TransactionID: "TXN1", DrawID: "D1", EntryDate: first},
+        {EntryID: "E2", TransactionID: "TXN2", EntryDate: second},
+    })
+    files.set(legacyWinnersFile, []*models.Winner{
+        {WinnerID: "W1", UserID: 42, Date: first, PaymentStatus: "pending"},
+    })
+    files.set(legacyAdminActionsFile, []*models.AdminAction{
+        {AdminID: 9001, ActionType: "draw", Timestamp: first},
+        {AdminID: 9001, ActionType: "verify", Timestamp: second},
+    })
+
+    ds := newTestStorage(t, files)
+
+    for _, name := range []string{
+        "transactions/2026-10-15.json", "transactions/2026-10-16.json",
+        "entries/2026-10-15.json", "entries/2026-10-16.json",
+        "winners/2026-10-15.json",
+        "admin_actions/2026-10-15.json", "admin_actions/2026-10-16.json",
+        indexFile,
+        legacyTransactionsFile + migratedSuffix, legacyEntriesFile + migratedSuffix,
+        legacyWinnersFile + migratedSuffix, legacyAdminActionsFile + migratedSuffix,
+    } {
+        if _, ok := files.files[name]; !ok {
+            t.Errorf("%s missing after migration", name)
+        }
+    }
+    if _, ok := files.files[legacyEntriesFile]; ok {
+        t.Errorf("%s not renamed after migration", legacyEntriesFile)
+    }
+
+    txn, err := ds.GetTransaction(ctx, "TXN1")
+    if err != nil || txn.Status != "verified" {
+        t.Errorf("GetTransaction(TXN1) = %+v, %v", txn, err)
+    }
+    pending, err := ds.GetTransactionsByStatus(ctx, "pending")
+    if err != nil || len(pending) != 1 || pending[0].TransactionID != "TXN2" {
+        t.Errorf("GetTransactionsByStatus(pending) = %d transactions, %v; want TXN2", len(pending), err)
+    }
+    entries, err := ds.GetEntriesByDraw(ctx, "D1")
+    if err != nil || len(entries) != 1 || entries[0].EntryID != "E1" {
+        t.Errorf("GetEntriesByDraw(D1) = %d entries, %v; want E1", len(entries), err)
+    }
+    winners, err := ds.GetWinnersByUser(ctx, 42)
+    if err != nil || len(winners) != 1 {
+        t.Errorf("GetWinnersByUser(42) = %d winners, %v; want 1", len(winners), err)
+    }
+    actions, err := ds.GetAdminActions(ctx, storage.AdminActionFilter{Limit: 1})
+    if err != nil || len(actions) != 1 || actions[0].ActionType != "verify" {
+        t.Errorf("GetAdminActions(limit 1) = %+v, %v; want the newest action", actions, err)
+    }
+
+    // Reopening uses the index and does not migrate again
+    ds.SaveTransaction(ctx, &models.Transaction{TransactionID: "TXN3", Date: second, Status: "pending"})
+    reopened := newTestStorage(t, files)
+    for _, id := range []string{"TXN1", "TXN2", "TXN3"} {
+        if used, _ := reopened.IsTransactionUsed(ctx, id); !used {
+            t.Errorf("IsTransactionUsed(%s) = false after reopening", id)
+        }
+    }
+}
+
+func TestSplitVersion1Index(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+
+    date := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.Local)
+    files.set("transactions/2026-10-16.json", []*models.Transaction{{TransactionID: "TXN1", Date: date, Status: "pending"}})
+    files.set("entries/2026-10-16.json", []*models.LotteryEntry{{EntryID: "E1", TransactionID: "TXN1", DrawID: "D1", EntryDate: date}})
+    files.set("winners/2026-10-16.json", []*models.Winner{{WinnerID: "W1", UserID: 42, Date: date, PaymentStatus: "pending"}})
+    files.set(indexFile, &index{
+        Version:      1,
+        Transactions: map[string]ref{"TXN1": {Day: "2026-10-16", Status: "pending"}},
+        Entries:      map[string]ref{"E1": {Day: "2026-10-16", Txn: "TXN1", Draw: "D1"}},
+        Winners:      map[string]ref{"W1": {Day: "2026-10-16", Status: "pending", User: 42}},
+    })
+
+    ds := newTestStorage(t, files)
+
+    var idx index
+    json.Unmarshal(files.files[indexFile], &idx)
+    if idx.Version != indexVersion || idx.Transactions != nil || idx.Entries != nil || idx.Winners != nil {
+        t.Errorf("index after split = %s", files.files[indexFile])
+    }
+    if used, err := ds.IsTransactionUsed(ctx, "TXN1"); err != nil || !used {
+        t.Errorf("IsTransactionUsed(TXN1) = %v, %v; want true", used, err)
+    }
+    entries, err := ds.GetEntriesByDraw(ctx, "D1")
+    if err != nil || len(entries) != 1 {
+        t.Errorf("GetEntriesByDraw(D1) = %d entries, %v; want E1", len(entries), err)
+    }
+    winners, err := ds.GetWinnersByPaymentStatus(ctx, "pending")
+    if err != nil || len(winners) != 1 {
+        t.Errorf("GetWinnersByPaymentStatus(pending) = %d winners, %v; want W1", len(winners), err)
+    }
+}
+
+func TestWriteRetriesAfterConflict(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    // Another instance saves a user between our read and our write
+    files.beforeWrite = func(name string) {
+        files.beforeWrite = nil
+        files.set(name, []*models.User{{UserID: 1, Username: "other"}})
+    }
+    if err := ds.SaveUser(ctx, &models.User{UserID: 2, Username: "ours"}); err != nil {
+        t.Fatalf("SaveUser: %v", err)
+    }
+
+    for _, id := range []int64{1, 2} {
+        if _, err := ds.GetUser(ctx, id); err != nil {
+            t.Errorf("GetUser(%d): %v", id, err)
+        }
+    }
+}
+
+func TestDuplicateTransactionAcrossInstances(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    first := newTestStorage(t, files)
+    second := newTestStorage(t, files)
+
+    txn := &models.Transaction{TransactionID: "TXN1", Date: time.Now(), Status: "pending"}
+    if err := first.SaveTransaction(ctx, txn); err != nil {
+        t.Fatalf("SaveTransaction (first): %v", err)
+    }
+    if err := second.SaveTransaction(ctx, txn); !errors.Is(err, storage.ErrDuplicateTransaction) {
+        t.Errorf("SaveTransaction (second) = %v, want ErrDuplicateTransaction", err)
+    }
+}
+
+func TestWriteGivesUpAfterRepeatedConflicts(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    writes := 0
+    files.beforeWrite = func(name string) {
+        writes++
+        files.set(name, []*models.UserState{})
+    }
+    err := ds.SaveUserState(ctx, &models.UserState{UserID: 1, CurrentState: "awaiting_payment"})
+
+    if !errors.Is(err, storage.ErrConflict) {
+        t.Fatalf("SaveUserState = %v, want ErrConflict", err)
+    }
+    var storageErr *storage.StorageError
+    if !errors.As(err, &storageErr) || storageErr.Operation != "SaveUserState" {
+        t.Errorf("SaveUserState error = %#v, want a StorageError for SaveUserState", err)
+    }
+    if writes != maxWriteAttempts {
+        t.Errorf("%d write attempts, want %d", writes, maxWriteAttempts)
+    }
+}
+
+func TestCacheServesRepeatedReads(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    if err := ds.SaveUserState(ctx, &models.UserState{UserID: 1, CurrentState: "awaiting_payment"}); err != nil {
+        t.Fatalf("SaveUserState: %v", err)
+    }
+    files.reads = nil
+    before := ds.CacheStats()
+
+    for i := 0; i < 3; i++ {
+        state, err := ds.GetUserState(ctx, 1)
+        if err != nil || state.CurrentState != "awaiting_payment" {
+            t.Fatalf("GetUserState = %+v, %v", state, err)
+        }
+        // Changes to a returned record must not leak into the cache
+        state.CurrentState = "changed"
+    }
+
+    if len(files.reads) != 0 {
+        t.Errorf("GetUserState read %v from the folder, want the cache only", files.reads)
+    }
+    if stats := ds.CacheStats(); stats.Hits-before.Hits != 3 || stats.Misses != before.Misses {
+        t.Errorf("CacheStats = %+v after %+v, want 3 more hits and no more misses", stats, before)
+    }
+}
+
+func TestCacheRefreshPicksUpOutsideChanges(t *testing.T) {
+    ctx := context.Background()
+    files := newMemFolder()
+    ds := newTestStorage(t, files)
+
+    if _, err := ds.GetUser(ctx, 1); err == nil {
+        t.Fatalf("GetUser found a user in an empty folder")
+    }
+
+    // Another instance or a manual edit adds the user in Drive
+    files.set(usersFile, []*models.User{{UserID: 1, Username: "other"}})
+    if _, err := ds.GetUser(ctx, 1); err == nil {
+        t.Errorf("GetUser saw the outside change before a refresh")
+    }
+
+    if err := ds.cache.refresh(ctx); err != nil {
+        t.Fatalf("refresh: %v", err)
+    }
+    if user, err := ds.GetUser(ctx, 1); err != nil || user.Username != "other" {
+        t.Errorf("GetUser after refresh = %+v, %v; want the outside change", user, err)
+    }
+}
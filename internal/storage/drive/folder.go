@@ -0,0 +1,341 @@
+package drive
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "path"
+    "sync"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+
+    drivev2 "google.golang.org/api/drive/v2"
+    "google.golang.org/api/drive/v3"
+    "google.golang.org/api/googleapi"
+)
+
+const (
+    folderMimeType = "application/vnd.google-apps.folder"
+
+    // maxWriteAttempts bounds how often modify retries after a conflict
+    maxWriteAttempts = 5
+)
+
+var (
+    // errNoFile is returned by rename when the file does not exist
+    errNoFile = errors.New("file does not exist")
+    // errNoChange tells modify that there is nothing to write
+    errNoChange = errors.New("no change")
+)
+
+// folder is where DriveStorage keeps its JSON files. Names may have one
+// directory level, e.g. "entries/2026-10-16.json".
+//
+// Every file has a revision that changes whenever the file does; 0 means
+// the file does not exist. Writes are conditional on the revision the
+// writer read, so a file changed by another bot instance or by hand is not
+// silently overwritten.
+type folder interface {
+    // read decodes the named file into v and returns its revision
+    read(ctx context.Context, name string, v interface{}) (int64, error)
+    // write replaces the named file with v as JSON, creating it if needed,
+    // and returns the new revision. It fails with storage.ErrConflict if the
+    // file's revision is no longer revision.
+    write(ctx context.Context, name string, v interface{}, revision int64) (int64, error)
+    // rename gives a file a new name in the same directory; it returns
+    // errNoFile if the file does not exist
+    rename(ctx context.Context, name, newName string) error
+    // changed returns the revisions of the files modified after since
+    changed(ctx context.Context, since time.Time) (map[string]int64, error)
+}
+
+// modify applies mutate to the current contents of the named file and
+// writes the result back. If the file changed in the meantime it is read
+// again and mutate re-applied, up to maxWriteAttempts times. mutate may
+// return errNoChange to skip the write.
+func modify[T any](ctx context.Context, files folder, name string, mutate func(*T) error) error {
+    for attempt := 1; ; attempt++ {
+        var v T
+        revision, err := files.read(ctx, name, &v)
+        if err != nil {
+            return err
+        }
+        if err := mutate(&v); err != nil {
+            if errors.Is(err, errNoChange) {
+                return nil
+            }
+            return err
+        }
+
+        _, err = files.write(ctx, name, v, revision)
+        if err == nil || !errors.Is(err, storage.ErrConflict) || attempt == maxWriteAttempts {
+            return err
+        }
+    }
+}
+
+// replace overwrites the named file with v, whatever it holds
+func replace[T any](ctx context.Context, files folder, name string, v T) error {
+    return modify(ctx, files, name, func(current *T) error {
+        *current = v
+        return nil
+    })
+}
+
+// driveFolder is a folder in Google Drive. Its revisions are the Drive
+// file version. The v3 API has no conditional update, so updates go through
+// the v2 API with an If-Match precondition on the ETag seen alongside the
+// version; Drive then rejects an update to a file changed in the meantime.
+type driveFolder struct {
+    service   *drive.Service
+    v2service *drivev2.Service // for conditional updates
+    rootID    string
+    mutex     sync.Mutex
+    ids       map[string]string  // file or directory name to Drive file ID
+    tags      map[string]fileTag // file name to the version and ETag last seen
+}
+
+// fileTag pairs a file's version with the ETag it had at that version
+type fileTag struct {
+    version int64
+    etag    string
+}
+
+func newDriveFolder(service *drive.Service, v2service *drivev2.Service, rootID string) *driveFolder {
+    return &driveFolder{
+        service:   service,
+        v2service: v2service,
+        rootID:    rootID,
+        ids:       make(map[string]string),
+        tags:      make(map[string]fileTag),
+    }
+}
+
+// find returns the Drive ID of name, or "" if it does not exist. With
+// createDir set, a missing parent directory is created.
+func (f *driveFolder) find(ctx context.Context, name string, createDir bool) (string, error) {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+    return f.findLocked(ctx, name, createDir)
+}
+
+func (f *driveFolder) findLocked(ctx context.Context, name string, createDir bool) (string, error) {
+    if id, ok := f.ids[name]; ok {
+        return id, nil
+    }
+
+    parentID := f.rootID
+    if dir, _ := path.Split(name); dir != "" {
+        id, err := f.dirLocked(ctx, path.Clean(dir), createDir)
+        if err != nil || id == "" {
+            return "", err
+        }
+        parentID = id
+    }
+
+    id, err := f.search(ctx, parentID, path.Base(name))
+    if err != nil {
+        return "", fmt.Errorf("failed to search for file %s: %v", name, err)
+    }
+    if id != "" {
+        f.ids[name] = id
+    }
+    return id, nil
+}
+
+func (f *driveFolder) dirLocked(ctx context.Context, dir string, create bool) (string, error) {
+    id, err := f.findLocked(ctx, dir, false)
+    if err != nil || id != "" || !create {
+        return id, err
+    }
+
+    created, err := f.service.Files.Create(&drive.File{
+        Name:     dir,
+        Parents:  []string{f.rootID},
+        MimeType: folderMimeType,
+    }).Context(ctx).Do()
+    if err != nil {
+        return "", fmt.Errorf("failed to create directory %s: %v", dir, err)
+    }
+    f.ids[dir] = created.Id
+    return created.Id, nil
+}
+
+func (f *driveFolder) search(ctx context.Context, parentID, name string) (string, error) {
+    query := fmt.Sprintf("name='%s' and '%s' in parents and trashed=false", name, parentID)
+    files, err := f.service.Files.List().Q(query).Fields("files(id)").Context(ctx).Do()
+    if err != nil {
+        return "", err
+    }
+    if len(files.Files) > 0 {
+        return files.Files[0].Id, nil
+    }
+    return "", nil
+}
+
+// stat returns the current version and ETag of a file; the caller holds
+// the mutex
+func (f *driveFolder) stat(ctx context.Context, name, id string) (fileTag, error) {
+    file, err := f.v2service.Files.Get(id).Fields("etag", "version").Context(ctx).Do()
+    if err != nil {
+        return fileTag{}, fmt.Errorf("failed to get version of file %s: %v", name, err)
+    }
+    tag := fileTag{version: file.Version, etag: file.Etag}
+    f.tags[name] = tag
+    return tag, nil
+}
+
+func (f *driveFolder) read(ctx context.Context, name string, v interface{}) (int64, error) {
+    id, err := f.find(ctx, name, false)
+    if err != nil || id == "" {
+        return 0, err
+    }
+
+    // The version is read first: if the file changes before the download,
+    // the next write conflicts instead of losing the change
+    f.mutex.Lock()
+    tag, err := f.stat(ctx, name, id)
+    f.mutex.Unlock()
+    if err != nil {
+        return 0, err
+    }
+
+    resp, err := f.service.Files.Get(id).Context(ctx).Download()
+    if err != nil {
+        return 0, fmt.Errorf("failed to download file %s: %v", name, err)
+    }
+    defer resp.Body.Close()
+
+    if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+        return 0, fmt.Errorf("failed to decode JSON from file %s: %v", name, err)
+    }
+    return tag.version, nil
+}
+
+func (f *driveFolder) write(ctx context.Context, name string, v interface{}, revision int64) (int64, error) {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return 0, fmt.Errorf("failed to marshal data: %v", err)
+    }
+
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    id, err := f.findLocked(ctx, name, true)
+    if err != nil {
+        return 0, err
+    }
+    if id != "" {
+        tag, ok := f.tags[name]
+        if !ok || tag.version != revision {
+            if tag, err = f.stat(ctx, name, id); err != nil {
+                return 0, err
+            }
+        }
+        if tag.version != revision {
+            return 0, fmt.Errorf("file %s changed since it was read: %w", name, storage.ErrConflict)
+        }
+
+        call := f.v2service.Files.Update(id, nil).Media(bytes.NewReader(data)).Fields("etag", "version").Context(ctx)
+        call.Header().Set("If-Match", tag.etag)
+        updated, err := call.Do()
+        var apiErr *googleapi.Error
+        if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+            delete(f.tags, name)
+            return 0, fmt.Errorf("file %s changed since it was read: %w", name, storage.ErrConflict)
+        }
+        if err != nil {
+            return 0, fmt.Errorf("failed to write file %s: %v", name, err)
+        }
+        f.tags[name] = fileTag{version: updated.Version, etag: updated.Etag}
+        return updated.Version, nil
+    }
+    if revision != 0 {
+        return 0, fmt.Errorf("file %s was removed since it was read: %w", name, storage.ErrConflict)
+    }
+
+    parentID := f.rootID
+    dir, base := path.Split(name)
+    if dir != "" {
+        parentID = f.ids[path.Clean(dir)]
+    }
+    created, err := f.service.Files.Create(&drive.File{
+        Name:     base,
+        Parents:  []string{parentID},
+        MimeType: "application/json",
+    }).Media(bytes.NewReader(data)).Fields("id", "version").Context(ctx).Do()
+    if err != nil {
+        return 0, fmt.Errorf("failed to create file %s: %v", name, err)
+    }
+    f.ids[name] = created.Id
+    return created.Version, nil
+}
+
+func (f *driveFolder) rename(ctx context.Context, name, newName string) error {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    id, err := f.findLocked(ctx, name, false)
+    if err != nil {
+        return err
+    }
+    if id == "" {
+        return errNoFile
+    }
+
+    if _, err := f.service.Files.Update(id, &drive.File{Name: path.Base(newName)}).Context(ctx).Do(); err != nil {
+        return fmt.Errorf("failed to rename file %s: %v", name, err)
+    }
+    delete(f.ids, name)
+    delete(f.tags, name)
+    f.ids[newName] = id
+    return nil
+}
+
+func (f *driveFolder) changed(ctx context.Context, since time.Time) (map[string]int64, error) {
+    modified := fmt.Sprintf("modifiedTime > '%s'", since.UTC().Format(time.RFC3339))
+    changed := make(map[string]int64)
+    dirs := make(map[string]string) // directory ID to name
+
+    query := fmt.Sprintf("'%s' in parents and trashed=false and (%s or mimeType='%s')", f.rootID, modified, folderMimeType)
+    err := f.list(ctx, query, func(file *drive.File) {
+        if file.MimeType == folderMimeType {
+            dirs[file.Id] = file.Name
+        } else {
+            changed[file.Name] = file.Version
+        }
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    for id, dir := range dirs {
+        query := fmt.Sprintf("'%s' in parents and trashed=false and %s", id, modified)
+        err := f.list(ctx, query, func(file *drive.File) {
+            changed[path.Join(dir, file.Name)] = file.Version
+        })
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return changed, nil
+}
+
+func (f *driveFolder) list(ctx context.Context, query string, fn func(*drive.File)) error {
+    call := f.service.Files.List().Q(query).Fields("nextPageToken, files(id, name, mimeType, version)")
+    err := call.Pages(ctx, func(page *drive.FileList) error {
+        for _, file := range page.Files {
+            fn(file)
+        }
+        return nil
+    })
+    if err != nil {
+        return fmt.Errorf("failed to list changed files: %v", err)
+    }
+    return nil
+}
@@ -0,0 +1,107 @@
+package drive
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+
+    drivev2 "google.golang.org/api/drive/v2"
+    "google.golang.org/api/drive/v3"
+    "google.golang.org/api/option"
+)
+
+// fakeDrive serves one JSON file through the parts of the Drive v2 and v3
+// APIs that driveFolder uses. Updates honour If-Match like Drive does.
+type fakeDrive struct {
+    mutex   sync.Mutex
+    content string
+    version int64
+}
+
+func (d *fakeDrive) etag() string {
+    return fmt.Sprintf(`"etag-%d"`, d.version)
+}
+
+// change updates the file the way another writer would
+func (d *fakeDrive) change(content string) {
+    d.mutex.Lock()
+    defer d.mutex.Unlock()
+    d.content = content
+    d.version++
+}
+
+func (d *fakeDrive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    d.mutex.Lock()
+    defer d.mutex.Unlock()
+
+    switch {
+    case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+        fmt.Fprint(w, `{"files": [{"id": "F1"}]}`)
+    case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/F1":
+        fmt.Fprint(w, d.content)
+    case r.Method == http.MethodGet && r.URL.Path == "/drive/v2/files/F1":
+        fmt.Fprintf(w, `{"etag": %q, "version": "%d"}`, d.etag(), d.version)
+    case r.Method == http.MethodPut && r.URL.Path == "/upload/drive/v2/files/F1":
+        if match := r.Header.Get("If-Match"); match != "" && match != d.etag() {
+            w.WriteHeader(http.StatusPreconditionFailed)
+            fmt.Fprint(w, `{"error": {"code": 412, "message": "Precondition Failed"}}`)
+            return
+        }
+        d.version++
+        fmt.Fprintf(w, `{"etag": %q, "version": "%d"}`, d.etag(), d.version)
+    default:
+        http.NotFound(w, r)
+    }
+}
+
+func newFakeDriveFolder(t *testing.T, fake *fakeDrive) *driveFolder {
+    t.Helper()
+    ctx := context.Background()
+
+    server := httptest.NewServer(fake)
+    t.Cleanup(server.Close)
+
+    service, err := drive.NewService(ctx, option.WithEndpoint(server.URL+"/drive/v3/"), option.WithoutAuthentication())
+    if err != nil {
+        t.Fatalf("drive.NewService: %v", err)
+    }
+    v2service, err := drivev2.NewService(ctx, option.WithEndpoint(server.URL+"/drive/v2/"), option.WithoutAuthentication())
+    if err != nil {
+        t.Fatalf("drivev2.NewService: %v", err)
+    }
+    return newDriveFolder(service, v2service, "root")
+}
+
+func TestDriveFolderWriteIsConditional(t *testing.T) {
+    ctx := context.Background()
+    fake := &fakeDrive{content: `["ours"]`, version: 1}
+    files := newFakeDriveFolder(t, fake)
+
+    var v []string
+    revision, err := files.read(ctx, usersFile, &v)
+    if err != nil || revision != 1 {
+        t.Fatalf("read = %d, %v; want revision 1", revision, err)
+    }
+
+    // Another writer changes the file after our read; the update must be
+    // refused by Drive rather than overwrite it
+    fake.change(`["theirs"]`)
+    if _, err := files.write(ctx, usersFile, []string{"ours", "new"}, revision); !errors.Is(err, storage.ErrConflict) {
+        t.Fatalf("write over a changed file = %v, want ErrConflict", err)
+    }
+
+    revision, err = files.read(ctx, usersFile, &v)
+    if err != nil || revision != 2 {
+        t.Fatalf("read again = %d, %v; want revision 2", revision, err)
+    }
+    newRevision, err := files.write(ctx, usersFile, append(v, "new"), revision)
+    if err != nil || newRevision != 3 {
+        t.Errorf("write after reading again = %d, %v; want revision 3", newRevision, err)
+    }
+}
@@ -0,0 +1,328 @@
+package drive
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "hash/fnv"
+    "log"
+    "sort"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+)
+
+// Records that accumulate with history are kept in one file per day, e.g.
+// "entries/2026-10-16.json", so a save rewrites one small file and a bad
+// write can only damage that day. Refs mapping record IDs to their day,
+// with the fields looked up across days, are spread over a fixed number of
+// index shards by a hash of the ID, e.g. "index/entries-07.json": a save
+// rewrites one shard, a lookup by ID reads one shard and one partition,
+// and a query by status, draw or user reads every shard of its kind.
+// index.json itself only holds the layout version and the days with admin
+// actions. Like every file, the index and its shards are served from the
+// in-memory cache, so records added by another bot instance are seen only
+// once RunCacheRefresh notices the change, up to database.cache_refresh
+// later. A write to a file changed elsewhere conflicts and re-reads it, so
+// a stale cache can delay a lookup but never lose an update or let a
+// duplicate transaction through.
+const (
+    indexFile      = "index.json"
+    indexVersion   = 2
+    indexDir       = "index"
+    indexShards    = 16
+    entriesDir     = "entries"
+    txnsDir        = "transactions"
+    winnersDir     = "winners"
+    actionsDir     = "admin_actions"
+    dayFormat      = "2006-01-02"
+    migratedSuffix = ".migrated"
+)
+
+// Single-file layout used before partitioning; migrate splits these up
+const (
+    legacyEntriesFile      = "lottery_entries.json"
+    legacyTransactionsFile = "transactions.json"
+    legacyWinnersFile      = "winners.json"
+    legacyAdminActionsFile = "admin_actions.json"
+)
+
+// ref locates one record and carries the fields queried across days
+type ref struct {
+    Day    string `json:"day"`
+    Status string `json:"status,omitempty"`
+    Txn    string `json:"txn,omitempty"`
+    Draw   string `json:"draw,omitempty"`
+    User   int64  `json:"user,omitempty"`
+}
+
+type index struct {
+    Version    int      `json:"version"`
+    ActionDays []string `json:"action_days"` // sorted
+
+    // Version 1 kept every ref here; splitIndex moves them to the shards
+    Transactions map[string]ref `json:"transactions,omitempty"`
+    Entries      map[string]ref `json:"entries,omitempty"`
+    Winners      map[string]ref `json:"winners,omitempty"`
+}
+
+// shardFile names shard n of the refs of one kind of record, which is
+// named after its partition directory
+func shardFile(kind string, n uint32) string {
+    return fmt.Sprintf("%s/%s-%02d.json", indexDir, kind, n)
+}
+
+// shardName names the shard holding the ref of a record ID
+func shardName(kind, id string) string {
+    h := fnv.New32a()
+    h.Write([]byte(id))
+    return shardFile(kind, h.Sum32()%indexShards)
+}
+
+func partition(dir, day string) string {
+    return dir + "/" + day + ".json"
+}
+
+// day returns the partition day of t
+func (ds *DriveStorage) day(t time.Time) string {
+    return t.In(ds.location).Format(dayFormat)
+}
+
+// daysFor returns the partition days that can hold records from date's
+// calendar day in date's location: one, or two if the locations differ
+func (ds *DriveStorage) daysFor(date time.Time) []string {
+    start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+    first, last := ds.day(start), ds.day(start.AddDate(0, 0, 1).Add(-time.Nanosecond))
+    if first == last {
+        return []string{first}
+    }
+    return []string{first, last}
+}
+
+// readRef looks up the ref of a record ID
+func (ds *DriveStorage) readRef(ctx context.Context, kind, id string) (ref, bool, error) {
+    var refs map[string]ref
+    if _, err := ds.files.read(ctx, shardName(kind, id), &refs); err != nil {
+        return ref{}, false, err
+    }
+    r, ok := refs[id]
+    return r, ok, nil
+}
+
+// modifyRefs applies mutate to the refs in the named shard, see modify
+func (ds *DriveStorage) modifyRefs(ctx context.Context, shard string, mutate func(map[string]ref) error) error {
+    return modify(ctx, ds.files, shard, func(refs *map[string]ref) error {
+        if *refs == nil {
+            *refs = make(map[string]ref)
+        }
+        return mutate(*refs)
+    })
+}
+
+// refDays returns the sorted days of the refs of a kind that match. It
+// reads every shard of the kind.
+func (ds *DriveStorage) refDays(ctx context.Context, kind string, match func(ref) bool) ([]string, error) {
+    seen := make(map[string]bool)
+    var days []string
+    for n := uint32(0); n < indexShards; n++ {
+        var refs map[string]ref
+        if _, err := ds.files.read(ctx, shardFile(kind, n), &refs); err != nil {
+            return nil, err
+        }
+        for _, r := range refs {
+            if match(r) && !seen[r.Day] {
+                seen[r.Day] = true
+                days = append(days, r.Day)
+            }
+        }
+    }
+    sort.Strings(days)
+    return days, nil
+}
+
+func (idx *index) addActionDay(day string) bool {
+    i := sort.SearchStrings(idx.ActionDays, day)
+    if i < len(idx.ActionDays) && idx.ActionDays[i] == day {
+        return false
+    }
+    idx.ActionDays = append(idx.ActionDays, "")
+    copy(idx.ActionDays[i+1:], idx.ActionDays[i:])
+    idx.ActionDays[i] = day
+    return true
+}
+
+// readDay reads one partition into v; a missing partition is empty
+func (ds *DriveStorage) readDay(ctx context.Context, dir, day string, v interface{}) error {
+    _, err := ds.files.read(ctx, partition(dir, day), v)
+    return err
+}
+
+func (ds *DriveStorage) readIndex(ctx context.Context) (*index, error) {
+    var idx index
+    if _, err := ds.files.read(ctx, indexFile, &idx); err != nil {
+        return nil, err
+    }
+    return &idx, nil
+}
+
+// modifyIndex applies mutate to the current index, see modify
+func (ds *DriveStorage) modifyIndex(ctx context.Context, mutate func(*index) error) error {
+    return modify(ctx, ds.files, indexFile, func(idx *index) error {
+        idx.Version = indexVersion
+        return mutate(idx)
+    })
+}
+
+// prepareLayout migrates the single-file layout or a version 1 index if the
+// folder still uses it
+func (ds *DriveStorage) prepareLayout(ctx context.Context) error {
+    var idx index
+    revision, err := ds.files.read(ctx, indexFile, &idx)
+    if err != nil {
+        return err
+    }
+    if revision != 0 {
+        if idx.Version < indexVersion {
+            if err := ds.splitIndex(ctx, &idx); err != nil {
+                return fmt.Errorf("failed to split Drive index: %v", err)
+            }
+        }
+        // A migration that stopped before renaming the old files already
+        // wrote every partition and the index
+        return ds.retireLegacyFiles(ctx)
+    }
+
+    if err := ds.migrate(ctx); err != nil {
+        return fmt.Errorf("failed to migrate Drive layout: %v", err)
+    }
+    return nil
+}
+
+// splitIndex moves the refs held in a version 1 index into the shards and
+// then writes the index without them, so an interrupted split simply runs
+// again on the next start
+func (ds *DriveStorage) splitIndex(ctx context.Context, idx *index) error {
+    for kind, all := range map[string]map[string]ref{
+        txnsDir:    idx.Transactions,
+        entriesDir: idx.Entries,
+        winnersDir: idx.Winners,
+    } {
+        shards := make(map[string]map[string]ref)
+        for id, r := range all {
+            name := shardName(kind, id)
+            if shards[name] == nil {
+                shards[name] = make(map[string]ref)
+            }
+            shards[name][id] = r
+        }
+        for name, shard := range shards {
+            err := ds.modifyRefs(ctx, name, func(refs map[string]ref) error {
+                for id, r := range shard {
+                    refs[id] = r
+                }
+                return nil
+            })
+            if err != nil {
+                return err
+            }
+        }
+    }
+
+    idx.Version = indexVersion
+    idx.Transactions, idx.Entries, idx.Winners = nil, nil, nil
+    return replace(ctx, ds.files, indexFile, idx)
+}
+
+// migrate splits the single-file layout into day partitions. Partitions
+// are written whole from the old files and the index last, so an
+// interrupted migration simply runs again on the next start. The old files
+// are kept with a ".migrated" suffix.
+func (ds *DriveStorage) migrate(ctx context.Context) error {
+    idx := &index{
+        Transactions: make(map[string]ref),
+        Entries:      make(map[string]ref),
+        Winners:      make(map[string]ref),
+    }
+
+    var transactions []*models.Transaction
+    if _, err := ds.files.read(ctx, legacyTransactionsFile, &transactions); err != nil {
+        return err
+    }
+    txnDays := make(map[string][]*models.Transaction)
+    for _, txn := range transactions {
+        day := ds.day(txn.Date)
+        txnDays[day] = append(txnDays[day], txn)
+        idx.Transactions[txn.TransactionID] = ref{Day: day, Status: txn.Status}
+    }
+    for day, txns := range txnDays {
+        if err := replace(ctx, ds.files, partition(txnsDir, day), txns); err != nil {
+            return err
+        }
+    }
+
+    var entries []*models.LotteryEntry
+    if _, err := ds.files.read(ctx, legacyEntriesFile, &entries); err != nil {
+        return err
+    }
+    entryDays := make(map[string][]*models.LotteryEntry)
+    for _, entry := range entries {
+        day := ds.day(entry.EntryDate)
+        entryDays[day] = append(entryDays[day], entry)
+        idx.Entries[entry.EntryID] = ref{Day: day, Txn: entry.TransactionID, Draw: entry.DrawID}
+    }
+    for day, dayEntries := range entryDays {
+        if err := replace(ctx, ds.files, partition(entriesDir, day), dayEntries); err != nil {
+            return err
+        }
+    }
+
+    var winners []*models.Winner
+    if _, err := ds.files.read(ctx, legacyWinnersFile, &winners); err != nil {
+        return err
+    }
+    winnerDays := make(map[string][]*models.Winner)
+    for _, winner := range winners {
+        day := ds.day(winner.Date)
+        winnerDays[day] = append(winnerDays[day], winner)
+        idx.Winners[winner.WinnerID] = ref{Day: day, Status: winner.PaymentStatus, User: winner.UserID}
+    }
+    for day, dayWinners := range winnerDays {
+        if err := replace(ctx, ds.files, partition(winnersDir, day), dayWinners); err != nil {
+            return err
+        }
+    }
+
+    var actions []*models.AdminAction
+    if _, err := ds.files.read(ctx, legacyAdminActionsFile, &actions); err != nil {
+        return err
+    }
+    actionDays := make(map[string][]*models.AdminAction)
+    for _, action := range actions {
+        day := ds.day(action.Timestamp)
+        actionDays[day] = append(actionDays[day], action)
+        idx.addActionDay(day)
+    }
+    for day, dayActions := range actionDays {
+        if err := replace(ctx, ds.files, partition(actionsDir, day), dayActions); err != nil {
+            return err
+        }
+    }
+
+    if err := ds.splitIndex(ctx, idx); err != nil {
+        return err
+    }
+    if n := len(transactions) + len(entries) + len(winners) + len(actions); n > 0 {
+        log.Printf("Migrated %d Drive records to the partitioned layout", n)
+    }
+    return ds.retireLegacyFiles(ctx)
+}
+
+func (ds *DriveStorage) retireLegacyFiles(ctx context.Context) error {
+    for _, name := range []string{legacyTransactionsFile, legacyEntriesFile, legacyWinnersFile, legacyAdminActionsFile} {
+        err := ds.files.rename(ctx, name, name+migratedSuffix)
+        if err != nil && !errors.Is(err, errNoFile) {
+            return err
+        }
+    }
+    return nil
+}
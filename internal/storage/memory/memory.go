@@ -0,0 +1,556 @@
+package memory
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// MemoryStorage keeps all data in process memory. It follows the same
+// semantics as DriveStorage and is meant for tests and dry runs.
+// Values are copied on the way in and out, so callers never share state
+// with the store.
+type MemoryStorage struct {
+    mutex        sync.RWMutex
+    users        []*models.User
+    transactions []*models.Transaction
+    entries      []*models.LotteryEntry
+    winners      []*models.Winner
+    draws        []*models.Draw
+    states       []*models.UserState
+    adminActions []*models.AdminAction
+}
+
+func NewMemoryStorage() *MemoryStorage {
+    return &MemoryStorage{}
+}
+
+func sameDay(t, date time.Time) bool {
+    startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+    endDate := startDate.Add(24 * time.Hour)
+    return !t.Before(startDate) && t.Before(endDate)
+}
+
+func (ms *MemoryStorage) SaveUser(ctx context.Context, user *models.User) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    u := *user
+    for i, existing := range ms.users {
+        if existing.UserID == user.UserID {
+            ms.users[i] = &u
+            return nil
+        }
+    }
+
+    ms.users = append(ms.users, &u)
+    return nil
+}
+
+func (ms *MemoryStorage) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    for _, user := range ms.users {
+        if user.UserID == userID {
+            u := *user
+            return &u, nil
+        }
+    }
+
+    return nil, storage.NewStorageError("GetUser", fmt.Errorf("user not found"))
+}
+
+func (ms *MemoryStorage) GetAllUsers(ctx context.Context, fromDate, toDate time.Time) ([]*models.User, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var users []*models.User
+    for _, user := range ms.users {
+        if (fromDate.IsZero() || !user.JoinedDate.Before(fromDate)) &&
+            (toDate.IsZero() || !user.JoinedDate.After(toDate)) {
+            u := *user
+            users = append(users, &u)
+        }
+    }
+
+    return users, nil
+}
+
+func (ms *MemoryStorage) SaveTransaction(ctx context.Context, txn *models.Transaction) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, existing := range ms.transactions {
+        if existing.TransactionID == txn.TransactionID {
+            return storage.NewStorageError("SaveTransaction", storage.ErrDuplicateTransaction)
+        }
+    }
+
+    t := *txn
+    ms.transactions = append(ms.transactions, &t)
+    return nil
+}
+
+func (ms *MemoryStorage) GetTransaction(ctx context.Context, txnID string) (*models.Transaction, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    for _, txn := range ms.transactions {
+        if txn.TransactionID == txnID {
+            t := *txn
+            return &t, nil
+        }
+    }
+
+    return nil, storage.NewStorageError("GetTransaction", fmt.Errorf("transaction not found"))
+}
+
+func (ms *MemoryStorage) GetTransactionsByDate(ctx context.Context, date time.Time) ([]*models.Transaction, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var txns []*models.Transaction
+    for _, txn := range ms.transactions {
+        if sameDay(txn.Date, date) {
+            t := *txn
+            txns = append(txns, &t)
+        }
+    }
+
+    return txns, nil
+}
+
+func (ms *MemoryStorage) IsTransactionUsed(ctx context.Context, txnID string) (bool, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    for _, txn := range ms.transactions {
+        if txn.TransactionID == txnID {
+            return true, nil
+        }
+    }
+
+    return false, nil
+}
+
+func (ms *MemoryStorage) GetTransactionsByStatus(ctx context.Context, status string) ([]*models.Transaction, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var txns []*models.Transaction
+    for _, txn := range ms.transactions {
+        if txn.Status == status {
+            t := *txn
+            txns = append(txns, &t)
+        }
+    }
+
+    return txns, nil
+}
+
+func (ms *MemoryStorage) UpdateTransactionStatus(ctx context.Context, txnID, status string) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, txn := range ms.transactions {
+        if txn.TransactionID == txnID {
+            txn.Status = status
+            return nil
+        }
+    }
+
+    return storage.NewStorageError("UpdateTransactionStatus", fmt.Errorf("transaction not found"))
+}
+
+func (ms *MemoryStorage) RefundTransaction(ctx context.Context, txnID, refundRef string, refundedAt time.Time) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, txn := range ms.transactions {
+        if txn.TransactionID == txnID {
+            txn.Status = "refunded"
+            txn.RefundRef = refundRef
+            txn.RefundedAt = refundedAt
+            return nil
+        }
+    }
+
+    return storage.NewStorageError("RefundTransaction", fmt.Errorf("transaction not found"))
+}
+
+func (ms *MemoryStorage) SetTransactionScreenshot(ctx context.Context, txnID, fileID, fileType string) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, txn := range ms.transactions {
+        if txn.TransactionID == txnID {
+            txn.ScreenshotFileID = fileID
+            txn.ScreenshotType = fileType
+            return nil
+        }
+    }
+
+    return storage.NewStorageError("SetTransactionScreenshot", fmt.Errorf("transaction not found"))
+}
+
+func (ms *MemoryStorage) SaveLotteryEntry(ctx context.Context, entry *models.LotteryEntry) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    e := *entry
+    ms.entries = append(ms.entries, &e)
+    return nil
+}
+
+func (ms *MemoryStorage) GetEntriesByDate(ctx context.Context, date time.Time) ([]*models.LotteryEntry, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var entries []*models.LotteryEntry
+    for _, entry := range ms.entries {
+        if sameDay(entry.EntryDate, date) {
+            e := *entry
+            entries = append(entries, &e)
+        }
+    }
+
+    return entries, nil
+}
+
+func (ms *MemoryStorage) GetEntriesByTransaction(ctx context.Context, txnID string) ([]*models.LotteryEntry, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var entries []*models.LotteryEntry
+    for _, entry := range ms.entries {
+        if entry.TransactionID == txnID {
+            e := *entry
+            entries = append(entries, &e)
+        }
+    }
+
+    return entries, nil
+}
+
+func (ms *MemoryStorage) GetEntriesByDraw(ctx context.Context, drawID string) ([]*models.LotteryEntry, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var entries []*models.LotteryEntry
+    for _, entry := range ms.entries {
+        if entry.DrawID == drawID {
+            e := *entry
+            entries = append(entries, &e)
+        }
+    }
+
+    return entries, nil
+}
+
+func (ms *MemoryStorage) UpdateEntryDraw(ctx context.Context, entryIDs []string, drawID string) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    ids := make(map[string]bool, len(entryIDs))
+    for _, id := range entryIDs {
+        ids[id] = true
+    }
+
+    for _, entry := range ms.entries {
+        if ids[entry.EntryID] {
+            entry.DrawID = drawID
+        }
+    }
+
+    return nil
+}
+
+func (ms *MemoryStorage) UpdateEntryStatus(ctx context.Context, entryIDs []string, status string) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    ids := make(map[string]bool, len(entryIDs))
+    for _, id := range entryIDs {
+        ids[id] = true
+    }
+
+    for _, entry := range ms.entries {
+        if ids[entry.EntryID] {
+            entry.Status = status
+        }
+    }
+
+    return nil
+}
+
+func (ms *MemoryStorage) SaveWinner(ctx context.Context, winner *models.Winner) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    w := *winner
+    for i, existing := range ms.winners {
+        if existing.WinnerID == winner.WinnerID {
+            ms.winners[i] = &w
+            return nil
+        }
+    }
+
+    ms.winners = append(ms.winners, &w)
+    return nil
+}
+
+func (ms *MemoryStorage) GetWinnersByDate(ctx context.Context, date time.Time) ([]*models.Winner, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var winners []*models.Winner
+    for _, winner := range ms.winners {
+        if sameDay(winner.Date, date) {
+            w := *winner
+            winners = append(winners, &w)
+        }
+    }
+
+    return winners, nil
+}
+
+func (ms *MemoryStorage) GetWinnersByUser(ctx context.Context, userID int64) ([]*models.Winner, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var winners []*models.Winner
+    for _, winner := range ms.winners {
+        if winner.UserID == userID {
+            w := *winner
+            winners = append(winners, &w)
+        }
+    }
+
+    return winners, nil
+}
+
+func (ms *MemoryStorage) GetWinner(ctx context.Context, winnerID string) (*models.Winner, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    for _, winner := range ms.winners {
+        if winner.WinnerID == winnerID {
+            w := *winner
+            return &w, nil
+        }
+    }
+
+    return nil, storage.NewStorageError("GetWinner", fmt.Errorf("winner not found"))
+}
+
+func (ms *MemoryStorage) GetWinnersByPaymentStatus(ctx context.Context, status string) ([]*models.Winner, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var winners []*models.Winner
+    for _, winner := range ms.winners {
+        if winner.PaymentStatus == status {
+            w := *winner
+            winners = append(winners, &w)
+        }
+    }
+
+    return winners, nil
+}
+
+func (ms *MemoryStorage) UpdateWinnerPaymentStatus(ctx context.Context, winnerID, status, paymentTxnID string) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, winner := range ms.winners {
+        if winner.WinnerID == winnerID {
+            winner.PaymentStatus = status
+            winner.PaymentTransactionID = paymentTxnID
+            return nil
+        }
+    }
+
+    return storage.NewStorageError("UpdateWinnerPaymentStatus", fmt.Errorf("winner not found"))
+}
+
+func copyDraw(draw *models.Draw) *models.Draw {
+    d := *draw
+    d.WinningNumbers = append([]int(nil), draw.WinningNumbers...)
+    return &d
+}
+
+func (ms *MemoryStorage) OpenDraw(ctx context.Context, draw *models.Draw) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, existing := range ms.draws {
+        if existing.DrawID == draw.DrawID {
+            return storage.NewStorageError("OpenDraw", storage.ErrDuplicateDraw)
+        }
+    }
+
+    d := copyDraw(draw)
+    d.Status = "open"
+    ms.draws = append(ms.draws, d)
+    return nil
+}
+
+func (ms *MemoryStorage) CloseDraw(ctx context.Context, drawID string, closedAt time.Time) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for _, draw := range ms.draws {
+        if draw.DrawID == drawID {
+            if draw.Status != "open" {
+                return storage.NewStorageError("CloseDraw", fmt.Errorf("draw is %s", draw.Status))
+            }
+            draw.Status = "closed"
+            draw.ClosedAt = closedAt
+            return nil
+        }
+    }
+
+    return storage.NewStorageError("CloseDraw", fmt.Errorf("draw not found"))
+}
+
+func (ms *MemoryStorage) SaveDraw(ctx context.Context, draw *models.Draw) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    d := copyDraw(draw)
+    for i, existing := range ms.draws {
+        if existing.DrawID == draw.DrawID {
+            ms.draws[i] = d
+            return nil
+        }
+    }
+
+    ms.draws = append(ms.draws, d)
+    return nil
+}
+
+func (ms *MemoryStorage) GetDraw(ctx context.Context, drawID string) (*models.Draw, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    for _, draw := range ms.draws {
+        if draw.DrawID == drawID {
+            return copyDraw(draw), nil
+        }
+    }
+
+    return nil, storage.NewStorageError("GetDraw", fmt.Errorf("draw not found"))
+}
+
+func (ms *MemoryStorage) ListDraws(ctx context.Context, filter storage.DrawFilter) ([]*models.Draw, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var draws []*models.Draw
+    for _, draw := range ms.draws {
+        if filter.Status != "" && draw.Status != filter.Status {
+            continue
+        }
+        if filter.TicketAmount != 0 && draw.TicketAmount != filter.TicketAmount {
+            continue
+        }
+        draws = append(draws, copyDraw(draw))
+    }
+
+    sort.SliceStable(draws, func(i, j int) bool {
+        return draws[i].OpenedAt.Before(draws[j].OpenedAt)
+    })
+    return draws, nil
+}
+
+func (ms *MemoryStorage) SaveAdminAction(ctx context.Context, action *models.AdminAction) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    a := *action
+    ms.adminActions = append(ms.adminActions, &a)
+    return nil
+}
+
+// GetAdminActions returns matching admin actions, newest first
+func (ms *MemoryStorage) GetAdminActions(ctx context.Context, filter storage.AdminActionFilter) ([]*models.AdminAction, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    var actions []*models.AdminAction
+    for i := len(ms.adminActions) - 1; i >= 0; i-- {
+        action := ms.adminActions[i]
+        if (filter.AdminID == 0 || action.AdminID == filter.AdminID) &&
+            (filter.FromDate.IsZero() || !action.Timestamp.Before(filter.FromDate)) &&
+            (filter.ToDate.IsZero() || !action.Timestamp.After(filter.ToDate)) {
+            a := *action
+            actions = append(actions, &a)
+        }
+    }
+
+    if filter.Offset >= len(actions) {
+        return nil, nil
+    }
+    actions = actions[filter.Offset:]
+    if filter.Limit > 0 && len(actions) > filter.Limit {
+        actions = actions[:filter.Limit]
+    }
+
+    return actions, nil
+}
+
+func (ms *MemoryStorage) SaveUserState(ctx context.Context, state *models.UserState) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    s := *state
+    for i, existing := range ms.states {
+        if existing.UserID == state.UserID {
+            ms.states[i] = &s
+            return nil
+        }
+    }
+
+    ms.states = append(ms.states, &s)
+    return nil
+}
+
+func (ms *MemoryStorage) GetUserState(ctx context.Context, userID int64) (*models.UserState, error) {
+    ms.mutex.RLock()
+    defer ms.mutex.RUnlock()
+
+    for _, state := range ms.states {
+        if state.UserID == userID {
+            s := *state
+            return &s, nil
+        }
+    }
+
+    // Return new state if not found
+    return &models.UserState{
+        UserID:          userID,
+        CurrentState:    "",
+        InvalidAttempts: 0,
+        LastUpdated:     time.Now(),
+    }, nil
+}
+
+func (ms *MemoryStorage) DeleteUserState(ctx context.Context, userID int64) error {
+    ms.mutex.Lock()
+    defer ms.mutex.Unlock()
+
+    for i, state := range ms.states {
+        if state.UserID == userID {
+            ms.states = append(ms.states[:i], ms.states[i+1:]...)
+            break
+        }
+    }
+
+    return nil
+}
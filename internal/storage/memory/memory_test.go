@@ -0,0 +1,14 @@
+package memory
+
+import (
+    "testing"
+
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+    "github.com/gsshankar104/telegram-bot/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+    storagetest.Run(t, func(t *testing.T) storage.Storage {
+        return NewMemoryStorage()
+    })
+}
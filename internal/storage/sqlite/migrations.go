@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+)
+
+// migrations are applied in order and recorded in schema_migrations.
+// Never edit a released migration; append a new one instead.
+var migrations = []string{
+    // 1: initial schema
+    `CREATE TABLE users (
+        user_id     INTEGER PRIMARY KEY,
+        username    TEXT NOT NULL DEFAULT '',
+        first_name  TEXT NOT NULL DEFAULT '',
+        last_name   TEXT NOT NULL DEFAULT '',
+        joined_date INTEGER NOT NULL,
+        status      TEXT NOT NULL DEFAULT ''
+    );
+    CREATE INDEX idx_users_joined_date ON users (joined_date);
+
+    CREATE TABLE transactions (
+        transaction_id TEXT PRIMARY KEY,
+        user_id        INTEGER NOT NULL,
+        amount         REAL NOT NULL,
+        date           INTEGER NOT NULL,
+        time           INTEGER NOT NULL,
+        status         TEXT NOT NULL DEFAULT ''
+    );
+    CREATE INDEX idx_transactions_user_id ON transactions (user_id);
+    CREATE INDEX idx_transactions_date ON transactions (date);
+
+    CREATE TABLE lottery_entries (
+        entry_id       TEXT PRIMARY KEY,
+        user_id        INTEGER NOT NULL,
+        ticket_amount  REAL NOT NULL,
+        transaction_id TEXT NOT NULL DEFAULT '',
+        unique_code    TEXT NOT NULL DEFAULT '',
+        lucky_number   INTEGER NOT NULL,
+        entry_date     INTEGER NOT NULL,
+        entry_time     INTEGER NOT NULL,
+        status         TEXT NOT NULL DEFAULT ''
+    );
+    CREATE INDEX idx_entries_user_id ON lottery_entries (user_id);
+    CREATE INDEX idx_entries_transaction_id ON lottery_entries (transaction_id);
+    CREATE INDEX idx_entries_entry_date ON lottery_entries (entry_date);
+
+    CREATE TABLE winners (
+        winner_id              TEXT PRIMARY KEY,
+        user_id                INTEGER NOT NULL,
+        entry_id               TEXT NOT NULL,
+        winning_amount         REAL NOT NULL,
+        date                   INTEGER NOT NULL,
+        time                   INTEGER NOT NULL,
+        payment_status         TEXT NOT NULL DEFAULT '',
+        payment_transaction_id TEXT NOT NULL DEFAULT ''
+    );
+    CREATE INDEX idx_winners_user_id ON winners (user_id);
+    CREATE INDEX idx_winners_date ON winners (date);
+
+    CREATE TABLE user_states (
+        user_id          INTEGER PRIMARY KEY,
+        current_state    TEXT NOT NULL DEFAULT '',
+        selected_amount  REAL NOT NULL DEFAULT 0,
+        transaction_id   TEXT NOT NULL DEFAULT '',
+        unique_code      TEXT NOT NULL DEFAULT '',
+        winner_count     INTEGER NOT NULL DEFAULT 0,
+        invalid_attempts INTEGER NOT NULL DEFAULT 0,
+        last_updated     INTEGER NOT NULL
+    );
+
+    CREATE TABLE admin_actions (
+        action_id   TEXT PRIMARY KEY,
+        admin_id    INTEGER NOT NULL,
+        action_type TEXT NOT NULL,
+        details     TEXT NOT NULL DEFAULT '',
+        timestamp   INTEGER NOT NULL
+    );
+    CREATE INDEX idx_admin_actions_admin_id ON admin_actions (admin_id);
+    CREATE INDEX idx_admin_actions_timestamp ON admin_actions (timestamp);`,
+
+    // 2: payment screenshots
+    `ALTER TABLE transactions ADD COLUMN screenshot_file_id TEXT NOT NULL DEFAULT '';
+    ALTER TABLE transactions ADD COLUMN screenshot_type TEXT NOT NULL DEFAULT '';
+    ALTER TABLE user_states ADD COLUMN screenshot_file_id TEXT NOT NULL DEFAULT '';
+    ALTER TABLE user_states ADD COLUMN screenshot_type TEXT NOT NULL DEFAULT '';`,
+
+    // 3: payment gateway orders
+    `ALTER TABLE transactions ADD COLUMN provider TEXT NOT NULL DEFAULT '';
+    ALTER TABLE transactions ADD COLUMN provider_ref TEXT NOT NULL DEFAULT '';`,
+
+    // 4: payment note for statement matching
+    `ALTER TABLE transactions ADD COLUMN unique_code TEXT NOT NULL DEFAULT '';
+    CREATE INDEX idx_transactions_unique_code ON transactions (unique_code);`,
+
+    // 5: refunds
+    `ALTER TABLE transactions ADD COLUMN refund_ref TEXT NOT NULL DEFAULT '';
+    ALTER TABLE transactions ADD COLUMN refunded_at INTEGER NOT NULL DEFAULT 0;`,
+
+    // 6: winner payouts
+    `ALTER TABLE user_states ADD COLUMN winner_id TEXT NOT NULL DEFAULT '';
+    CREATE INDEX idx_winners_payment_status ON winners (payment_status);`,
+
+    // 7: draw records
+    `CREATE TABLE draws (
+        draw_id                 TEXT PRIMARY KEY,
+        date                    INTEGER NOT NULL,
+        ticket_amount           REAL NOT NULL,
+        method                  TEXT NOT NULL DEFAULT '',
+        entry_count             INTEGER NOT NULL DEFAULT 0,
+        winning_numbers         TEXT NOT NULL DEFAULT '',
+        announcement_chat_id    INTEGER NOT NULL DEFAULT 0,
+        announcement_message_id INTEGER NOT NULL DEFAULT 0,
+        created_at              INTEGER NOT NULL
+    );
+    CREATE INDEX idx_draws_date ON draws (date);
+    ALTER TABLE winners ADD COLUMN draw_id TEXT NOT NULL DEFAULT '';`,
+
+    // 8: draw lifecycle, entries linked to their draw. Existing draw
+    // records are all completed draws.
+    `ALTER TABLE draws RENAME COLUMN created_at TO opened_at;
+    ALTER TABLE draws ADD COLUMN status TEXT NOT NULL DEFAULT 'drawn';
+    ALTER TABLE draws ADD COLUMN winner_count INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE draws ADD COLUMN seed TEXT NOT NULL DEFAULT '';
+    ALTER TABLE draws ADD COLUMN closed_at INTEGER NOT NULL DEFAULT 0;
+    ALTER TABLE draws ADD COLUMN drawn_at INTEGER NOT NULL DEFAULT 0;
+    UPDATE draws SET drawn_at = opened_at;
+    CREATE INDEX idx_draws_status ON draws (status, ticket_amount);
+    ALTER TABLE lottery_entries ADD COLUMN draw_id TEXT NOT NULL DEFAULT '';
+    CREATE INDEX idx_lottery_entries_draw ON lottery_entries (draw_id);`,
+
+    // 9: commit-reveal seed hash
+    `ALTER TABLE draws ADD COLUMN seed_hash TEXT NOT NULL DEFAULT '';`,
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+    _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version    INTEGER PRIMARY KEY,
+        applied_at INTEGER NOT NULL
+    )`)
+    if err != nil {
+        return fmt.Errorf("failed to create schema_migrations: %v", err)
+    }
+
+    var current int
+    if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+        return fmt.Errorf("failed to read schema version: %v", err)
+    }
+
+    for i := current; i < len(migrations); i++ {
+        version := i + 1
+
+        tx, err := db.BeginTx(ctx, nil)
+        if err != nil {
+            return fmt.Errorf("failed to begin migration %d: %v", version, err)
+        }
+
+        if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+            tx.Rollback()
+            return fmt.Errorf("failed to apply migration %d: %v", version, err)
+        }
+
+        if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+            version, time.Now().UnixNano()); err != nil {
+            tx.Rollback()
+            return fmt.Errorf("failed to record migration %d: %v", version, err)
+        }
+
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit migration %d: %v", version, err)
+        }
+    }
+
+    return nil
+}
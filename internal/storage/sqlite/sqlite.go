@@ -0,0 +1,819 @@
+package sqlite
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+
+    _ "modernc.org/sqlite"
+)
+
+// SQLiteStorage stores all bot data in a local SQLite database.
+// Timestamps are stored as Unix nanoseconds so range queries stay index friendly.
+type SQLiteStorage struct {
+    db *sql.DB
+}
+
+func NewSQLiteStorage(ctx context.Context, path string) (*SQLiteStorage, error) {
+    dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+    db, err := sql.Open("sqlite", dsn)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open SQLite database: %v", err)
+    }
+
+    // SQLite allows a single writer; sharing one connection avoids SQLITE_BUSY
+    db.SetMaxOpenConns(1)
+
+    if err := migrate(ctx, db); err != nil {
+        db.Close()
+        return nil, err
+    }
+
+    return &SQLiteStorage{db: db}, nil
+}
+
+// Close releases the underlying database handle
+func (s *SQLiteStorage) Close() error {
+    return s.db.Close()
+}
+
+func toUnix(t time.Time) int64 {
+    if t.IsZero() {
+        return 0
+    }
+    return t.UnixNano()
+}
+
+func fromUnix(n int64) time.Time {
+    if n == 0 {
+        return time.Time{}
+    }
+    return time.Unix(0, n)
+}
+
+func dayRange(date time.Time) (int64, int64) {
+    startDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+    endDate := startDate.Add(24 * time.Hour)
+    return startDate.UnixNano(), endDate.UnixNano()
+}
+
+type scanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func (s *SQLiteStorage) SaveUser(ctx context.Context, user *models.User) error {
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO users (user_id, username, first_name, last_name, joined_date, status)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id) DO UPDATE SET
+            username = excluded.username,
+            first_name = excluded.first_name,
+            last_name = excluded.last_name,
+            joined_date = excluded.joined_date,
+            status = excluded.status`,
+        user.UserID, user.Username, user.FirstName, user.LastName, toUnix(user.JoinedDate), user.Status,
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveUser", err)
+    }
+    return nil
+}
+
+const userColumns = `user_id, username, first_name, last_name, joined_date, status`
+
+func scanUser(row scanner) (*models.User, error) {
+    var user models.User
+    var joined int64
+    if err := row.Scan(&user.UserID, &user.Username, &user.FirstName, &user.LastName, &joined, &user.Status); err != nil {
+        return nil, err
+    }
+    user.JoinedDate = fromUnix(joined)
+    return &user, nil
+}
+
+func (s *SQLiteStorage) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+    row := s.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE user_id = ?`, userID)
+    user, err := scanUser(row)
+    if err == sql.ErrNoRows {
+        return nil, storage.NewStorageError("GetUser", fmt.Errorf("user not found"))
+    }
+    if err != nil {
+        return nil, storage.NewStorageError("GetUser", err)
+    }
+    return user, nil
+}
+
+func (s *SQLiteStorage) GetAllUsers(ctx context.Context, fromDate, toDate time.Time) ([]*models.User, error) {
+    query := `SELECT ` + userColumns + ` FROM users WHERE 1 = 1`
+    var args []interface{}
+    if !fromDate.IsZero() {
+        query += ` AND joined_date >= ?`
+        args = append(args, fromDate.UnixNano())
+    }
+    if !toDate.IsZero() {
+        query += ` AND joined_date <= ?`
+        args = append(args, toDate.UnixNano())
+    }
+    query += ` ORDER BY joined_date`
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, storage.NewStorageError("GetAllUsers", err)
+    }
+    defer rows.Close()
+
+    var users []*models.User
+    for rows.Next() {
+        user, err := scanUser(rows)
+        if err != nil {
+            return nil, storage.NewStorageError("GetAllUsers", err)
+        }
+        users = append(users, user)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, storage.NewStorageError("GetAllUsers", err)
+    }
+
+    return users, nil
+}
+
+func (s *SQLiteStorage) SaveTransaction(ctx context.Context, txn *models.Transaction) error {
+    res, err := s.db.ExecContext(ctx, `
+        INSERT INTO transactions (transaction_id, user_id, amount, date, time, status,
+            unique_code, screenshot_file_id, screenshot_type, provider, provider_ref)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (transaction_id) DO NOTHING`,
+        txn.TransactionID, txn.UserID, txn.Amount, toUnix(txn.Date), toUnix(txn.Time), txn.Status,
+        txn.UniqueCode, txn.ScreenshotFileID, txn.ScreenshotType, txn.Provider, txn.ProviderRef,
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveTransaction", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("SaveTransaction", err)
+    }
+    if n == 0 {
+        return storage.NewStorageError("SaveTransaction", storage.ErrDuplicateTransaction)
+    }
+    return nil
+}
+
+const transactionColumns = `transaction_id, user_id, amount, date, time, status,
+    unique_code, screenshot_file_id, screenshot_type, provider, provider_ref, refund_ref, refunded_at`
+
+func scanTransaction(row scanner) (*models.Transaction, error) {
+    var txn models.Transaction
+    var date, tm, refunded int64
+    err := row.Scan(&txn.TransactionID, &txn.UserID, &txn.Amount, &date, &tm, &txn.Status,
+        &txn.UniqueCode, &txn.ScreenshotFileID, &txn.ScreenshotType, &txn.Provider, &txn.ProviderRef,
+        &txn.RefundRef, &refunded)
+    if err != nil {
+        return nil, err
+    }
+    txn.Date = fromUnix(date)
+    txn.Time = fromUnix(tm)
+    txn.RefundedAt = fromUnix(refunded)
+    return &txn, nil
+}
+
+func (s *SQLiteStorage) GetTransaction(ctx context.Context, txnID string) (*models.Transaction, error) {
+    row := s.db.QueryRowContext(ctx, `SELECT `+transactionColumns+` FROM transactions WHERE transaction_id = ?`, txnID)
+    txn, err := scanTransaction(row)
+    if err == sql.ErrNoRows {
+        return nil, storage.NewStorageError("GetTransaction", fmt.Errorf("transaction not found"))
+    }
+    if err != nil {
+        return nil, storage.NewStorageError("GetTransaction", err)
+    }
+    return txn, nil
+}
+
+func (s *SQLiteStorage) GetTransactionsByDate(ctx context.Context, date time.Time) ([]*models.Transaction, error) {
+    start, end := dayRange(date)
+    return s.queryTransactions(ctx, "GetTransactionsByDate",
+        `SELECT `+transactionColumns+` FROM transactions WHERE date >= ? AND date < ? ORDER BY time`,
+        start, end,
+    )
+}
+
+func (s *SQLiteStorage) IsTransactionUsed(ctx context.Context, txnID string) (bool, error) {
+    var exists bool
+    err := s.db.QueryRowContext(ctx,
+        `SELECT EXISTS (SELECT 1 FROM transactions WHERE transaction_id = ?)`, txnID,
+    ).Scan(&exists)
+    if err != nil {
+        return false, storage.NewStorageError("IsTransactionUsed", err)
+    }
+    return exists, nil
+}
+
+func (s *SQLiteStorage) queryTransactions(ctx context.Context, operation, query string, args ...interface{}) ([]*models.Transaction, error) {
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, storage.NewStorageError(operation, err)
+    }
+    defer rows.Close()
+
+    var txns []*models.Transaction
+    for rows.Next() {
+        txn, err := scanTransaction(rows)
+        if err != nil {
+            return nil, storage.NewStorageError(operation, err)
+        }
+        txns = append(txns, txn)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, storage.NewStorageError(operation, err)
+    }
+
+    return txns, nil
+}
+
+func (s *SQLiteStorage) GetTransactionsByStatus(ctx context.Context, status string) ([]*models.Transaction, error) {
+    return s.queryTransactions(ctx, "GetTransactionsByStatus",
+        `SELECT `+transactionColumns+` FROM transactions WHERE status = ? ORDER BY time`,
+        status,
+    )
+}
+
+func (s *SQLiteStorage) UpdateTransactionStatus(ctx context.Context, txnID, status string) error {
+    res, err := s.db.ExecContext(ctx, `UPDATE transactions SET status = ? WHERE transaction_id = ?`, status, txnID)
+    if err != nil {
+        return storage.NewStorageError("UpdateTransactionStatus", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("UpdateTransactionStatus", err)
+    }
+    if n == 0 {
+        return storage.NewStorageError("UpdateTransactionStatus", fmt.Errorf("transaction not found"))
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) RefundTransaction(ctx context.Context, txnID, refundRef string, refundedAt time.Time) error {
+    res, err := s.db.ExecContext(ctx,
+        `UPDATE transactions SET status = 'refunded', refund_ref = ?, refunded_at = ? WHERE transaction_id = ?`,
+        refundRef, toUnix(refundedAt), txnID,
+    )
+    if err != nil {
+        return storage.NewStorageError("RefundTransaction", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("RefundTransaction", err)
+    }
+    if n == 0 {
+        return storage.NewStorageError("RefundTransaction", fmt.Errorf("transaction not found"))
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) SetTransactionScreenshot(ctx context.Context, txnID, fileID, fileType string) error {
+    res, err := s.db.ExecContext(ctx,
+        `UPDATE transactions SET screenshot_file_id = ?, screenshot_type = ? WHERE transaction_id = ?`,
+        fileID, fileType, txnID,
+    )
+    if err != nil {
+        return storage.NewStorageError("SetTransactionScreenshot", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("SetTransactionScreenshot", err)
+    }
+    if n == 0 {
+        return storage.NewStorageError("SetTransactionScreenshot", fmt.Errorf("transaction not found"))
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) SaveLotteryEntry(ctx context.Context, entry *models.LotteryEntry) error {
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO lottery_entries (entry_id, user_id, ticket_amount, transaction_id, unique_code,
+            lucky_number, entry_date, entry_time, status, draw_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        entry.EntryID, entry.UserID, entry.TicketAmount, entry.TransactionID, entry.UniqueCode,
+        entry.LuckyNumber, toUnix(entry.EntryDate), toUnix(entry.EntryTime), entry.Status, entry.DrawID,
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveLotteryEntry", err)
+    }
+    return nil
+}
+
+const entryColumns = `entry_id, user_id, ticket_amount, transaction_id, unique_code, lucky_number, entry_date, entry_time, status, draw_id`
+
+func scanEntry(row scanner) (*models.LotteryEntry, error) {
+    var entry models.LotteryEntry
+    var date, tm int64
+    err := row.Scan(&entry.EntryID, &entry.UserID, &entry.TicketAmount, &entry.TransactionID, &entry.UniqueCode,
+        &entry.LuckyNumber, &date, &tm, &entry.Status, &entry.DrawID)
+    if err != nil {
+        return nil, err
+    }
+    entry.EntryDate = fromUnix(date)
+    entry.EntryTime = fromUnix(tm)
+    return &entry, nil
+}
+
+func (s *SQLiteStorage) queryEntries(ctx context.Context, operation, query string, args ...interface{}) ([]*models.LotteryEntry, error) {
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, storage.NewStorageError(operation, err)
+    }
+    defer rows.Close()
+
+    var entries []*models.LotteryEntry
+    for rows.Next() {
+        entry, err := scanEntry(rows)
+        if err != nil {
+            return nil, storage.NewStorageError(operation, err)
+        }
+        entries = append(entries, entry)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, storage.NewStorageError(operation, err)
+    }
+
+    return entries, nil
+}
+
+func (s *SQLiteStorage) GetEntriesByDate(ctx context.Context, date time.Time) ([]*models.LotteryEntry, error) {
+    start, end := dayRange(date)
+    return s.queryEntries(ctx, "GetEntriesByDate",
+        `SELECT `+entryColumns+` FROM lottery_entries WHERE entry_date >= ? AND entry_date < ? ORDER BY entry_time`,
+        start, end,
+    )
+}
+
+func (s *SQLiteStorage) GetEntriesByTransaction(ctx context.Context, txnID string) ([]*models.LotteryEntry, error) {
+    return s.queryEntries(ctx, "GetEntriesByTransaction",
+        `SELECT `+entryColumns+` FROM lottery_entries WHERE transaction_id = ? ORDER BY entry_time`,
+        txnID,
+    )
+}
+
+func (s *SQLiteStorage) GetEntriesByDraw(ctx context.Context, drawID string) ([]*models.LotteryEntry, error) {
+    return s.queryEntries(ctx, "GetEntriesByDraw",
+        `SELECT `+entryColumns+` FROM lottery_entries WHERE draw_id = ? ORDER BY entry_time`,
+        drawID,
+    )
+}
+
+func (s *SQLiteStorage) UpdateEntryDraw(ctx context.Context, entryIDs []string, drawID string) error {
+    if len(entryIDs) == 0 {
+        return nil
+    }
+
+    placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(entryIDs)), ", ")
+    args := []interface{}{drawID}
+    for _, id := range entryIDs {
+        args = append(args, id)
+    }
+
+    _, err := s.db.ExecContext(ctx,
+        `UPDATE lottery_entries SET draw_id = ? WHERE entry_id IN (`+placeholders+`)`, args...,
+    )
+    if err != nil {
+        return storage.NewStorageError("UpdateEntryDraw", err)
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) UpdateEntryStatus(ctx context.Context, entryIDs []string, status string) error {
+    if len(entryIDs) == 0 {
+        return nil
+    }
+
+    placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(entryIDs)), ", ")
+    args := []interface{}{status}
+    for _, id := range entryIDs {
+        args = append(args, id)
+    }
+
+    _, err := s.db.ExecContext(ctx,
+        `UPDATE lottery_entries SET status = ? WHERE entry_id IN (`+placeholders+`)`, args...,
+    )
+    if err != nil {
+        return storage.NewStorageError("UpdateEntryStatus", err)
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) SaveWinner(ctx context.Context, winner *models.Winner) error {
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO winners (winner_id, user_id, entry_id, winning_amount, date, time,
+            payment_status, payment_transaction_id, draw_id)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (winner_id) DO UPDATE SET
+            user_id = excluded.user_id,
+            entry_id = excluded.entry_id,
+            winning_amount = excluded.winning_amount,
+            date = excluded.date,
+            time = excluded.time,
+            payment_status = excluded.payment_status,
+            payment_transaction_id = excluded.payment_transaction_id,
+            draw_id = excluded.draw_id`,
+        winner.WinnerID, winner.UserID, winner.EntryID, winner.WinningAmount, toUnix(winner.Date),
+        toUnix(winner.Time), winner.PaymentStatus, winner.PaymentTransactionID, winner.DrawID,
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveWinner", err)
+    }
+    return nil
+}
+
+const winnerColumns = `winner_id, user_id, entry_id, winning_amount, date, time, payment_status, payment_transaction_id,
+    draw_id`
+
+func scanWinner(row scanner) (*models.Winner, error) {
+    var winner models.Winner
+    var date, tm int64
+    err := row.Scan(&winner.WinnerID, &winner.UserID, &winner.EntryID, &winner.WinningAmount, &date, &tm,
+        &winner.PaymentStatus, &winner.PaymentTransactionID, &winner.DrawID)
+    if err != nil {
+        return nil, err
+    }
+    winner.Date = fromUnix(date)
+    winner.Time = fromUnix(tm)
+    return &winner, nil
+}
+
+func (s *SQLiteStorage) queryWinners(ctx context.Context, operation, query string, args ...interface{}) ([]*models.Winner, error) {
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, storage.NewStorageError(operation, err)
+    }
+    defer rows.Close()
+
+    var winners []*models.Winner
+    for rows.Next() {
+        winner, err := scanWinner(rows)
+        if err != nil {
+            return nil, storage.NewStorageError(operation, err)
+        }
+        winners = append(winners, winner)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, storage.NewStorageError(operation, err)
+    }
+
+    return winners, nil
+}
+
+func (s *SQLiteStorage) GetWinnersByDate(ctx context.Context, date time.Time) ([]*models.Winner, error) {
+    start, end := dayRange(date)
+    return s.queryWinners(ctx, "GetWinnersByDate",
+        `SELECT `+winnerColumns+` FROM winners WHERE date >= ? AND date < ? ORDER BY time`,
+        start, end,
+    )
+}
+
+func (s *SQLiteStorage) GetWinnersByUser(ctx context.Context, userID int64) ([]*models.Winner, error) {
+    return s.queryWinners(ctx, "GetWinnersByUser",
+        `SELECT `+winnerColumns+` FROM winners WHERE user_id = ? ORDER BY time`,
+        userID,
+    )
+}
+
+func (s *SQLiteStorage) GetWinner(ctx context.Context, winnerID string) (*models.Winner, error) {
+    row := s.db.QueryRowContext(ctx, `SELECT `+winnerColumns+` FROM winners WHERE winner_id = ?`, winnerID)
+    winner, err := scanWinner(row)
+    if err == sql.ErrNoRows {
+        return nil, storage.NewStorageError("GetWinner", fmt.Errorf("winner not found"))
+    }
+    if err != nil {
+        return nil, storage.NewStorageError("GetWinner", err)
+    }
+    return winner, nil
+}
+
+func (s *SQLiteStorage) GetWinnersByPaymentStatus(ctx context.Context, status string) ([]*models.Winner, error) {
+    return s.queryWinners(ctx, "GetWinnersByPaymentStatus",
+        `SELECT `+winnerColumns+` FROM winners WHERE payment_status = ? ORDER BY time`,
+        status,
+    )
+}
+
+func (s *SQLiteStorage) UpdateWinnerPaymentStatus(ctx context.Context, winnerID, status, paymentTxnID string) error {
+    res, err := s.db.ExecContext(ctx,
+        `UPDATE winners SET payment_status = ?, payment_transaction_id = ? WHERE winner_id = ?`,
+        status, paymentTxnID, winnerID,
+    )
+    if err != nil {
+        return storage.NewStorageError("UpdateWinnerPaymentStatus", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("UpdateWinnerPaymentStatus", err)
+    }
+    if n == 0 {
+        return storage.NewStorageError("UpdateWinnerPaymentStatus", fmt.Errorf("winner not found"))
+    }
+    return nil
+}
+
+const drawColumns = `draw_id, ticket_amount, status, method, winner_count, seed, seed_hash, date,
+    opened_at, closed_at, drawn_at, entry_count, winning_numbers, announcement_chat_id, announcement_message_id`
+
+func drawArgs(draw *models.Draw, status string) []interface{} {
+    return []interface{}{
+        draw.DrawID, draw.TicketAmount, status, draw.Method, draw.WinnerCount, draw.Seed, draw.SeedHash, toUnix(draw.Date),
+        toUnix(draw.OpenedAt), toUnix(draw.ClosedAt), toUnix(draw.DrawnAt), draw.EntryCount,
+        joinInts(draw.WinningNumbers), draw.AnnouncementChatID, draw.AnnouncementMessageID,
+    }
+}
+
+func (s *SQLiteStorage) OpenDraw(ctx context.Context, draw *models.Draw) error {
+    res, err := s.db.ExecContext(ctx, `
+        INSERT INTO draws (`+drawColumns+`)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (draw_id) DO NOTHING`,
+        drawArgs(draw, "open")...,
+    )
+    if err != nil {
+        return storage.NewStorageError("OpenDraw", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("OpenDraw", err)
+    }
+    if n == 0 {
+        return storage.NewStorageError("OpenDraw", storage.ErrDuplicateDraw)
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) CloseDraw(ctx context.Context, drawID string, closedAt time.Time) error {
+    res, err := s.db.ExecContext(ctx,
+        `UPDATE draws SET status = 'closed', closed_at = ? WHERE draw_id = ? AND status = 'open'`,
+        toUnix(closedAt), drawID,
+    )
+    if err != nil {
+        return storage.NewStorageError("CloseDraw", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return storage.NewStorageError("CloseDraw", err)
+    }
+    if n == 0 {
+        var status string
+        err := s.db.QueryRowContext(ctx, `SELECT status FROM draws WHERE draw_id = ?`, drawID).Scan(&status)
+        if err == sql.ErrNoRows {
+            return storage.NewStorageError("CloseDraw", fmt.Errorf("draw not found"))
+        }
+        if err != nil {
+            return storage.NewStorageError("CloseDraw", err)
+        }
+        return storage.NewStorageError("CloseDraw", fmt.Errorf("draw is %s", status))
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) SaveDraw(ctx context.Context, draw *models.Draw) error {
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO draws (`+drawColumns+`)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (draw_id) DO UPDATE SET
+            ticket_amount = excluded.ticket_amount,
+            status = excluded.status,
+            method = excluded.method,
+            winner_count = excluded.winner_count,
+            seed = excluded.seed,
+            seed_hash = excluded.seed_hash,
+            date = excluded.date,
+            opened_at = excluded.opened_at,
+            closed_at = excluded.closed_at,
+            drawn_at = excluded.drawn_at,
+            entry_count = excluded.entry_count,
+            winning_numbers = excluded.winning_numbers,
+            announcement_chat_id = excluded.announcement_chat_id,
+            announcement_message_id = excluded.announcement_message_id`,
+        drawArgs(draw, draw.Status)...,
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveDraw", err)
+    }
+    return nil
+}
+
+func scanDraw(row scanner) (*models.Draw, error) {
+    var draw models.Draw
+    var date, opened, closed, drawn int64
+    var numbers string
+    err := row.Scan(&draw.DrawID, &draw.TicketAmount, &draw.Status, &draw.Method, &draw.WinnerCount, &draw.Seed, &draw.SeedHash, &date,
+        &opened, &closed, &drawn, &draw.EntryCount, &numbers, &draw.AnnouncementChatID, &draw.AnnouncementMessageID)
+    if err != nil {
+        return nil, err
+    }
+
+    draw.Date = fromUnix(date)
+    draw.OpenedAt = fromUnix(opened)
+    draw.ClosedAt = fromUnix(closed)
+    draw.DrawnAt = fromUnix(drawn)
+    if draw.WinningNumbers, err = splitInts(numbers); err != nil {
+        return nil, err
+    }
+    return &draw, nil
+}
+
+func (s *SQLiteStorage) GetDraw(ctx context.Context, drawID string) (*models.Draw, error) {
+    draw, err := scanDraw(s.db.QueryRowContext(ctx, `SELECT `+drawColumns+` FROM draws WHERE draw_id = ?`, drawID))
+    if err == sql.ErrNoRows {
+        return nil, storage.NewStorageError("GetDraw", fmt.Errorf("draw not found"))
+    }
+    if err != nil {
+        return nil, storage.NewStorageError("GetDraw", err)
+    }
+    return draw, nil
+}
+
+// ListDraws returns matching draws, oldest first
+func (s *SQLiteStorage) ListDraws(ctx context.Context, filter storage.DrawFilter) ([]*models.Draw, error) {
+    query := `SELECT ` + drawColumns + ` FROM draws WHERE 1 = 1`
+    var args []interface{}
+    if filter.Status != "" {
+        query += ` AND status = ?`
+        args = append(args, filter.Status)
+    }
+    if filter.TicketAmount != 0 {
+        query += ` AND ticket_amount = ?`
+        args = append(args, filter.TicketAmount)
+    }
+    query += ` ORDER BY opened_at`
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, storage.NewStorageError("ListDraws", err)
+    }
+    defer rows.Close()
+
+    var draws []*models.Draw
+    for rows.Next() {
+        draw, err := scanDraw(rows)
+        if err != nil {
+            return nil, storage.NewStorageError("ListDraws", err)
+        }
+        draws = append(draws, draw)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, storage.NewStorageError("ListDraws", err)
+    }
+
+    return draws, nil
+}
+
+// joinInts and splitInts store small integer lists as "7,42"
+func joinInts(values []int) string {
+    parts := make([]string, len(values))
+    for i, v := range values {
+        parts[i] = strconv.Itoa(v)
+    }
+    return strings.Join(parts, ",")
+}
+
+func splitInts(s string) ([]int, error) {
+    if s == "" {
+        return nil, nil
+    }
+    var values []int
+    for _, part := range strings.Split(s, ",") {
+        v, err := strconv.Atoi(part)
+        if err != nil {
+            return nil, err
+        }
+        values = append(values, v)
+    }
+    return values, nil
+}
+
+func (s *SQLiteStorage) SaveAdminAction(ctx context.Context, action *models.AdminAction) error {
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO admin_actions (action_id, admin_id, action_type, details, timestamp)
+        VALUES (?, ?, ?, ?, ?)`,
+        action.ActionID, action.AdminID, action.ActionType, action.Details, toUnix(action.Timestamp),
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveAdminAction", err)
+    }
+    return nil
+}
+
+// GetAdminActions returns matching admin actions, newest first
+func (s *SQLiteStorage) GetAdminActions(ctx context.Context, filter storage.AdminActionFilter) ([]*models.AdminAction, error) {
+    query := `SELECT action_id, admin_id, action_type, details, timestamp FROM admin_actions WHERE 1 = 1`
+    var args []interface{}
+    if filter.AdminID != 0 {
+        query += ` AND admin_id = ?`
+        args = append(args, filter.AdminID)
+    }
+    if !filter.FromDate.IsZero() {
+        query += ` AND timestamp >= ?`
+        args = append(args, filter.FromDate.UnixNano())
+    }
+    if !filter.ToDate.IsZero() {
+        query += ` AND timestamp <= ?`
+        args = append(args, filter.ToDate.UnixNano())
+    }
+    query += ` ORDER BY timestamp DESC, rowid DESC LIMIT ? OFFSET ?`
+    limit := filter.Limit
+    if limit <= 0 {
+        limit = -1
+    }
+    args = append(args, limit, filter.Offset)
+
+    rows, err := s.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, storage.NewStorageError("GetAdminActions", err)
+    }
+    defer rows.Close()
+
+    var actions []*models.AdminAction
+    for rows.Next() {
+        var action models.AdminAction
+        var ts int64
+        if err := rows.Scan(&action.ActionID, &action.AdminID, &action.ActionType, &action.Details, &ts); err != nil {
+            return nil, storage.NewStorageError("GetAdminActions", err)
+        }
+        action.Timestamp = fromUnix(ts)
+        actions = append(actions, &action)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, storage.NewStorageError("GetAdminActions", err)
+    }
+
+    return actions, nil
+}
+
+func (s *SQLiteStorage) SaveUserState(ctx context.Context, state *models.UserState) error {
+    _, err := s.db.ExecContext(ctx, `
+        INSERT INTO user_states (user_id, current_state, selected_amount, transaction_id, unique_code,
+            winner_count, winner_id, screenshot_file_id, screenshot_type, invalid_attempts, last_updated)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id) DO UPDATE SET
+            current_state = excluded.current_state,
+            selected_amount = excluded.selected_amount,
+            transaction_id = excluded.transaction_id,
+            unique_code = excluded.unique_code,
+            winner_count = excluded.winner_count,
+            winner_id = excluded.winner_id,
+            screenshot_file_id = excluded.screenshot_file_id,
+            screenshot_type = excluded.screenshot_type,
+            invalid_attempts = excluded.invalid_attempts,
+            last_updated = excluded.last_updated`,
+        state.UserID, state.CurrentState, state.SelectedAmount, state.TransactionID, state.UniqueCode,
+        state.WinnerCount, state.WinnerID, state.ScreenshotFileID, state.ScreenshotType, state.InvalidAttempts, toUnix(state.LastUpdated),
+    )
+    if err != nil {
+        return storage.NewStorageError("SaveUserState", err)
+    }
+    return nil
+}
+
+func (s *SQLiteStorage) GetUserState(ctx context.Context, userID int64) (*models.UserState, error) {
+    var state models.UserState
+    var updated int64
+    err := s.db.QueryRowContext(ctx, `
+        SELECT user_id, current_state, selected_amount, transaction_id, unique_code,
+            winner_count, winner_id, screenshot_file_id, screenshot_type, invalid_attempts, last_updated
+        FROM user_states WHERE user_id = ?`, userID,
+    ).Scan(&state.UserID, &state.CurrentState, &state.SelectedAmount, &state.TransactionID, &state.UniqueCode,
+        &state.WinnerCount, &state.WinnerID, &state.ScreenshotFileID, &state.ScreenshotType, &state.InvalidAttempts, &updated)
+
+    if err == sql.ErrNoRows {
+        // Return new state if not found
+        return &models.UserState{
+            UserID:          userID,
+            CurrentState:    "",
+            InvalidAttempts: 0,
+            LastUpdated:     time.Now(),
+        }, nil
+    }
+    if err != nil {
+        return nil, storage.NewStorageError("GetUserState", err)
+    }
+
+    state.LastUpdated = fromUnix(updated)
+    return &state, nil
+}
+
+func (s *SQLiteStorage) DeleteUserState(ctx context.Context, userID int64) error {
+    if _, err := s.db.ExecContext(ctx, `DELETE FROM user_states WHERE user_id = ?`, userID); err != nil {
+        return storage.NewStorageError("DeleteUserState", err)
+    }
+    return nil
+}
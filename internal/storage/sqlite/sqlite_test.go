@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+    "context"
+    "path/filepath"
+    "testing"
+
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+    "github.com/gsshankar104/telegram-bot/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+    storagetest.Run(t, func(t *testing.T) storage.Storage {
+        s, err := NewSQLiteStorage(context.Background(), filepath.Join(t.TempDir(), "lottery.db"))
+        if err != nil {
+            t.Fatalf("NewSQLiteStorage: %v", err)
+        }
+        t.Cleanup(func() { s.Close() })
+        return s
+    })
+}
+
+func TestMigrationsAreIdempotent(t *testing.T) {
+    ctx := context.Background()
+    path := filepath.Join(t.TempDir(), "lottery.db")
+
+    for i := 0; i < 2; i++ {
+        s, err := NewSQLiteStorage(ctx, path)
+        if err != nil {
+            t.Fatalf("NewSQLiteStorage (open %d): %v", i+1, err)
+        }
+
+        var version int
+        if err := s.db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+            t.Fatalf("read schema version: %v", err)
+        }
+        if version != len(migrations) {
+            t.Errorf("schema version = %d, want %d", version, len(migrations))
+        }
+        s.Close()
+    }
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
     "context"
+    "errors"
     "time"
 
     "github.com/gsshankar104/telegram-bot/internal/models"
@@ -17,6 +18,33 @@ func (e *StorageError) Error() string {
     return e.Operation + ": " + e.Err.Error()
 }
 
+func (e *StorageError) Unwrap() error {
+    return e.Err
+}
+
+// ErrDuplicateTransaction is wrapped by SaveTransaction when the
+// transaction ID has already been recorded.
+var ErrDuplicateTransaction = errors.New("transaction already exists")
+
+// ErrDuplicateDraw is wrapped by OpenDraw when the draw ID is taken
+var ErrDuplicateDraw = errors.New("draw already exists")
+
+// ErrConflict is wrapped when a write gave up because another writer kept
+// changing the same data
+var ErrConflict = errors.New("concurrent update conflict")
+
+// CacheStats counts reads a storage served from memory (hits) and reads
+// that went to the backing store (misses)
+type CacheStats struct {
+    Hits   int64
+    Misses int64
+}
+
+// CachedStorage is implemented by storages that cache reads in memory
+type CachedStorage interface {
+    CacheStats() CacheStats
+}
+
 // NewStorageError creates a new StorageError
 func NewStorageError(operation string, err error) *StorageError {
     return &StorageError{
@@ -25,6 +53,23 @@ func NewStorageError(operation string, err error) *StorageError {
     }
 }
 
+// AdminActionFilter narrows the admin actions returned by GetAdminActions.
+// Zero values match everything; Limit 0 means no limit.
+type AdminActionFilter struct {
+    AdminID  int64
+    FromDate time.Time
+    ToDate   time.Time
+    Offset   int
+    Limit    int
+}
+
+// DrawFilter narrows the draws returned by ListDraws. Zero values match
+// everything.
+type DrawFilter struct {
+    Status       string
+    TicketAmount float64
+}
+
 // Storage defines the interface for data persistence
 type Storage interface {
     // User operations
@@ -33,14 +78,50 @@ type Storage interface {
     GetAllUsers(ctx context.Context, fromDate, toDate time.Time) ([]*models.User, error)
 
     // Transaction operations
+    // SaveTransaction checks for a duplicate ID and saves atomically, so
+    // two concurrent saves of the same ID cannot both succeed.
     SaveTransaction(ctx context.Context, txn *models.Transaction) error
     GetTransaction(ctx context.Context, txnID string) (*models.Transaction, error)
     GetTransactionsByDate(ctx context.Context, date time.Time) ([]*models.Transaction, error)
     IsTransactionUsed(ctx context.Context, txnID string) (bool, error)
+    GetTransactionsByStatus(ctx context.Context, status string) ([]*models.Transaction, error)
+    UpdateTransactionStatus(ctx context.Context, txnID, status string) error
+    // RefundTransaction marks a transaction refunded and records the refund reference
+    RefundTransaction(ctx context.Context, txnID, refundRef string, refundedAt time.Time) error
+    // SetTransactionScreenshot attaches a payment screenshot sent after the transaction was saved
+    SetTransactionScreenshot(ctx context.Context, txnID, fileID, fileType string) error
 
     // Lottery entry operations
     SaveLotteryEntry(ctx context.Context, entry *models.LotteryEntry) error
     GetEntriesByDate(ctx context.Context, date time.Time) ([]*models.LotteryEntry, error)
+    GetEntriesByTransaction(ctx context.Context, txnID string) ([]*models.LotteryEntry, error)
+    GetEntriesByDraw(ctx context.Context, drawID string) ([]*models.LotteryEntry, error)
+    UpdateEntryDraw(ctx context.Context, entryIDs []string, drawID string) error
+    UpdateEntryStatus(ctx context.Context, entryIDs []string, status string) error
+
+    // Winner operations
+    SaveWinner(ctx context.Context, winner *models.Winner) error
+    GetWinnersByDate(ctx context.Context, date time.Time) ([]*models.Winner, error)
+    GetWinnersByUser(ctx context.Context, userID int64) ([]*models.Winner, error)
+    GetWinner(ctx context.Context, winnerID string) (*models.Winner, error)
+    GetWinnersByPaymentStatus(ctx context.Context, status string) ([]*models.Winner, error)
+    UpdateWinnerPaymentStatus(ctx context.Context, winnerID, status, paymentTxnID string) error
+
+    // Draw operations
+    // OpenDraw saves a new draw with status "open"; it fails with
+    // ErrDuplicateDraw if the ID is taken.
+    OpenDraw(ctx context.Context, draw *models.Draw) error
+    // CloseDraw stops an open draw from taking new entries
+    CloseDraw(ctx context.Context, drawID string, closedAt time.Time) error
+    // SaveDraw creates or replaces a draw, e.g. with its result
+    SaveDraw(ctx context.Context, draw *models.Draw) error
+    GetDraw(ctx context.Context, drawID string) (*models.Draw, error)
+    // ListDraws returns matching draws, oldest first
+    ListDraws(ctx context.Context, filter DrawFilter) ([]*models.Draw, error)
+
+    // Admin action operations
+    SaveAdminAction(ctx context.Context, action *models.AdminAction) error
+    GetAdminActions(ctx context.Context, filter AdminActionFilter) ([]*models.AdminAction, error)
 
     // User state operations
     SaveUserState(ctx context.Context, state *models.UserState) error
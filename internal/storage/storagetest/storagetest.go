@@ -0,0 +1,623 @@
+// Package storagetest provides a conformance suite that every
+// storage.Storage implementation must pass.
+package storagetest
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/gsshankar104/telegram-bot/internal/models"
+    "github.com/gsshankar104/telegram-bot/internal/storage"
+)
+
+// Factory returns a new, empty storage for a single subtest
+type Factory func(t *testing.T) storage.Storage
+
+// base is a fixed mid-day timestamp so date range checks are stable
+var base = time.Date(2024, time.March, 15, 12, 0, 0, 0, time.Local)
+
+// Run executes the conformance suite against the storage returned by newStorage
+func Run(t *testing.T, newStorage Factory) {
+    tests := []struct {
+        name string
+        fn   func(t *testing.T, s storage.Storage)
+    }{
+        {"Users", testUsers},
+        {"UserNotFound", testUserNotFound},
+        {"AllUsersDateFilter", testAllUsersDateFilter},
+        {"Transactions", testTransactions},
+        {"DuplicateTransaction", testDuplicateTransaction},
+        {"Entries", testEntries},
+        {"Winners", testWinners},
+        {"Draws", testDraws},
+        {"DrawLifecycle", testDrawLifecycle},
+        {"AdminActions", testAdminActions},
+        {"UserState", testUserState},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.fn(t, newStorage(t))
+        })
+    }
+}
+
+func assertNotFound(t *testing.T, err error, operation string) {
+    t.Helper()
+
+    var storageErr *storage.StorageError
+    if !errors.As(err, &storageErr) {
+        t.Fatalf("expected *storage.StorageError, got %T (%v)", err, err)
+    }
+    if storageErr.Operation != operation {
+        t.Errorf("Operation = %q, want %q", storageErr.Operation, operation)
+    }
+    if !strings.Contains(storageErr.Error(), "not found") {
+        t.Errorf("error %q does not mention \"not found\"", storageErr.Error())
+    }
+}
+
+func testUsers(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    user := &models.User{UserID: 1, Username: "alice", FirstName: "Alice", JoinedDate: base, Status: "active"}
+    if err := s.SaveUser(ctx, user); err != nil {
+        t.Fatalf("SaveUser: %v", err)
+    }
+
+    got, err := s.GetUser(ctx, 1)
+    if err != nil {
+        t.Fatalf("GetUser: %v", err)
+    }
+    if got.Username != "alice" || got.FirstName != "Alice" || got.Status != "active" || !got.JoinedDate.Equal(base) {
+        t.Errorf("GetUser = %+v, want %+v", got, user)
+    }
+
+    // Mutating a returned value must not change the store
+    got.Status = "blocked"
+    again, _ := s.GetUser(ctx, 1)
+    if again.Status != "active" {
+        t.Errorf("store shares state with callers: status = %q", again.Status)
+    }
+
+    // Saving the same user again replaces it
+    user.Status = "blocked"
+    if err := s.SaveUser(ctx, user); err != nil {
+        t.Fatalf("SaveUser (update): %v", err)
+    }
+    all, err := s.GetAllUsers(ctx, time.Time{}, time.Time{})
+    if err != nil {
+        t.Fatalf("GetAllUsers: %v", err)
+    }
+    if len(all) != 1 || all[0].Status != "blocked" {
+        t.Errorf("GetAllUsers after update = %+v, want one blocked user", all)
+    }
+}
+
+func testUserNotFound(t *testing.T, s storage.Storage) {
+    _, err := s.GetUser(context.Background(), 42)
+    assertNotFound(t, err, "GetUser")
+}
+
+func testAllUsersDateFilter(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    for i, joined := range []time.Time{base.AddDate(0, 0, -2), base, base.AddDate(0, 0, 2)} {
+        if err := s.SaveUser(ctx, &models.User{UserID: int64(i + 1), JoinedDate: joined}); err != nil {
+            t.Fatalf("SaveUser: %v", err)
+        }
+    }
+
+    users, err := s.GetAllUsers(ctx, base.AddDate(0, 0, -1), base.AddDate(0, 0, 1))
+    if err != nil {
+        t.Fatalf("GetAllUsers: %v", err)
+    }
+    if len(users) != 1 || users[0].UserID != 2 {
+        t.Errorf("GetAllUsers(range) = %+v, want user 2 only", users)
+    }
+
+    users, err = s.GetAllUsers(ctx, time.Time{}, time.Time{})
+    if err != nil {
+        t.Fatalf("GetAllUsers: %v", err)
+    }
+    if len(users) != 3 {
+        t.Errorf("GetAllUsers(all) returned %d users, want 3", len(users))
+    }
+}
+
+func testTransactions(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    used, err := s.IsTransactionUsed(ctx, "TXN1")
+    if err != nil || used {
+        t.Fatalf("IsTransactionUsed before save = %v, %v; want false, nil", used, err)
+    }
+
+    _, err = s.GetTransaction(ctx, "TXN1")
+    assertNotFound(t, err, "GetTransaction")
+
+    txns := []*models.Transaction{
+        {TransactionID: "TXN1", UserID: 1, Amount: 100, Date: base, Time: base, Status: "pending", UniqueCode: "LC1",
+            ScreenshotFileID: "BQACAgQAAxkBAAIC", ScreenshotType: "document"},
+        {TransactionID: "TXN2", UserID: 2, Amount: 200, Date: base.AddDate(0, 0, 1), Time: base.AddDate(0, 0, 1), Status: "pending",
+            Provider: "upigateway", ProviderRef: "GW42"},
+    }
+    for _, txn := range txns {
+        if err := s.SaveTransaction(ctx, txn); err != nil {
+            t.Fatalf("SaveTransaction: %v", err)
+        }
+    }
+
+    used, err = s.IsTransactionUsed(ctx, "TXN1")
+    if err != nil || !used {
+        t.Errorf("IsTransactionUsed after save = %v, %v; want true, nil", used, err)
+    }
+
+    got, err := s.GetTransaction(ctx, "TXN1")
+    if err != nil {
+        t.Fatalf("GetTransaction: %v", err)
+    }
+    if got.UserID != 1 || got.Amount != 100 || got.Status != "pending" || !got.Date.Equal(base) ||
+        got.UniqueCode != "LC1" || got.ScreenshotFileID != "BQACAgQAAxkBAAIC" || got.ScreenshotType != "document" {
+        t.Errorf("GetTransaction = %+v", got)
+    }
+
+    got, _ = s.GetTransaction(ctx, "TXN2")
+    if got.Provider != "upigateway" || got.ProviderRef != "GW42" {
+        t.Errorf("GetTransaction(TXN2) provider = %q/%q", got.Provider, got.ProviderRef)
+    }
+
+    byDate, err := s.GetTransactionsByDate(ctx, base)
+    if err != nil {
+        t.Fatalf("GetTransactionsByDate: %v", err)
+    }
+    if len(byDate) != 1 || byDate[0].TransactionID != "TXN1" {
+        t.Errorf("GetTransactionsByDate = %+v, want TXN1 only", byDate)
+    }
+
+    if err := s.UpdateTransactionStatus(ctx, "TXN2", "verified"); err != nil {
+        t.Fatalf("UpdateTransactionStatus: %v", err)
+    }
+    pending, err := s.GetTransactionsByStatus(ctx, "pending")
+    if err != nil {
+        t.Fatalf("GetTransactionsByStatus: %v", err)
+    }
+    if len(pending) != 1 || pending[0].TransactionID != "TXN1" {
+        t.Errorf("GetTransactionsByStatus(pending) = %+v, want TXN1 only", pending)
+    }
+    verified, _ := s.GetTransactionsByStatus(ctx, "verified")
+    if len(verified) != 1 || verified[0].TransactionID != "TXN2" {
+        t.Errorf("GetTransactionsByStatus(verified) = %+v, want TXN2 only", verified)
+    }
+
+    err = s.UpdateTransactionStatus(ctx, "missing", "verified")
+    assertNotFound(t, err, "UpdateTransactionStatus")
+
+    refundedAt := base.Add(48 * time.Hour)
+    if err := s.RefundTransaction(ctx, "TXN2", "RF-77", refundedAt); err != nil {
+        t.Fatalf("RefundTransaction: %v", err)
+    }
+    got, _ = s.GetTransaction(ctx, "TXN2")
+    if got.Status != "refunded" || got.RefundRef != "RF-77" || !got.RefundedAt.Equal(refundedAt) {
+        t.Errorf("refunded transaction = %+v", got)
+    }
+
+    err = s.RefundTransaction(ctx, "missing", "RF-78", refundedAt)
+    assertNotFound(t, err, "RefundTransaction")
+
+    if err := s.SetTransactionScreenshot(ctx, "TXN2", "FILE9", "photo"); err != nil {
+        t.Fatalf("SetTransactionScreenshot: %v", err)
+    }
+    got, _ = s.GetTransaction(ctx, "TXN2")
+    if got.ScreenshotFileID != "FILE9" || got.ScreenshotType != "photo" || got.Status != "refunded" {
+        t.Errorf("transaction with screenshot = %+v", got)
+    }
+
+    err = s.SetTransactionScreenshot(ctx, "missing", "FILE9", "photo")
+    assertNotFound(t, err, "SetTransactionScreenshot")
+}
+
+func testDuplicateTransaction(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    const racers = 8
+    var wg sync.WaitGroup
+    errs := make(chan error, racers)
+    for i := 0; i < racers; i++ {
+        wg.Add(1)
+        go func(userID int64) {
+            defer wg.Done()
+            errs <- s.SaveTransaction(ctx, &models.Transaction{
+                TransactionID: "DUP1",
+                UserID:        userID,
+                Amount:        100,
+                Date:          base,
+                Time:          base,
+                Status:        "pending",
+            })
+        }(int64(i + 1))
+    }
+    wg.Wait()
+    close(errs)
+
+    succeeded := 0
+    for err := range errs {
+        if err == nil {
+            succeeded++
+            continue
+        }
+        if !errors.Is(err, storage.ErrDuplicateTransaction) {
+            t.Errorf("SaveTransaction error = %v, want ErrDuplicateTransaction", err)
+        }
+        var storageErr *storage.StorageError
+        if !errors.As(err, &storageErr) {
+            t.Errorf("SaveTransaction error %T is not a *storage.StorageError", err)
+        }
+    }
+    if succeeded != 1 {
+        t.Errorf("%d concurrent saves of the same ID succeeded, want 1", succeeded)
+    }
+
+    byDate, _ := s.GetTransactionsByDate(ctx, base)
+    if len(byDate) != 1 {
+        t.Errorf("stored %d transactions, want 1", len(byDate))
+    }
+}
+
+func testEntries(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    entries := []*models.LotteryEntry{
+        {EntryID: "E1", UserID: 1, TicketAmount: 100, TransactionID: "T1", LuckyNumber: 7, EntryDate: base, EntryTime: base, Status: "active", DrawID: "D1"},
+        {EntryID: "E2", UserID: 2, TicketAmount: 100, TransactionID: "T2", LuckyNumber: 8, EntryDate: base.Add(time.Hour), EntryTime: base.Add(time.Hour), Status: "active", DrawID: "D2"},
+        {EntryID: "E3", UserID: 3, TicketAmount: 100, TransactionID: "T3", LuckyNumber: 9, EntryDate: base.AddDate(0, 0, 1), EntryTime: base.AddDate(0, 0, 1), Status: "active", DrawID: "D1"},
+    }
+    for _, entry := range entries {
+        if err := s.SaveLotteryEntry(ctx, entry); err != nil {
+            t.Fatalf("SaveLotteryEntry: %v", err)
+        }
+    }
+
+    got, err := s.GetEntriesByDate(ctx, base)
+    if err != nil {
+        t.Fatalf("GetEntriesByDate: %v", err)
+    }
+    if ids := entryIDs(got); ids != "E1,E2" {
+        t.Errorf("GetEntriesByDate = %s, want E1,E2", ids)
+    }
+
+    byTxn, err := s.GetEntriesByTransaction(ctx, "T3")
+    if err != nil {
+        t.Fatalf("GetEntriesByTransaction: %v", err)
+    }
+    if ids := entryIDs(byTxn); ids != "E3" {
+        t.Errorf("GetEntriesByTransaction = %s, want E3", ids)
+    }
+
+    // A draw's entries may span calendar days
+    byDraw, err := s.GetEntriesByDraw(ctx, "D1")
+    if err != nil {
+        t.Fatalf("GetEntriesByDraw: %v", err)
+    }
+    if ids := entryIDs(byDraw); ids != "E1,E3" {
+        t.Errorf("GetEntriesByDraw = %s, want E1,E3", ids)
+    }
+
+    if err := s.UpdateEntryDraw(ctx, []string{"E2"}, "D1"); err != nil {
+        t.Fatalf("UpdateEntryDraw: %v", err)
+    }
+    byDraw, _ = s.GetEntriesByDraw(ctx, "D1")
+    if ids := entryIDs(byDraw); ids != "E1,E2,E3" {
+        t.Errorf("GetEntriesByDraw after move = %s, want E1,E2,E3", ids)
+    }
+    if byDraw, _ = s.GetEntriesByDraw(ctx, "D2"); len(byDraw) != 0 {
+        t.Errorf("GetEntriesByDraw(D2) = %s, want none", entryIDs(byDraw))
+    }
+
+    if err := s.UpdateEntryStatus(ctx, []string{"E1"}, "winner"); err != nil {
+        t.Fatalf("UpdateEntryStatus: %v", err)
+    }
+    if err := s.UpdateEntryStatus(ctx, []string{"E2"}, "expired"); err != nil {
+        t.Fatalf("UpdateEntryStatus: %v", err)
+    }
+
+    got, _ = s.GetEntriesByDate(ctx, base)
+    statuses := map[string]string{}
+    for _, entry := range got {
+        statuses[entry.EntryID] = entry.Status
+    }
+    if statuses["E1"] != "winner" || statuses["E2"] != "expired" {
+        t.Errorf("statuses after update = %v", statuses)
+    }
+}
+
+func entryIDs(entries []*models.LotteryEntry) string {
+    var ids []string
+    for _, entry := range entries {
+        ids = append(ids, entry.EntryID)
+    }
+    return strings.Join(ids, ",")
+}
+
+func testWinners(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    winners := []*models.Winner{
+        {WinnerID: "W1", UserID: 1, EntryID: "E1", WinningAmount: 500, Date: base, Time: base, PaymentStatus: "pending", DrawID: "D1"},
+        {WinnerID: "W2", UserID: 2, EntryID: "E2", WinningAmount: 500, Date: base.AddDate(0, 0, 1), Time: base.AddDate(0, 0, 1), PaymentStatus: "pending"},
+    }
+    for _, winner := range winners {
+        if err := s.SaveWinner(ctx, winner); err != nil {
+            t.Fatalf("SaveWinner: %v", err)
+        }
+    }
+
+    byDate, err := s.GetWinnersByDate(ctx, base)
+    if err != nil {
+        t.Fatalf("GetWinnersByDate: %v", err)
+    }
+    if len(byDate) != 1 || byDate[0].WinnerID != "W1" {
+        t.Errorf("GetWinnersByDate = %+v, want W1 only", byDate)
+    }
+
+    byUser, err := s.GetWinnersByUser(ctx, 2)
+    if err != nil {
+        t.Fatalf("GetWinnersByUser: %v", err)
+    }
+    if len(byUser) != 1 || byUser[0].WinnerID != "W2" {
+        t.Errorf("GetWinnersByUser = %+v, want W2 only", byUser)
+    }
+
+    if err := s.UpdateWinnerPaymentStatus(ctx, "W1", "completed", "PAYOUT1"); err != nil {
+        t.Fatalf("UpdateWinnerPaymentStatus: %v", err)
+    }
+    got, err := s.GetWinner(ctx, "W1")
+    if err != nil {
+        t.Fatalf("GetWinner: %v", err)
+    }
+    if got.UserID != 1 || got.PaymentStatus != "completed" || got.PaymentTransactionID != "PAYOUT1" || got.DrawID != "D1" {
+        t.Errorf("winner after payout = %+v", got)
+    }
+
+    owed, err := s.GetWinnersByPaymentStatus(ctx, "pending")
+    if err != nil {
+        t.Fatalf("GetWinnersByPaymentStatus: %v", err)
+    }
+    if len(owed) != 1 || owed[0].WinnerID != "W2" {
+        t.Errorf("GetWinnersByPaymentStatus(pending) = %+v, want W2 only", owed)
+    }
+
+    _, err = s.GetWinner(ctx, "missing")
+    assertNotFound(t, err, "GetWinner")
+
+    err = s.UpdateWinnerPaymentStatus(ctx, "missing", "completed", "X")
+    assertNotFound(t, err, "UpdateWinnerPaymentStatus")
+}
+
+func testDraws(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    draw := &models.Draw{
+        DrawID:         "D1",
+        TicketAmount:   100,
+        Status:         "drawn",
+        Method:         "random",
+        WinnerCount:    2,
+        Seed:           "12345",
+        SeedHash:       "5994471abb01112afcc18159f6cc74b4f511b99806da59b3caf5a9c173cacfc5",
+        Date:           base,
+        OpenedAt:       base.Add(-24 * time.Hour),
+        ClosedAt:       base.Add(-time.Minute),
+        DrawnAt:        base,
+        EntryCount:     12,
+        WinningNumbers: []int{7, 42},
+    }
+    if err := s.SaveDraw(ctx, draw); err != nil {
+        t.Fatalf("SaveDraw: %v", err)
+    }
+
+    draw.AnnouncementChatID = -1001234567890
+    draw.AnnouncementMessageID = 55
+    if err := s.SaveDraw(ctx, draw); err != nil {
+        t.Fatalf("SaveDraw (update): %v", err)
+    }
+
+    got, err := s.GetDraw(ctx, "D1")
+    if err != nil {
+        t.Fatalf("GetDraw: %v", err)
+    }
+    if got.TicketAmount != 100 || got.Status != "drawn" || got.Method != "random" || got.WinnerCount != 2 ||
+        got.Seed != "12345" || got.SeedHash != draw.SeedHash || got.EntryCount != 12 || !got.Date.Equal(base) ||
+        !got.OpenedAt.Equal(base.Add(-24*time.Hour)) || !got.ClosedAt.Equal(base.Add(-time.Minute)) ||
+        !got.DrawnAt.Equal(base) ||
+        len(got.WinningNumbers) != 2 || got.WinningNumbers[0] != 7 || got.WinningNumbers[1] != 42 ||
+        got.AnnouncementChatID != -1001234567890 || got.AnnouncementMessageID != 55 {
+        t.Errorf("GetDraw = %+v", got)
+    }
+
+    _, err = s.GetDraw(ctx, "missing")
+    assertNotFound(t, err, "GetDraw")
+}
+
+func testDrawLifecycle(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    opened := []struct {
+        id     string
+        amount float64
+        at     time.Duration
+    }{
+        {"D2", 100, 2 * time.Hour},
+        {"D1", 100, time.Hour},
+        {"D3", 200, 3 * time.Hour},
+    }
+    for _, o := range opened {
+        draw := &models.Draw{
+            DrawID:       o.id,
+            TicketAmount: o.amount,
+            Status:       "drawn", // OpenDraw always opens
+            Method:       "random",
+            WinnerCount:  1,
+            OpenedAt:     base.Add(o.at),
+        }
+        if err := s.OpenDraw(ctx, draw); err != nil {
+            t.Fatalf("OpenDraw(%s): %v", o.id, err)
+        }
+    }
+
+    err := s.OpenDraw(ctx, &models.Draw{DrawID: "D1", TicketAmount: 100, OpenedAt: base})
+    if !errors.Is(err, storage.ErrDuplicateDraw) {
+        t.Errorf("OpenDraw duplicate: err = %v, want ErrDuplicateDraw", err)
+    }
+
+    open, err := s.ListDraws(ctx, storage.DrawFilter{Status: "open", TicketAmount: 100})
+    if err != nil {
+        t.Fatalf("ListDraws: %v", err)
+    }
+    if ids := drawIDs(open); ids != "D1,D2" {
+        t.Errorf("ListDraws(open, 100) = %s, want D1,D2 oldest first", ids)
+    }
+
+    if err := s.CloseDraw(ctx, "D1", base.Add(3*time.Hour)); err != nil {
+        t.Fatalf("CloseDraw: %v", err)
+    }
+    if err := s.CloseDraw(ctx, "D1", base.Add(4*time.Hour)); err == nil {
+        t.Error("CloseDraw on a closed draw succeeded")
+    }
+    err = s.CloseDraw(ctx, "missing", base)
+    assertNotFound(t, err, "CloseDraw")
+
+    got, err := s.GetDraw(ctx, "D1")
+    if err != nil {
+        t.Fatalf("GetDraw: %v", err)
+    }
+    if got.Status != "closed" || !got.ClosedAt.Equal(base.Add(3*time.Hour)) {
+        t.Errorf("closed draw = %+v", got)
+    }
+
+    closed, _ := s.ListDraws(ctx, storage.DrawFilter{Status: "closed"})
+    if ids := drawIDs(closed); ids != "D1" {
+        t.Errorf("ListDraws(closed) = %s, want D1", ids)
+    }
+    all, _ := s.ListDraws(ctx, storage.DrawFilter{})
+    if ids := drawIDs(all); ids != "D1,D2,D3" {
+        t.Errorf("ListDraws() = %s, want D1,D2,D3", ids)
+    }
+}
+
+func drawIDs(draws []*models.Draw) string {
+    var ids []string
+    for _, d := range draws {
+        ids = append(ids, d.DrawID)
+    }
+    return strings.Join(ids, ",")
+}
+
+func testAdminActions(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    for i := 0; i < 5; i++ {
+        action := &models.AdminAction{
+            ActionID:   fmt.Sprintf("A%d", i+1),
+            AdminID:    int64(10 + i%2),
+            ActionType: "view_data",
+            Details:    "{}",
+            Timestamp:  base.AddDate(0, 0, i),
+        }
+        if err := s.SaveAdminAction(ctx, action); err != nil {
+            t.Fatalf("SaveAdminAction: %v", err)
+        }
+    }
+
+    all, err := s.GetAdminActions(ctx, storage.AdminActionFilter{})
+    if err != nil {
+        t.Fatalf("GetAdminActions: %v", err)
+    }
+    if ids := actionIDs(all); ids != "A5,A4,A3,A2,A1" {
+        t.Errorf("GetAdminActions = %s, want newest first", ids)
+    }
+
+    byAdmin, _ := s.GetAdminActions(ctx, storage.AdminActionFilter{AdminID: 11})
+    if ids := actionIDs(byAdmin); ids != "A4,A2" {
+        t.Errorf("GetAdminActions(admin) = %s, want A4,A2", ids)
+    }
+
+    byDate, _ := s.GetAdminActions(ctx, storage.AdminActionFilter{
+        FromDate: base.AddDate(0, 0, 1),
+        ToDate:   base.AddDate(0, 0, 3),
+    })
+    if ids := actionIDs(byDate); ids != "A4,A3,A2" {
+        t.Errorf("GetAdminActions(range) = %s, want A4,A3,A2", ids)
+    }
+
+    page, _ := s.GetAdminActions(ctx, storage.AdminActionFilter{Offset: 2, Limit: 2})
+    if ids := actionIDs(page); ids != "A3,A2" {
+        t.Errorf("GetAdminActions(page) = %s, want A3,A2", ids)
+    }
+
+    past, _ := s.GetAdminActions(ctx, storage.AdminActionFilter{Offset: 10})
+    if len(past) != 0 {
+        t.Errorf("GetAdminActions(offset past end) = %s, want empty", actionIDs(past))
+    }
+}
+
+func actionIDs(actions []*models.AdminAction) string {
+    var ids []string
+    for _, action := range actions {
+        ids = append(ids, action.ActionID)
+    }
+    return strings.Join(ids, ",")
+}
+
+func testUserState(t *testing.T, s storage.Storage) {
+    ctx := context.Background()
+
+    state, err := s.GetUserState(ctx, 7)
+    if err != nil {
+        t.Fatalf("GetUserState (missing): %v", err)
+    }
+    if state.UserID != 7 || state.CurrentState != "" || state.InvalidAttempts != 0 {
+        t.Errorf("default state = %+v", state)
+    }
+
+    state.CurrentState = "awaiting_transaction_id"
+    state.SelectedAmount = 100
+    state.InvalidAttempts = 1
+    state.ScreenshotFileID = "AgACAgQAAxkBAAIB"
+    state.ScreenshotType = "photo"
+    state.WinnerID = "W1"
+    if err := s.SaveUserState(ctx, state); err != nil {
+        t.Fatalf("SaveUserState: %v", err)
+    }
+
+    got, err := s.GetUserState(ctx, 7)
+    if err != nil {
+        t.Fatalf("GetUserState: %v", err)
+    }
+    if got.CurrentState != "awaiting_transaction_id" || got.SelectedAmount != 100 || got.InvalidAttempts != 1 ||
+        got.ScreenshotFileID != "AgACAgQAAxkBAAIB" || got.ScreenshotType != "photo" || got.WinnerID != "W1" {
+        t.Errorf("GetUserState = %+v", got)
+    }
+
+    got.CurrentState = "awaiting_lucky_number"
+    if err := s.SaveUserState(ctx, got); err != nil {
+        t.Fatalf("SaveUserState (update): %v", err)
+    }
+    got, _ = s.GetUserState(ctx, 7)
+    if got.CurrentState != "awaiting_lucky_number" {
+        t.Errorf("state after update = %q", got.CurrentState)
+    }
+
+    if err := s.DeleteUserState(ctx, 7); err != nil {
+        t.Fatalf("DeleteUserState: %v", err)
+    }
+    got, _ = s.GetUserState(ctx, 7)
+    if got.CurrentState != "" {
+        t.Errorf("state after delete = %q, want default", got.CurrentState)
+    }
+}